@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+// requireAdmin authenticates admin requests with the ADMIN_TOKEN bearer
+// token. Admin endpoints are disabled when no token is configured.
+func (server *Server) requireAdmin(ctx *gin.Context) {
+	if server.config.AdminToken == "" {
+		respondError(ctx, http.StatusServiceUnavailable, errors.New("admin API disabled: no admin token configured"))
+		ctx.Abort()
+		return
+	}
+	token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(server.config.AdminToken)) != 1 {
+		respondError(ctx, http.StatusUnauthorized, errors.New("invalid or missing admin token"))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+func (server *Server) getSettings(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.tuning.Get())
+}
+
+func (server *Server) updateSettings(ctx *gin.Context) {
+	var req models.SettingsPatch
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	actor := ctx.GetHeader("X-Admin-User")
+	if actor == "" {
+		actor = "admin"
+	}
+	ctx.JSON(http.StatusOK, server.tuning.Update(actor, req))
+}
+
+func (server *Server) getAudit(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.tuning.Audit())
+}
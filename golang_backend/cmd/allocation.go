@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/allocation"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getAllocations(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.allocator.Snapshot())
+}
+
+func (server *Server) registerStrategy(ctx *gin.Context) {
+	var req models.AllocationStrategyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	server.allocator.Register(allocation.Strategy{
+		Name:        req.Name,
+		Weight:      req.Weight,
+		Volatility:  req.Volatility,
+		Performance: req.Performance,
+	})
+	ctx.JSON(http.StatusOK, server.allocator.Snapshot())
+}
+
+func (server *Server) removeStrategy(ctx *gin.Context) {
+	if err := server.allocator.Remove(ctx.Param("name")); err != nil {
+		respondError(ctx, http.StatusNotFound, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, server.allocator.Snapshot())
+}
+
+func (server *Server) rebalance(ctx *gin.Context) {
+	var req models.RebalanceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	equity := req.Equity
+	if equity == 0 {
+		equity = server.supervisor.Status().Account.Equity
+	}
+
+	allocations, err := server.allocator.Rebalance(equity, allocation.Method(req.Method))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, allocations)
+}
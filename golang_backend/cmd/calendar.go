@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/abs/go_billing/internal/calendar"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) listCalendars(ctx *gin.Context) {
+	now := server.clock.Now()
+	statuses := []calendar.Status{}
+	for _, name := range server.calendars.Names() {
+		c, _ := server.calendars.Get(name)
+		statuses = append(statuses, c.Status(now))
+	}
+	ctx.JSON(http.StatusOK, statuses)
+}
+
+func (server *Server) getCalendar(ctx *gin.Context) {
+	c, ok := server.calendarParam(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, c.Status(server.clock.Now()))
+}
+
+func (server *Server) resampleDaily(ctx *gin.Context) {
+	c, ok := server.calendarParam(ctx)
+	if !ok {
+		return
+	}
+	var req models.CandlesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, c.Daily(req.Candles))
+}
+
+// calendarParam looks up the calendar named in the path, responding 404
+// when there is none.
+func (server *Server) calendarParam(ctx *gin.Context) (calendar.Calendar, bool) {
+	name := ctx.Param("name")
+	c, ok := server.calendars.Get(name)
+	if !ok {
+		respondError(ctx, http.StatusNotFound, fmt.Errorf("unknown calendar %q", name))
+	}
+	return c, ok
+}
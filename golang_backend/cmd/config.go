@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/abs/go_billing/internal/calendar"
+	"github.com/abs/go_billing/internal/risk"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Files read from CONFIG_DIR. Each is optional and only applied when it is
+// added or modified: settings.json then patches the settings it names and
+// constraints.json replaces the constraints of every strategy, including
+// ones set through the API since.
+const (
+	settingsFile    = "settings.json"
+	instrumentsFile = "instruments.json"
+	constraintsFile = "constraints.json"
+	calendarsFile   = "calendars.json"
+)
+
+// applyConfig applies the changed files of the config directory. Every
+// file is parsed and validated before anything is swapped in, so a bad
+// file rejects the whole change.
+func (server *Server) applyConfig(files map[string][]byte) error {
+	var settings *models.SettingsPatch
+	if data, ok := files[settingsFile]; ok {
+		settings = &models.SettingsPatch{}
+		if err := decodeConfig(settingsFile, data, settings); err != nil {
+			return err
+		}
+	}
+
+	var instruments []models.Instrument
+	if data, ok := files[instrumentsFile]; ok {
+		if err := json.Unmarshal(data, &instruments); err != nil {
+			return fmt.Errorf("%s: %w", instrumentsFile, err)
+		}
+		for _, instrument := range instruments {
+			if err := binding.Validator.ValidateStruct(instrument); err != nil {
+				return fmt.Errorf("%s: %s: %w", instrumentsFile, instrument.Symbol, err)
+			}
+		}
+	}
+
+	// calendars.json maps calendar names to their holiday dates.
+	var holidays map[string]map[string]bool
+	if data, ok := files[calendarsFile]; ok {
+		var dates map[string][]string
+		if err := json.Unmarshal(data, &dates); err != nil {
+			return fmt.Errorf("%s: %w", calendarsFile, err)
+		}
+		holidays = make(map[string]map[string]bool, len(dates))
+		for name, list := range dates {
+			if _, ok := server.calendars.Get(name); !ok {
+				return fmt.Errorf("%s: unknown calendar %q", calendarsFile, name)
+			}
+			parsed, err := calendar.ParseHolidays(list)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", calendarsFile, name, err)
+			}
+			holidays[name] = parsed
+		}
+	}
+
+	var constraints map[string]models.TradingConstraints
+	if data, ok := files[constraintsFile]; ok {
+		if err := json.Unmarshal(data, &constraints); err != nil {
+			return fmt.Errorf("%s: %w", constraintsFile, err)
+		}
+		for strategy, c := range constraints {
+			if err := binding.Validator.ValidateStruct(c); err != nil {
+				return fmt.Errorf("%s: %s: %w", constraintsFile, strategy, err)
+			}
+			if err := risk.CheckConstraints(c); err != nil {
+				return fmt.Errorf("%s: %s: %w", constraintsFile, strategy, err)
+			}
+		}
+	}
+
+	// Everything is valid: swap it in. The calendars and constraints are
+	// each replaced in one step and checked again as they are.
+	if holidays != nil {
+		if err := server.calendars.SetAllHolidays(holidays); err != nil {
+			return fmt.Errorf("%s: %w", calendarsFile, err)
+		}
+	}
+	if constraints != nil {
+		if err := server.supervisor.ReplaceConstraints(constraints); err != nil {
+			return fmt.Errorf("%s: %w", constraintsFile, err)
+		}
+	}
+	if settings != nil {
+		server.tuning.Update("config:"+settingsFile, *settings)
+	}
+	for _, instrument := range instruments {
+		server.ledger.SetInstrument(instrument)
+	}
+	return nil
+}
+
+func decodeConfig(name string, data []byte, v any) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/bench"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) runBenchmarks(ctx *gin.Context) {
+	var req models.BenchRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Sizes) == 0 {
+		req.Sizes = bench.DefaultSizes
+	}
+	ctx.JSON(http.StatusOK, bench.Run(req.Sizes, req.Cases))
+}
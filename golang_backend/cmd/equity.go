@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/adjust"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+type adjustResponse struct {
+	Adjusted   []models.OHLC   `json:"adjusted"`
+	Unadjusted []models.OHLC   `json:"unadjusted"`
+	Factors    []adjust.Factor `json:"factors"`
+}
+
+func (server *Server) adjustCandles(ctx *gin.Context) {
+	var req models.AdjustRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	adjusted, factors, err := adjust.Candles(req.Candles, req.Actions, req.SplitsOnly)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, adjustResponse{
+		Adjusted:   adjusted,
+		Unadjusted: req.Candles,
+		Factors:    factors,
+	})
+}
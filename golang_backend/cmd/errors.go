@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Error codes returned in the code field of error responses. Clients
+// should branch on these rather than on messages.
+const (
+	CodeValidation   = "VALIDATION_ERROR"
+	CodeDataQuality  = "DATA_QUALITY_ERROR"
+	CodeUnauthorized = "UNAUTHORIZED"
+	CodeNotFound     = "NOT_FOUND"
+	CodeConflict     = "CONFLICT"
+	CodeUpstream     = "UPSTREAM_ERROR"
+	CodeUnavailable  = "SERVICE_UNAVAILABLE"
+	CodeInternal     = "INTERNAL_ERROR"
+)
+
+// apiError is the body of every error response, under the error key.
+// Field names the offending request field, in its JSON spelling, when the
+// error is tied to one.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// statusCodes maps response statuses to error codes. Handlers use 400 for
+// malformed or invalid requests, 422 for well-formed input the analysis
+// cannot use and 502 for failures of upstream data providers.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          CodeValidation,
+	http.StatusUnprocessableEntity: CodeDataQuality,
+	http.StatusUnauthorized:        CodeUnauthorized,
+	http.StatusNotFound:            CodeNotFound,
+	http.StatusConflict:            CodeConflict,
+	http.StatusBadGateway:          CodeUpstream,
+	http.StatusServiceUnavailable:  CodeUnavailable,
+}
+
+// useJSONFieldNames makes validation failures report the JSON or query
+// name of a field rather than its Go name.
+func useJSONFieldNames() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{"json", "form"} {
+				name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+				if name == "-" {
+					return ""
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	}
+}
+
+// respondError writes an error response with the code of status. A nil err
+// is reported with the status text.
+func respondError(ctx *gin.Context, status int, err error) {
+	ctx.JSON(status, errorResponse(status, err))
+}
+
+func errorResponse(status int, err error) gin.H {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = CodeInternal
+	}
+	body := apiError{Code: code, Message: http.StatusText(status)}
+	if err == nil {
+		return gin.H{"error": body}
+	}
+	body.Message = err.Error()
+
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs) && len(validationErrs) > 0:
+		fe := validationErrs[0]
+		body.Field = fieldPath(fe.Namespace())
+		body.Message = fmt.Sprintf("%s failed the %s check", body.Field, fe.Tag())
+		body.Hint = validationHint(fe)
+		if len(validationErrs) > 1 {
+			body.Message += fmt.Sprintf(" (and %d more)", len(validationErrs)-1)
+		}
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		body.Hint = "the body is empty or truncated"
+	case errors.As(err, &syntaxErr):
+		body.Hint = fmt.Sprintf("the body is not valid JSON near offset %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		body.Field = typeErr.Field
+		body.Hint = fmt.Sprintf("expected a %s", typeErr.Type)
+	case code == CodeUpstream:
+		body.Hint = "the data provider failed; retry later"
+	}
+	return gin.H{"error": body}
+}
+
+// fieldPath drops the request type from a validator namespace, turning
+// "PatternRequest.candles[0].time" into "candles[0].time". The Go names of
+// embedded structs, which have no JSON name, are dropped as well.
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	segments := strings.Split(path, ".")
+	kept := segments[:0]
+	for _, segment := range segments {
+		if segment != "" && unicode.IsUpper(rune(segment[0])) {
+			continue
+		}
+		kept = append(kept, segment)
+	}
+	return strings.Join(kept, ".")
+}
+
+func validationHint(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "the field is required"
+	case "required_without":
+		return fmt.Sprintf("required when %s is not set", strings.ToLower(fe.Param()))
+	case "required_with":
+		return fmt.Sprintf("required when %s is set", strings.ToLower(fe.Param()))
+	case "required_if":
+		if field, value, ok := strings.Cut(fe.Param(), " "); ok {
+			return fmt.Sprintf("required when %s is %s", strings.ToLower(field), value)
+		}
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "min":
+		if k := fe.Kind(); k == reflect.Slice || k == reflect.Map || k == reflect.String {
+			return "must have at least " + fe.Param() + " items"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if k := fe.Kind(); k == reflect.Slice || k == reflect.Map || k == reflect.String {
+			return "must have at most " + fe.Param() + " items"
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "lte":
+		return "must be at most " + fe.Param()
+	}
+	return ""
+}
+
+func noRoute(ctx *gin.Context) {
+	respondError(ctx, http.StatusNotFound, fmt.Errorf("no route for %s %s", ctx.Request.Method, ctx.Request.URL.Path))
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abs/go_billing/internal/futures"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) buildContinuous(ctx *gin.Context) {
+	var req models.ContinuousRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	contracts := make([]futures.Contract, len(req.Contracts))
+	for i, contract := range req.Contracts {
+		contracts[i] = futures.Contract{
+			Symbol:  contract.Symbol,
+			Expiry:  contract.Expiry,
+			Candles: contract.Candles,
+		}
+	}
+
+	adjustment := futures.Adjustment(req.Adjustment)
+	if adjustment == "" {
+		adjustment = futures.Backward
+	}
+	rollBefore := time.Duration(req.RollDaysBeforeExpiry) * 24 * time.Hour
+
+	continuous, err := futures.Build(contracts, rollBefore, adjustment)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, continuous)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getRates(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.rates.Rates())
+}
+
+func (server *Server) setRates(ctx *gin.Context) {
+	var req models.RatesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := server.rates.SetAll(req.Rates); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, server.rates.Rates())
+}
+
+type convertResponse struct {
+	Amount    float64 `json:"amount"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Rate      float64 `json:"rate"`
+	Converted float64 `json:"converted"`
+}
+
+func (server *Server) convertAmount(ctx *gin.Context) {
+	var req models.ConvertRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	rate, err := server.converter.Rate(req.From, req.To)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, convertResponse{
+		Amount:    req.Amount,
+		From:      req.From,
+		To:        req.To,
+		Rate:      rate,
+		Converted: req.Amount * rate,
+	})
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/abs/go_billing/internal/upstream"
+	"github.com/gin-gonic/gin"
+)
+
+type readinessResponse struct {
+	Ready     bool              `json:"ready"`
+	Reasons   []string          `json:"reasons,omitempty"`
+	Degraded  bool              `json:"degraded"`
+	Instance  string            `json:"instance"`
+	Leader    bool              `json:"leader"`
+	Upstreams []upstream.Health `json:"upstreams"`
+}
+
+// ready reports whether the instance can serve requests, with 503 when it
+// cannot. Only local dependencies count: with leader election, the
+// instance is not ready while the leader lock has not answered within its
+// TTL, since it cannot tell whether it may accept leader-only writes.
+// Upstreams are optional and shared by every replica, so an open circuit
+// only marks the instance degraded: failing readiness for it would pull
+// every replica at once, endpoints that never call the upstream included.
+// Followers are ready; leadership is only reported. The database is a
+// local dependency too when DB_SOURCE is set. A shutting down instance is
+// not ready, so that load balancers stop routing to it while it drains.
+func (server *Server) ready(ctx *gin.Context) {
+	response := readinessResponse{
+		Instance:  server.config.InstanceID,
+		Leader:    server.isLeader(),
+		Upstreams: make([]upstream.Health, 0, len(server.upstreams)),
+	}
+	if server.draining.Load() {
+		response.Reasons = append(response.Reasons, "shutting down")
+	}
+	if server.elector != nil && !server.elector.Reachable() {
+		response.Reasons = append(response.Reasons, "leader lock unreachable")
+	}
+	if server.store != nil {
+		pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		if err := server.store.Ping(pingCtx); err != nil {
+			response.Reasons = append(response.Reasons, "database unreachable")
+		}
+		cancel()
+	}
+	response.Ready = len(response.Reasons) == 0
+	for _, client := range server.upstreams {
+		health := client.Health()
+		if health.State == upstream.Open {
+			response.Degraded = true
+		}
+		response.Upstreams = append(response.Upstreams, health)
+	}
+
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, response)
+}
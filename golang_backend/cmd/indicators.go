@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) calculateIndicators(ctx *gin.Context) {
+	var req models.IndicatorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	indicators, err := utils.CalculateIndicators(req)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, indicators)
+}
+
+func (server *Server) calculatePivots(ctx *gin.Context) {
+	var req models.PivotRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	location := time.UTC
+	if req.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(req.Timezone); err != nil {
+			respondError(ctx, http.StatusBadRequest, fmt.Errorf("invalid timezone: %w", err))
+			return
+		}
+	}
+	if req.Period == "" {
+		req.Period = utils.PivotDaily
+	}
+
+	pivots, err := utils.CalculatePivots(req.Candles, req.Period, location, req.Methods)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	server.renderAnalysis(ctx, pivots)
+}
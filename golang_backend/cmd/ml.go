@@ -0,0 +1,211 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/ml"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) detectAnomalies(ctx *gin.Context) {
+	var req models.AnomalyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := ml.ForestParams{
+		Trees:      req.Trees,
+		SampleSize: req.SampleSize,
+		Threshold:  req.Threshold,
+		Seed:       req.Seed,
+	}
+	if params.Trees == 0 {
+		params.Trees = 100
+	}
+	if params.SampleSize == 0 {
+		params.SampleSize = 256
+	}
+	if params.Threshold == 0 {
+		params.Threshold = 0.6
+	}
+
+	anomalies := ml.DetectAnomalies(req.Candles, params)
+	if anomalies == nil {
+		anomalies = []ml.Anomaly{}
+	}
+	ctx.JSON(http.StatusOK, anomalies)
+}
+
+type calibrationResponse struct {
+	Method     string          `json:"method"`
+	Calibrator ml.Calibrator   `json:"calibrator"`
+	Scores     []ml.Calibrated `json:"scores"`
+}
+
+func (server *Server) calibrateScores(ctx *gin.Context) {
+	var req models.CalibrationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Method == "" {
+		req.Method = ml.Platt
+	}
+
+	calibrator, err := ml.Fit(req.Method, req.Outcomes)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, calibrationResponse{
+		Method:     req.Method,
+		Calibrator: calibrator,
+		Scores:     ml.Calibrate(calibrator, req.Scores),
+	})
+}
+
+func (server *Server) purgedCV(ctx *gin.Context) {
+	var req models.PurgedCVRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Folds == 0 {
+		req.Folds = 5
+	}
+
+	labelEnds := req.LabelEnds
+	if len(labelEnds) == 0 {
+		labelEnds = make([]time.Time, len(req.Times))
+		for i := range labelEnds {
+			labelEnds[i] = req.Times[min(i+req.Horizon, len(req.Times)-1)]
+		}
+	}
+
+	folds, err := ml.PurgedKFold(req.Times, labelEnds, req.Folds, req.Embargo)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, folds)
+}
+
+type monitorResponse struct {
+	Features   []ml.Drift `json:"features"`
+	Prediction *ml.Drift  `json:"prediction,omitempty"`
+	Alert      bool       `json:"alert"`
+}
+
+func (server *Server) monitorModel(ctx *gin.Context) {
+	var req models.MonitorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	thresholds := ml.DriftThresholds{PSI: req.PSIThreshold, KSPValue: req.KSPValue, Bins: req.Bins}
+	if thresholds.PSI == 0 {
+		thresholds.PSI = 0.2
+	}
+	if thresholds.KSPValue == 0 {
+		thresholds.KSPValue = 0.05
+	}
+	if thresholds.Bins == 0 {
+		thresholds.Bins = 10
+	}
+
+	names := make([]string, 0, len(req.Features))
+	for name := range req.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var resp monitorResponse
+	resp.Features = make([]ml.Drift, 0, len(names))
+	for _, name := range names {
+		sample := req.Features[name]
+		drift := ml.CompareDistributions(name, sample.Reference, sample.Live, thresholds)
+		resp.Features = append(resp.Features, drift)
+		if drift.Drifted {
+			resp.Alert = true
+			log.Printf("model input %s drifted: psi=%.3f ks=%.3f p=%.4f", name, drift.PSI, drift.KS, drift.KSPValue)
+		}
+	}
+	if req.Predictions != nil {
+		drift := ml.CompareDistributions("prediction", req.Predictions.Reference, req.Predictions.Live, thresholds)
+		resp.Prediction = &drift
+		if drift.Drifted {
+			resp.Alert = true
+			log.Printf("model predictions drifted: psi=%.3f ks=%.3f p=%.4f", drift.PSI, drift.KS, drift.KSPValue)
+		}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (server *Server) listModels(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.registry.Models())
+}
+
+func (server *Server) registerModel(ctx *gin.Context) {
+	var req models.ModelVersionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	model, err := server.registry.Register(req.Name, req.Version, req.Metadata, req.Metrics)
+	if err != nil {
+		respondError(ctx, http.StatusConflict, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model)
+}
+
+func (server *Server) deployModel(ctx *gin.Context) {
+	var req models.DeployRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	model, err := server.registry.Deploy(ctx.Param("name"), req.Traffic)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model)
+}
+
+type routeResponse struct {
+	Model   string `json:"model"`
+	Version string `json:"version"`
+}
+
+func (server *Server) routeModel(ctx *gin.Context) {
+	version, err := server.registry.Route(ctx.Param("name"), ctx.Query("key"))
+	if err != nil {
+		respondError(ctx, http.StatusNotFound, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, routeResponse{Model: ctx.Param("name"), Version: version})
+}
+
+func (server *Server) recordModelOutcome(ctx *gin.Context) {
+	var req models.ModelOutcomeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	model, err := server.registry.Record(ctx.Param("name"), req.Version, req.Outcome)
+	if err != nil {
+		respondError(ctx, http.StatusNotFound, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, model)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/abs/go_billing/internal/onchain"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+type onChainResponse struct {
+	Asset   string                `json:"asset"`
+	Metrics map[string][]*float64 `json:"metrics"`
+}
+
+func (server *Server) getOnChainSeries(ctx *gin.Context) {
+	var req models.OnChainRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if server.onchain == nil {
+		respondError(ctx, http.StatusServiceUnavailable, errors.New("no on-chain provider configured"))
+		return
+	}
+
+	from := req.Candles[0].Time
+	to := req.Candles[len(req.Candles)-1].Time
+
+	rsp := onChainResponse{Asset: req.Asset, Metrics: make(map[string][]*float64)}
+	for _, metric := range req.Metrics {
+		// Fetch from well before the first candle so it has a value.
+		points, err := server.onchain.Series(ctx, req.Asset, metric, from.AddDate(0, 0, -7), to)
+		if err != nil {
+			respondError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		rsp.Metrics[metric] = onchain.Align(points, req.Candles)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/patterns"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getPatternCatalog(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, patterns.Catalog())
+}
+
+func (server *Server) detectPatterns(ctx *gin.Context) {
+	var req models.PatternRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Format == "indices" {
+		indices, err := patterns.DetectIndices(req.Candles, req.Patterns)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		server.renderAnalysis(ctx, indices)
+		return
+	}
+
+	detected, err := patterns.Detect(req.Candles, req.Patterns)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, detected)
+}
+
+func (server *Server) detectCompression(ctx *gin.Context) {
+	var req models.CandlesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, patterns.DetectCompression(req.Candles))
+}
+
+func (server *Server) detectChartPatterns(ctx *gin.Context) {
+	var req models.CandlesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, patterns.DetectChartPatterns(req.Candles))
+}
+
+func (server *Server) detectTrendlines(ctx *gin.Context) {
+	var req models.TrendlineRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := trendlineParams(req.SwingStrength, req.MinTouches, req.Tolerance)
+	server.renderAnalysis(ctx, patterns.Trendlines(req.Candles, params))
+}
+
+// trendlineParams applies the trendline defaults to zero values.
+func trendlineParams(swingStrength, minTouches int, tolerance float64) patterns.TrendlineParams {
+	params := patterns.TrendlineParams{
+		SwingStrength: swingStrength,
+		MinTouches:    minTouches,
+		Tolerance:     tolerance,
+	}
+	if params.SwingStrength == 0 {
+		params.SwingStrength = 3
+	}
+	if params.MinTouches == 0 {
+		params.MinTouches = 3
+	}
+	if params.Tolerance == 0 {
+		params.Tolerance = 0.25
+	}
+	return params
+}
+
+func (server *Server) getUniverseStats(ctx *gin.Context) {
+	var req models.UniverseStatsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := patterns.StatsParams{Horizon: req.Horizon, MinOccurrences: req.MinOccurrences}
+	if params.Horizon == 0 {
+		params.Horizon = 5
+	}
+	if params.MinOccurrences == 0 {
+		params.MinOccurrences = 5
+	}
+	stats, err := patterns.UniverseStats(req.Universe, req.Patterns, params)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, stats)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/pnl"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) addFills(ctx *gin.Context) {
+	var req models.FillsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, fill := range req.Fills {
+		server.ledger.Apply(fill)
+	}
+	server.respondPnL(ctx, server.config.AccountCurrency)
+}
+
+func (server *Server) setMarks(ctx *gin.Context) {
+	var req models.MarksRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	for symbol, price := range req.Marks {
+		server.ledger.Mark(symbol, price)
+	}
+	server.respondPnL(ctx, server.config.AccountCurrency)
+}
+
+func (server *Server) getPnL(ctx *gin.Context) {
+	currency := ctx.DefaultQuery("currency", server.config.AccountCurrency)
+	server.respondPnL(ctx, currency)
+}
+
+// respondPnL writes the ledger report converted to currency.
+func (server *Server) respondPnL(ctx *gin.Context, currency string) {
+	report, err := server.ledger.Report().Convert(server.converter, currency)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+func (server *Server) getClosedLots(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.ledger.ClosedLots())
+}
+
+func (server *Server) exportPnL(ctx *gin.Context) {
+	var req models.PnLExportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename="+req.Type+".csv")
+
+	var err error
+	if req.Type == "fills" {
+		err = pnl.WriteFillsCSV(ctx.Writer, server.ledger.Fills())
+	} else {
+		err = pnl.WriteLotsCSV(ctx.Writer, server.ledger.ClosedLots())
+	}
+	if err != nil {
+		ctx.Error(err)
+	}
+}
+
+func (server *Server) getInstruments(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.ledger.Instruments())
+}
+
+func (server *Server) setInstrument(ctx *gin.Context) {
+	var req models.Instrument
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	server.ledger.SetInstrument(req)
+	ctx.JSON(http.StatusOK, req)
+}
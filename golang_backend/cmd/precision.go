@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/abs/go_billing/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPrecision caps the requested number of decimal places.
+const maxPrecision = 12
+
+// bufferedWriter holds a response body back so it can be rewritten before
+// it is sent.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// roundResponses rounds the floats of JSON responses. The number of
+// decimals comes from the precision query parameter, else from the tick
+// size of the instrument named by the precision_symbol query parameter,
+// else from the output_precision setting; responses are left as they are
+// when none applies. The tick size rounds every float of the response, so
+// it is only used on request rather than from the symbol filter of other
+// endpoints.
+func (server *Server) roundResponses(ctx *gin.Context) {
+	decimals := server.tuning.Get().OutputPrecision
+	if symbol := ctx.Query("precision_symbol"); symbol != "" {
+		if instrument, ok := server.ledger.Instrument(symbol); ok && instrument.TickSize > 0 {
+			decimals = utils.Decimals(instrument.TickSize)
+		}
+	}
+	if value := ctx.Query("precision"); value != "" {
+		if d, err := strconv.Atoi(value); err == nil && d >= 0 {
+			decimals = d
+		}
+	}
+	if decimals < 0 {
+		ctx.Next()
+		return
+	}
+	decimals = min(decimals, maxPrecision)
+
+	writer := &bufferedWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer = writer
+	ctx.Next()
+	ctx.Writer = writer.ResponseWriter
+
+	body := writer.body.Bytes()
+	if strings.HasPrefix(writer.Header().Get("Content-Type"), "application/json") {
+		body = utils.RoundJSON(body, decimals)
+	}
+	writer.ResponseWriter.Write(body)
+}
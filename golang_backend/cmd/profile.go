@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/abs/go_billing/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// profileRequests attaches a profile to requests with profile=true.
+// Analysis endpoints then report where the time went in a timings block.
+func (server *Server) profileRequests(ctx *gin.Context) {
+	if ctx.Query("profile") == "true" {
+		profiled, _ := utils.WithProfile(ctx.Request.Context())
+		ctx.Request = ctx.Request.WithContext(profiled)
+	}
+	ctx.Next()
+}
+
+// renderAnalysis writes the result of an analysis endpoint. For profiled
+// requests it adds a timings block, in milliseconds, with the stages the
+// analysis recorded, the whole analysis and the JSON encoding; objects
+// gain a timings field and other results are wrapped as
+// {"result": ..., "timings": ...}.
+func (server *Server) renderAnalysis(ctx *gin.Context, result any) {
+	profile := utils.ProfileFrom(ctx.Request.Context())
+	if profile == nil {
+		ctx.JSON(http.StatusOK, result)
+		return
+	}
+	profile.Add("analysis", profile.Elapsed())
+
+	stop := profile.Time("json_encode")
+	body, err := json.Marshal(result)
+	stop()
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	timings, _ := json.Marshal(profile.Milliseconds())
+	if len(body) > 1 && body[0] == '{' {
+		separator := ","
+		if string(body) == "{}" {
+			separator = ""
+		}
+		body = append(body[:len(body)-1], separator+`"timings":`+string(timings)+"}"...)
+	} else {
+		body = []byte(`{"result":` + string(body) + `,"timings":` + string(timings) + "}")
+	}
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
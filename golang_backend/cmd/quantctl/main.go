@@ -0,0 +1,145 @@
+// Command quantctl runs the analysis behind the HTTP API against local
+// candle files, for research without a running server.
+//
+//	quantctl [-o out.json] [-pretty] <command> <candles.csv>
+//
+// Commands are indicators, patterns, chart, compression, smc, repaint and
+// snapshot; repaint audits the SMC detectors for repainting by replaying
+// the last 500 bars of the file one by one.
+// Results are written as JSON to stdout, or to the -o file.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/abs/go_billing/engine"
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// commands maps each command to the analysis it runs with the engine's
+// default settings.
+var commands = map[string]func(eng *engine.Engine, candles []models.OHLC) (any, error){
+	"indicators": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.Indicators(models.IndicatorRequest{Candles: candles})
+	},
+	"patterns": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.Patterns(candles, nil)
+	},
+	"chart": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.ChartPatterns(candles), nil
+	},
+	"compression": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.Compression(candles), nil
+	},
+	"smc": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.SMC(context.Background(), candles, nil), nil
+	},
+	"repaint": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.RepaintAudit(candles, max(len(candles)-engine.MaxReplay, 0)), nil
+	},
+	"snapshot": func(eng *engine.Engine, candles []models.OHLC) (any, error) {
+		return eng.Snapshot("", "", candles, false)
+	},
+}
+
+func main() {
+	output := flag.String("o", "", "write the result to this file instead of stdout")
+	pretty := flag.Bool("pretty", false, "indent the JSON output")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Arg(0), flag.Arg(1), *output, *pretty); err != nil {
+		fmt.Fprintln(os.Stderr, "quantctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(command, path, output string, pretty bool) error {
+	analyze, ok := commands[command]
+	if !ok {
+		return fmt.Errorf("unknown command %q", command)
+	}
+	candles, err := readCandles(path)
+	if err != nil {
+		return err
+	}
+	if len(candles) == 0 {
+		return fmt.Errorf("%s: no candles", path)
+	}
+	eng, err := engine.New(engine.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	result, err := analyze(eng, candles)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return encode(os.Stdout, result, pretty)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := encode(f, result, pretty); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func encode(w io.Writer, result any, pretty bool) error {
+	encoder := json.NewEncoder(w)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(result)
+}
+
+// readCandles reads a candle file, chosen by extension. Parquet is not
+// supported: the module has no Parquet reader, so convert to CSV first.
+func readCandles(path string) ([]models.OHLC, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+	case ".parquet":
+		return nil, fmt.Errorf("%s: parquet is not supported, convert it to csv", path)
+	default:
+		return nil, fmt.Errorf("%s: unsupported file type, want .csv", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	candles, err := utils.ReadCandlesCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return candles, nil
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: quantctl [flags] <command> <candles.csv>\n\ncommands: %s\n\nflags:\n", strings.Join(names, ", "))
+	flag.PrintDefaults()
+}
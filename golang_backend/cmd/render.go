@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/patterns"
+	"github.com/abs/go_billing/internal/render"
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) renderChart(ctx *gin.Context) {
+	var req models.ChartRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	svg, err := render.SVG(render.Chart{
+		Candles: req.Candles,
+		Zones:   req.Zones,
+		Labels:  req.Labels,
+		Width:   req.Width,
+		Height:  req.Height,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "image/svg+xml", svg)
+}
+
+func (server *Server) renderAnnotations(ctx *gin.Context) {
+	var req models.ChartRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	annotations, err := render.Annotations(render.Chart{
+		Candles: req.Candles,
+		Zones:   req.Zones,
+		Labels:  req.Labels,
+	})
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	for _, analysis := range req.Analyses {
+		switch analysis {
+		case "smc":
+			sfp := server.sfpParams(0, 0, 0)
+			result := smc.Analyze(ctx.Request.Context(), req.Candles, smc.Params{
+				SwingStrength: sfp.SwingStrength,
+				SupplyDemand:  server.supplyDemandParams(0, 0),
+				SFP:           sfp,
+				Sessions:      smc.DefaultSessions,
+			})
+			annotations = append(annotations, render.SMCAnnotations(req.Candles, result)...)
+		case "patterns":
+			matches, err := patterns.DetectIndices(req.Candles, nil)
+			if err != nil {
+				respondError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+			annotations = append(annotations, render.PatternAnnotations(req.Candles, matches)...)
+		case "chart_patterns":
+			annotations = append(annotations, render.ChartPatternAnnotations(req.Candles, patterns.DetectChartPatterns(req.Candles))...)
+		case "trendlines":
+			lines := patterns.Trendlines(req.Candles, trendlineParams(0, 0, 0))
+			annotations = append(annotations, render.TrendlineAnnotations(req.Candles, lines)...)
+		}
+	}
+	ctx.JSON(http.StatusOK, annotations)
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abs/go_billing/internal/risk"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) updateEquity(ctx *gin.Context) {
+	var req models.EquityUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	status := server.supervisor.UpdateEquity(req.Strategy, req.Equity)
+	ctx.JSON(http.StatusOK, status)
+}
+
+func (server *Server) getKillSwitch(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.supervisor.Status())
+}
+
+func (server *Server) setKillSwitch(ctx *gin.Context) {
+	var req models.KillSwitchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	switch req.Action {
+	case "trip":
+		ctx.JSON(http.StatusOK, server.supervisor.Trip(req.Strategy, req.Reason))
+	case "rearm":
+		status, err := server.supervisor.Rearm(req.Strategy)
+		if err != nil {
+			respondError(ctx, http.StatusNotFound, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, status)
+	default:
+		respondError(ctx, http.StatusBadRequest, fmt.Errorf("unknown action %q", req.Action))
+	}
+}
+
+func (server *Server) preTradeCheck(ctx *gin.Context) {
+	var req models.PreTradeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// Committing counts the trade against the day's limits, a write to the
+	// shared state.
+	if req.Commit && !server.isLeader() {
+		respondNotLeader(ctx, server.config.InstanceID)
+		return
+	}
+
+	// Limits and margin are in the account currency, so value the order
+	// and positions in it before checking.
+	rate, err := server.converter.Convert(1, req.Order.Currency, server.config.AccountCurrency)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	req.Order.Price *= rate
+	for i, position := range req.Positions {
+		rate, err := server.converter.Convert(1, position.Currency, server.config.AccountCurrency)
+		if err != nil {
+			respondError(ctx, http.StatusUnprocessableEntity, err)
+			return
+		}
+		req.Positions[i].Price *= rate
+	}
+
+	settings := server.tuning.Get()
+	limits := risk.PreTradeLimits{
+		MaxSymbolExposure: settings.MaxSymbolExposure,
+		MaxTotalExposure:  settings.MaxTotalExposure,
+		MaxGroupExposure:  settings.MaxGroupExposure,
+	}
+	result := server.supervisor.PreTrade(limits, req.Order, req.Positions, req.AvailableMargin, server.clock.Now(), req.Commit)
+	if req.Commit && result.Accepted {
+		server.shareStateNow(ctx)
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) getConstraints(ctx *gin.Context) {
+	strategy := ctx.Param("strategy")
+	constraints, ok := server.supervisor.Constraints(strategy)
+	if !ok {
+		respondError(ctx, http.StatusNotFound, fmt.Errorf("no constraints for strategy %q", strategy))
+		return
+	}
+	ctx.JSON(http.StatusOK, constraints)
+}
+
+func (server *Server) setConstraints(ctx *gin.Context) {
+	var req models.TradingConstraints
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := server.supervisor.SetConstraints(ctx.Param("strategy"), req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, req)
+}
+
+func (server *Server) replayConstraints(ctx *gin.Context) {
+	var req models.ConstraintReplayRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	replay, err := risk.ReplayConstraints(req.Constraints, req.Fills)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, replay)
+}
+
+func (server *Server) evaluatePropFirm(ctx *gin.Context) {
+	var req models.PropFirmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	rules := risk.PropFirmRules{
+		InitialBalance: req.InitialBalance,
+		DailyLossLimit: req.DailyLossLimit,
+		MaxDrawdown:    req.MaxDrawdown,
+		Trailing:       req.Trailing,
+		ProfitTarget:   req.ProfitTarget,
+		MinTradingDays: req.MinTradingDays,
+	}
+	if req.Timezone != "" {
+		location, err := time.LoadLocation(req.Timezone)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		rules.Location = location
+	}
+	ctx.JSON(http.StatusOK, risk.EvaluatePropFirm(req.Equity, rules))
+}
+
+func (server *Server) sizePosition(ctx *gin.Context) {
+	var req models.PositionSizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	size, err := risk.SizePosition(server.sizingParams(req.PositionSizing), req.Benchmark, server.sizingInput(req.Instrument))
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, fmt.Errorf("%s: %w", req.Instrument.Symbol, err))
+		return
+	}
+	ctx.JSON(http.StatusOK, size)
+}
+
+func (server *Server) sizePositions(ctx *gin.Context) {
+	var req models.PositionSizeBatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	inputs := make([]risk.SizingInput, len(req.Instruments))
+	for i, instrument := range req.Instruments {
+		inputs[i] = server.sizingInput(instrument)
+	}
+	ctx.JSON(http.StatusOK, risk.SizePositions(server.sizingParams(req.PositionSizing), req.Benchmark, inputs))
+}
+
+func (server *Server) sizingParams(req models.PositionSizing) risk.SizingParams {
+	params := risk.SizingParams{
+		Mode:        req.Mode,
+		Equity:      req.Equity,
+		Risk:        req.Risk,
+		ATRPeriod:   req.ATRPeriod,
+		ATRMultiple: req.ATRMultiple,
+		BetaPeriod:  req.BetaPeriod,
+	}
+	if params.ATRPeriod == 0 {
+		params.ATRPeriod = 14
+	}
+	if params.ATRMultiple == 0 {
+		params.ATRMultiple = 1
+	}
+	if params.BetaPeriod == 0 {
+		params.BetaPeriod = 63
+	}
+	return params
+}
+
+// sizingInput sizes a symbol as its registered instrument, so contract
+// multipliers count towards its risk, or as a plain linear one.
+func (server *Server) sizingInput(req models.SizingInstrument) risk.SizingInput {
+	instrument, ok := server.ledger.Instrument(req.Symbol)
+	if !ok {
+		instrument = models.LinearInstrument(req.Symbol)
+	}
+	return risk.SizingInput{Instrument: instrument, Price: req.Price, ATR: req.ATR, Beta: req.Beta, Candles: req.Candles}
+}
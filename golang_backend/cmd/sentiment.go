@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abs/go_billing/internal/sentiment"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+type sentimentResponse struct {
+	Headlines []sentiment.Scored           `json:"headlines"`
+	Series    map[string][]sentiment.Point `json:"series"`
+}
+
+func (server *Server) getSentimentSeries(ctx *gin.Context) {
+	var req models.SentimentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	interval, err := parseDurationDefault(req.Interval, time.Hour)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	window := req.Window
+	if window == 0 {
+		window = 24
+	}
+
+	for _, feed := range req.Feeds {
+		if err := server.feedHosts.Check(feed); err != nil {
+			respondError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	headlines := req.Headlines
+	for _, feed := range req.Feeds {
+		items, err := sentiment.FetchRSS(ctx, server.feeds, feed)
+		if err != nil {
+			respondError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		headlines = append(headlines, items...)
+	}
+
+	scored := sentiment.ScoreHeadlines(server.scorer, headlines, req.Symbols)
+	rsp := sentimentResponse{Headlines: scored, Series: make(map[string][]sentiment.Point)}
+	for symbol := range req.Symbols {
+		series, err := sentiment.Series(scored, symbol, interval, window)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		rsp.Series[symbol] = series
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -1 +1,396 @@
 package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/abs/go_billing/internal/allocation"
+	"github.com/abs/go_billing/internal/calendar"
+	"github.com/abs/go_billing/internal/fx"
+	"github.com/abs/go_billing/internal/leader"
+	"github.com/abs/go_billing/internal/ml"
+	"github.com/abs/go_billing/internal/onchain"
+	"github.com/abs/go_billing/internal/pnl"
+	"github.com/abs/go_billing/internal/reload"
+	"github.com/abs/go_billing/internal/risk"
+	"github.com/abs/go_billing/internal/sentiment"
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/internal/snapshot"
+	"github.com/abs/go_billing/internal/social"
+	db "github.com/abs/go_billing/internal/sqlc"
+	"github.com/abs/go_billing/internal/tuning"
+	"github.com/abs/go_billing/internal/upstream"
+	"github.com/abs/go_billing/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Server serves HTTP requests for the trading backend.
+type Server struct {
+	config     utils.Config
+	store      db.Store
+	supervisor *risk.Supervisor
+	allocator  *allocation.Allocator
+	ledger     *pnl.Ledger
+	rates      *fx.StaticRates
+	converter  *fx.Converter
+	onchain    onchain.Provider
+	scorer     sentiment.Scorer
+	feedHosts  sentiment.FeedHosts
+	social     social.Provider
+	registry   *ml.Registry
+	snapshots  *snapshot.Store
+	// indicators are computed for every snapshot.
+	indicators []snapshot.Indicator
+	journal    *signals.Journal
+	tuning     *tuning.Store
+	upstreams  []*upstream.Client
+	feeds      *upstream.Client
+	calendars  *calendar.Registry
+	elector    *leader.Elector
+	shared     leader.State
+	clock      utils.Clock
+	router     *gin.Engine
+	// draining is set once the server is shutting down.
+	draining atomic.Bool
+}
+
+// NewServer creates a new HTTP server and sets up routing. Every component
+// that reads the time or schedules work uses clock.
+func NewServer(config utils.Config, clock utils.Clock) (*Server, error) {
+	ledger, err := pnl.NewLedger(pnl.Method(config.PnLMethod))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create pnl ledger: %w", err)
+	}
+	allocator, err := allocation.NewAllocator(allocation.Method(config.AllocationMethod), clock)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create allocator: %w", err)
+	}
+
+	indicators := snapshot.DefaultIndicators
+	if len(config.SnapshotIndicators) > 0 {
+		if indicators, err = snapshot.ParseIndicators(config.SnapshotIndicators); err != nil {
+			return nil, fmt.Errorf("cannot parse SNAPSHOT_INDICATORS: %w", err)
+		}
+	}
+
+	rates := fx.NewStaticRates()
+	converter := fx.NewConverter(rates, "USD")
+	converter.Peg("USDT", "USD")
+	converter.Peg("USDC", "USD")
+
+	server := &Server{
+		config: config,
+		clock:  clock,
+		supervisor: risk.NewSupervisor(risk.Limits{
+			MaxStrategyDrawdown: config.MaxStrategyDrawdown,
+			MaxAccountDrawdown:  config.MaxAccountDrawdown,
+		}),
+		allocator:  allocator,
+		ledger:     ledger,
+		rates:      rates,
+		converter:  converter,
+		scorer:     sentiment.NewLexiconScorer(),
+		feedHosts:  sentiment.NewFeedHosts(config.SentimentFeedHosts),
+		registry:   ml.NewRegistry(clock),
+		snapshots:  snapshot.NewStore(),
+		indicators: indicators,
+		journal:    signals.NewJournal(config.SignalJournalLimit),
+		calendars:  calendar.NewRegistry(),
+		tuning: tuning.NewStore(tuning.Settings{
+			OutputPrecision:   config.OutputPrecision,
+			SwingStrength:     3,
+			MaxBase:           6,
+			LegRange:          1.5,
+			ConfirmCloses:     1,
+			MaxSymbolExposure: config.MaxSymbolExposure,
+			MaxTotalExposure:  config.MaxTotalExposure,
+			MaxGroupExposure:  config.MaxGroupExposure,
+		}, clock),
+	}
+
+	if config.DBSource != "" {
+		// The pool connects lazily, so the server starts while the
+		// database is down and readiness reports it.
+		pool, err := pgxpool.New(context.Background(), config.DBSource)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create database pool: %w", err)
+		}
+		server.store = db.NewStore(pool)
+	}
+	if config.OnChainAPIURL != "" {
+		client := upstream.NewClient("onchain", upstream.DefaultPolicy, clock)
+		server.upstreams = append(server.upstreams, client)
+		server.onchain = onchain.NewHTTPProvider(config.OnChainAPIURL, config.OnChainAPIKey, client)
+	}
+	if config.SocialAPIURL != "" {
+		client := upstream.NewClient("social", upstream.DefaultPolicy, clock)
+		server.upstreams = append(server.upstreams, client)
+		server.social = social.NewHTTPProvider(config.SocialAPIURL, config.SocialAPIKey, client)
+	}
+
+	server.feeds = upstream.NewClient("rss", upstream.DefaultPolicy, clock)
+	server.feeds.CheckRedirect(server.feedHosts.CheckRedirect)
+	server.upstreams = append(server.upstreams, server.feeds)
+
+	if config.RedisURL != "" {
+		options, err := redis.ParseURL(config.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse REDIS_URL: %w", err)
+		}
+		lock := leader.NewRedisLock(redis.NewClient(options), config.LeaderKey)
+		server.shared = lock
+		server.elector = leader.NewElector(lock, config.InstanceID, config.LeaderTTL, clock)
+		server.elector.OnElected(server.pullState)
+	}
+
+	useJSONFieldNames()
+	server.setupRouter()
+	return server, nil
+}
+
+func (server *Server) setupRouter() {
+	router := gin.Default()
+	router.Use(server.roundResponses)
+	router.Use(server.profileRequests)
+	router.NoRoute(noRoute)
+
+	router.POST("/calculate/indicators", server.calculateIndicators)
+	router.POST("/calculate/pivots", server.calculatePivots)
+
+	router.POST("/render/chart", server.renderChart)
+	router.POST("/render/annotations", server.renderAnnotations)
+
+	router.POST("/risk/equity", server.requireLeader, server.updateEquity)
+	router.GET("/risk/killswitch", server.getKillSwitch)
+	router.POST("/risk/killswitch", server.requireLeader, server.setKillSwitch)
+	router.POST("/risk/pretrade", server.preTradeCheck)
+	router.GET("/risk/constraints/:strategy", server.getConstraints)
+	router.POST("/risk/constraints/replay", server.replayConstraints)
+	router.PUT("/risk/constraints/:strategy", server.requireLeader, server.setConstraints)
+	router.POST("/risk/propfirm", server.evaluatePropFirm)
+	router.POST("/risk/position-size", server.sizePosition)
+	router.POST("/risk/position-size/batch", server.sizePositions)
+
+	router.GET("/allocations", server.getAllocations)
+	router.POST("/allocations/strategies", server.requireLeader, server.registerStrategy)
+	router.DELETE("/allocations/strategies/:name", server.requireLeader, server.removeStrategy)
+	router.POST("/allocations/rebalance", server.requireLeader, server.rebalance)
+
+	router.GET("/pnl", server.getPnL)
+	router.GET("/pnl/lots", server.getClosedLots)
+	router.GET("/pnl/export", server.exportPnL)
+	router.POST("/pnl/fills", server.addFills)
+	router.POST("/pnl/marks", server.setMarks)
+	router.GET("/pnl/instruments", server.getInstruments)
+	router.POST("/pnl/instruments", server.setInstrument)
+
+	router.GET("/fx/rates", server.getRates)
+	router.POST("/fx/rates", server.setRates)
+	router.GET("/fx/convert", server.convertAmount)
+
+	router.POST("/futures/continuous", server.buildContinuous)
+	router.POST("/equities/adjust", server.adjustCandles)
+
+	router.POST("/onchain/series", server.getOnChainSeries)
+	router.POST("/sentiment/series", server.getSentimentSeries)
+	router.POST("/social/spikes", server.detectSocialSpikes)
+
+	router.POST("/stats/breadth", server.getBreadth)
+	router.POST("/stats/relative-strength", server.getRelativeStrength)
+	router.POST("/stats/equity", server.getEquityStats)
+	router.POST("/stats/regimes", server.getRegimeStats)
+
+	router.POST("/ml/anomalies", server.detectAnomalies)
+	router.POST("/ml/calibrate", server.calibrateScores)
+	router.POST("/ml/cv/purged", server.purgedCV)
+	router.POST("/ml/monitor", server.monitorModel)
+	router.GET("/ml/models", server.listModels)
+	router.POST("/ml/models", server.registerModel)
+	router.POST("/ml/models/:name/deploy", server.deployModel)
+	router.GET("/ml/models/:name/route", server.routeModel)
+	router.POST("/ml/models/:name/outcomes", server.recordModelOutcome)
+
+	router.POST("/signals/ensemble", server.combineSignals)
+	router.GET("/signals/export", server.exportSignals)
+
+	router.GET("/patterns/catalog", server.getPatternCatalog)
+	router.POST("/patterns/detect", server.detectPatterns)
+	router.POST("/patterns/compression", server.detectCompression)
+	router.POST("/patterns/chart", server.detectChartPatterns)
+	router.POST("/patterns/trendlines", server.detectTrendlines)
+	router.POST("/patterns/universe-stats", server.getUniverseStats)
+
+	router.POST("/smc/supply-demand", server.detectSupplyDemand)
+	router.POST("/smc/zones/score", server.scoreZones)
+	router.POST("/smc/sfp", server.detectSFP)
+	router.POST("/smc/fvg", server.detectFVGs)
+	router.POST("/smc/fvg/inversions", server.detectFVGInversions)
+	router.POST("/smc/market-maker", server.detectMarketMaker)
+	router.POST("/smc/repaint-audit", server.auditRepaints)
+	router.POST("/smc/smt", server.detectSMT)
+	router.POST("/analyze/smc", server.analyzeSMC)
+
+	router.GET("/calendars", server.listCalendars)
+	router.GET("/calendars/:name", server.getCalendar)
+	router.POST("/calendars/:name/daily", server.resampleDaily)
+
+	router.GET("/health/ready", server.ready)
+
+	admin := router.Group("/admin", server.requireAdmin)
+	admin.GET("/settings", server.getSettings)
+	admin.PATCH("/settings", server.updateSettings)
+	admin.GET("/audit", server.getAudit)
+	admin.POST("/snapshot", server.saveState)
+	admin.POST("/restore", server.restoreState)
+	admin.GET("/bench", server.runBenchmarks)
+	admin.GET("/storage", server.requireStore, server.getStorage)
+	admin.GET("/retention", server.requireStore, server.listRetentionPolicies)
+	admin.PUT("/retention/:timeframe", server.requireStore, server.putRetentionPolicy)
+	admin.DELETE("/retention/:timeframe", server.requireStore, server.deleteRetentionPolicy)
+	admin.POST("/retention/compact", server.requireStore, server.compactCandles)
+
+	router.GET("/snapshot/:symbol/:tf", server.getSnapshot)
+	router.PUT("/snapshot/:symbol/:tf", server.putSnapshot)
+
+	server.router = router
+}
+
+// Start runs the HTTP server on a specific address until ctx is done,
+// along with the scheduled rebalancer when REBALANCE_INTERVAL is set and
+// the candle compaction every COMPACTION_INTERVAL when there is a
+// database. Saved state is restored first, before the config directory is
+// applied over it. With several replicas, scheduled jobs only run on the
+// elected leader while every replica serves requests, except writes to
+// the shared state, which only the leader accepts and shares with the
+// followers.
+//
+// Once ctx is done the instance reports itself not ready for
+// SHUTDOWN_DELAY, drains the requests in flight, shares its state if it
+// leads, stops the scheduled jobs, which releases the leader lock, and
+// saves the state to STATE_FILE.
+func (server *Server) Start(ctx context.Context, address string) error {
+	if err := server.restoreOnStart(); err != nil {
+		return err
+	}
+
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var running sync.WaitGroup
+	if server.config.ConfigDir != "" {
+		watcher := reload.NewWatcher(server.config.ConfigDir, server.applyConfig)
+		if err := watcher.Load(); err != nil {
+			return err
+		}
+		go watcher.Run(jobs, server.config.ConfigReloadInterval)
+	}
+	if server.elector != nil {
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			server.elector.Run(jobs)
+		}()
+		go server.syncState(jobs, server.config.LeaderTTL/3)
+	}
+	if server.config.RebalanceInterval > 0 {
+		go server.allocator.Run(jobs, server.config.RebalanceInterval, func() float64 {
+			// Followers report no equity, which skips the rebalance.
+			if !server.isLeader() {
+				return 0
+			}
+			return server.supervisor.Status().Account.Equity
+		}, func(err error) {
+			log.Print("allocation: scheduled rebalance failed: ", err)
+		})
+	}
+	if server.store != nil && server.config.CompactionInterval > 0 {
+		go server.runCompaction(jobs, server.config.CompactionInterval)
+	}
+
+	httpServer := &http.Server{Addr: address, Handler: server.router}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.ListenAndServe()
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Print("server: shutting down")
+	server.draining.Store(true)
+	<-server.clock.After(server.config.ShutdownDelay)
+	shutdown, cancel := context.WithTimeout(context.Background(), server.config.ShutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdown); err != nil {
+		log.Print("server: ", err)
+	}
+	server.shareStateNow(shutdown)
+	stopJobs()
+	running.Wait()
+
+	summary, err := server.writeState()
+	if err != nil {
+		return fmt.Errorf("cannot save state: %w", err)
+	}
+	log.Printf("server: saved state to %s", summary.Path)
+	return nil
+}
+
+// isLeader reports whether this instance runs the scheduled jobs: always
+// when there is no leader election.
+func (server *Server) isLeader() bool {
+	return server.elector == nil || server.elector.IsLeader()
+}
+
+// requireLeader rejects writes to the shared state on followers: kill
+// switches, equity, constraints and allocations have a single writer,
+// whose state followers pull from Redis. Clients retry the 409 against the
+// leader, for instance through a route that only targets it. The state is
+// shared as soon as a write succeeds.
+func (server *Server) requireLeader(ctx *gin.Context) {
+	if !server.isLeader() {
+		respondNotLeader(ctx, server.config.InstanceID)
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+	if ctx.Writer.Status() < http.StatusBadRequest {
+		server.shareStateNow(ctx)
+	}
+}
+
+// shareStateNow shares the state after a write, leaving a failure to the
+// next scheduled sync.
+func (server *Server) shareStateNow(ctx context.Context) {
+	if err := server.shareState(ctx); err != nil {
+		log.Print("leader: cannot share state: ", err)
+	}
+}
+
+func respondNotLeader(ctx *gin.Context, instance string) {
+	respondError(ctx, http.StatusConflict, fmt.Errorf("instance %s is not the leader: writes to shared state go to the leader", instance))
+}
+
+// parseDurationDefault parses a positive duration, returning fallback for
+// an empty string.
+func parseDurationDefault(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/abs/go_billing/internal/allocation"
+	"github.com/abs/go_billing/internal/risk"
+)
+
+// sharedState is the state every replica must agree on for orders to be
+// gated the same way wherever they land: the kill switches, equity,
+// constraints and day trade counts of the risk supervisor, and the
+// allocations. The leader is its only writer and shares it through Redis.
+type sharedState struct {
+	Risk      risk.State          `json:"risk"`
+	Allocator allocation.Snapshot `json:"allocator"`
+}
+
+// shareState saves the shared state for the other replicas. It does
+// nothing without leader election or on a follower.
+func (server *Server) shareState(ctx context.Context) error {
+	if server.shared == nil || !server.isLeader() {
+		return nil
+	}
+	data, err := json.Marshal(sharedState{
+		Risk:      server.supervisor.State(),
+		Allocator: server.allocator.Snapshot(),
+	})
+	if err != nil {
+		return err
+	}
+	return server.shared.Save(ctx, server.elector.ID(), data)
+}
+
+// pullState replaces the shared state with the one the leader saved last.
+// It runs on followers and on an instance about to take over, so that a
+// new leader carries on from its predecessor rather than from empty state.
+func (server *Server) pullState(ctx context.Context) error {
+	data, err := server.shared.Load(ctx)
+	if err != nil || data == nil {
+		return err
+	}
+	var state sharedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	// The sync loop may have pulled just as the instance was elected.
+	if server.isLeader() {
+		return nil
+	}
+	if err := server.supervisor.Restore(state.Risk); err != nil {
+		return err
+	}
+	server.allocator.Restore(state.Allocator)
+	return nil
+}
+
+// syncState shares the state every interval on the leader, which covers
+// the scheduled rebalance, and pulls it on followers.
+func (server *Server) syncState(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-server.clock.After(interval):
+			sync := server.pullState
+			if server.isLeader() {
+				sync = server.shareState
+			}
+			if err := sync(ctx); err != nil {
+				log.Print("leader: cannot sync shared state: ", err)
+			}
+		}
+	}
+}
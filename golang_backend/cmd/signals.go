@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) combineSignals(ctx *gin.Context) {
+	var req models.EnsembleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Method == "" {
+		req.Method = signals.Weighted
+	}
+	if req.Threshold == 0 {
+		req.Threshold = 0.2
+	}
+
+	signal, err := signals.Combine(req.Components, req.Weights, req.Method, req.Threshold)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if req.Symbol != "" {
+		server.journal.Add(req.Symbol, server.clock.Now().UTC(), signal)
+	}
+	ctx.JSON(http.StatusOK, signal)
+}
+
+func (server *Server) exportSignals(ctx *gin.Context) {
+	var req models.SignalExportRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	records := server.journal.Records(req.Symbol, req.From, req.To)
+	if req.Format == "json" {
+		ctx.JSON(http.StatusOK, records)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename=signals.csv")
+	if err := signals.WriteRecordsCSV(ctx.Writer, records); err != nil {
+		ctx.Error(err)
+	}
+}
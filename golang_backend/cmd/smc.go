@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) analyzeSMC(ctx *gin.Context) {
+	var req models.SMCRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	analysisCtx := ctx.Request.Context()
+	if req.DeadlineMS > 0 {
+		var cancel context.CancelFunc
+		analysisCtx, cancel = context.WithTimeout(analysisCtx, time.Duration(req.DeadlineMS)*time.Millisecond)
+		defer cancel()
+	}
+
+	sfp := server.sfpParams(req.SwingStrength, req.ConfirmCloses, req.VolumeFactor)
+	server.renderAnalysis(ctx, smc.Analyze(analysisCtx, req.Candles, smc.Params{
+		SwingStrength: sfp.SwingStrength,
+		SupplyDemand:  server.supplyDemandParams(req.MaxBase, req.LegRange),
+		SFP:           sfp,
+		Sessions:      smc.DefaultSessions,
+		Zones:         req.Zones,
+		Components:    req.Components,
+		MinZoneScore:  req.MinZoneScore,
+		ZoneOrder:     req.ZoneSort,
+		Explain:       req.Explain,
+	}))
+}
+
+func (server *Server) detectSupplyDemand(ctx *gin.Context) {
+	var req models.SupplyDemandRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, smc.SupplyDemand(req.Candles, server.supplyDemandParams(req.MaxBase, req.LegRange)))
+}
+
+func (server *Server) scoreZones(ctx *gin.Context) {
+	var req models.ZoneScoreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := smc.ScoreParams{
+		HalfLife:      req.HalfLife,
+		TouchDecay:    req.TouchDecay,
+		DistanceScale: req.DistanceScale,
+	}
+	if params.HalfLife == 0 {
+		params.HalfLife = smc.DefaultScoreParams.HalfLife
+	}
+	if params.TouchDecay == 0 {
+		params.TouchDecay = smc.DefaultScoreParams.TouchDecay
+	}
+	if params.DistanceScale == 0 {
+		params.DistanceScale = smc.DefaultScoreParams.DistanceScale
+	}
+	server.renderAnalysis(ctx, smc.ScoreZones(req.Candles, req.Zones, params, req.MinScore))
+}
+
+func (server *Server) detectSFP(ctx *gin.Context) {
+	var req models.SFPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, smc.SwingFailures(req.Candles, server.sfpParams(req.SwingStrength, req.ConfirmCloses, req.VolumeFactor)))
+}
+
+func (server *Server) detectFVGs(ctx *gin.Context) {
+	var req models.CandlesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, smc.FVGs(req.Candles))
+}
+
+func (server *Server) detectFVGInversions(ctx *gin.Context) {
+	var req models.FVGInversionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	server.renderAnalysis(ctx, smc.FVGInversions(req.Candles, req.LowerCandles))
+}
+
+func (server *Server) detectMarketMaker(ctx *gin.Context) {
+	var req models.MarketMakerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := smc.MMParams{
+		SwingStrength:     req.SwingStrength,
+		ConsolidationBars: req.ConsolidationBars,
+		RangeFactor:       req.RangeFactor,
+	}
+	if params.SwingStrength == 0 {
+		params.SwingStrength = server.tuning.Get().SwingStrength
+	}
+	if params.ConsolidationBars == 0 {
+		params.ConsolidationBars = 8
+	}
+	if params.RangeFactor == 0 {
+		params.RangeFactor = 3
+	}
+	server.renderAnalysis(ctx, smc.MarketMakerModels(req.Candles, params))
+}
+
+func (server *Server) auditRepaints(ctx *gin.Context) {
+	var req models.RepaintAuditRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	from := max(len(req.Candles)-smc.MaxReplay, 0)
+	if !req.From.IsZero() {
+		from = 0
+		for from < len(req.Candles) && req.Candles[from].Time.Before(req.From) {
+			from++
+		}
+	}
+	sfp := server.sfpParams(req.SwingStrength, req.ConfirmCloses, req.VolumeFactor)
+	server.renderAnalysis(ctx, smc.RepaintAudit(req.Candles, from, smc.Params{
+		SwingStrength: sfp.SwingStrength,
+		SupplyDemand:  server.supplyDemandParams(req.MaxBase, req.LegRange),
+		SFP:           sfp,
+	}))
+}
+
+func (server *Server) detectSMT(ctx *gin.Context) {
+	var req models.SMTRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	strength := req.SwingStrength
+	if strength == 0 {
+		strength = server.tuning.Get().SwingStrength
+	}
+
+	events := []smc.SMT{}
+	for _, pair := range req.Pairs {
+		a, okA := req.Series[pair[0]]
+		b, okB := req.Series[pair[1]]
+		if !okA || !okB {
+			respondError(ctx, http.StatusBadRequest, fmt.Errorf("pair %s/%s has no series", pair[0], pair[1]))
+			return
+		}
+		pairEvents, err := smc.SMTDivergences(pair[0], a, pair[1], b, strength)
+		if err != nil {
+			respondError(ctx, http.StatusUnprocessableEntity, err)
+			return
+		}
+		events = append(events, pairEvents...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	server.renderAnalysis(ctx, events)
+}
+
+// supplyDemandParams applies the tuned supply/demand defaults to zero
+// values.
+func (server *Server) supplyDemandParams(maxBase int, legRange float64) smc.SDParams {
+	settings := server.tuning.Get()
+	params := smc.SDParams{MaxBase: maxBase, LegRange: legRange}
+	if params.MaxBase == 0 {
+		params.MaxBase = settings.MaxBase
+	}
+	if params.LegRange == 0 {
+		params.LegRange = settings.LegRange
+	}
+	return params
+}
+
+// sfpParams applies the tuned SFP defaults to zero values.
+func (server *Server) sfpParams(swingStrength, confirmCloses int, volumeFactor float64) smc.SFPParams {
+	settings := server.tuning.Get()
+	params := smc.SFPParams{
+		SwingStrength: swingStrength,
+		ConfirmCloses: confirmCloses,
+		VolumeFactor:  volumeFactor,
+	}
+	if params.SwingStrength == 0 {
+		params.SwingStrength = settings.SwingStrength
+	}
+	if params.ConfirmCloses == 0 {
+		params.ConfirmCloses = settings.ConfirmCloses
+	}
+	return params
+}
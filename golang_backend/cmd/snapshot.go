@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/abs/go_billing/internal/snapshot"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getSnapshot(ctx *gin.Context) {
+	snap, err := server.snapshots.Get(ctx.Param("symbol"), ctx.Param("tf"))
+	if err != nil {
+		respondError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if snap.Candle != nil {
+		status := snap.Candle.At(server.clock.Now())
+		snap.Candle = &status
+	}
+	snap.Signals = server.journal.Latest(snap.Symbol, server.config.SnapshotSignals)
+	ctx.JSON(http.StatusOK, snap)
+}
+
+func (server *Server) putSnapshot(ctx *gin.Context) {
+	var req models.CandlesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var closedOnly bool
+	switch mode := ctx.DefaultQuery("mode", "partial"); mode {
+	case "partial":
+	case "closed":
+		closedOnly = true
+	default:
+		respondError(ctx, http.StatusBadRequest, fmt.Errorf("invalid mode %q: want partial or closed", mode))
+		return
+	}
+
+	snap := snapshot.Build(ctx.Param("symbol"), ctx.Param("tf"), req.Candles, server.clock.Now(), closedOnly, server.indicators)
+	server.snapshots.Put(snap)
+	snap.Signals = server.journal.Latest(snap.Symbol, server.config.SnapshotSignals)
+	ctx.JSON(http.StatusOK, snap)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/social"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) detectSocialSpikes(ctx *gin.Context) {
+	var req models.SocialSpikeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	interval, err := parseDurationDefault(req.Interval, time.Hour)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	lookback, err := parseDurationDefault(req.Lookback, 7*24*time.Hour)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	window := req.Window
+	if window == 0 {
+		window = 24
+	}
+	threshold := req.Threshold
+	if threshold == 0 {
+		threshold = 3
+	}
+
+	counts := req.Counts
+	if counts == nil {
+		counts = make(map[string][]models.MentionCount)
+	}
+	now := server.clock.Now()
+	for _, symbol := range req.Symbols {
+		if _, ok := counts[symbol]; ok {
+			continue
+		}
+		if server.social == nil {
+			respondError(ctx, http.StatusServiceUnavailable, errors.New("no social provider configured"))
+			return
+		}
+		symbolCounts, err := server.social.Mentions(ctx, symbol, now.Add(-lookback), now, interval)
+		if err != nil {
+			respondError(ctx, http.StatusBadGateway, err)
+			return
+		}
+		counts[symbol] = symbolCounts
+	}
+
+	spikes := []social.Spike{}
+	for symbol, symbolCounts := range counts {
+		spikes = append(spikes, social.DetectSpikes(symbol, symbolCounts, window, threshold)...)
+	}
+	sort.Slice(spikes, func(i, j int) bool {
+		if !spikes[i].Time.Equal(spikes[j].Time) {
+			return spikes[i].Time.Before(spikes[j].Time)
+		}
+		return spikes[i].Symbol < spikes[j].Symbol
+	})
+	for _, spike := range spikes {
+		server.journal.AddEvent(spike.Record())
+	}
+	ctx.JSON(http.StatusOK, spikes)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abs/go_billing/internal/allocation"
+	"github.com/abs/go_billing/internal/fx"
+	"github.com/abs/go_billing/internal/ml"
+	"github.com/abs/go_billing/internal/pnl"
+	"github.com/abs/go_billing/internal/risk"
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/internal/snapshot"
+	"github.com/abs/go_billing/internal/tuning"
+	"github.com/gin-gonic/gin"
+)
+
+// stateVersion is bumped when serviceState changes incompatibly.
+const stateVersion = 1
+
+// serviceState is the in-memory state of the server written to STATE_FILE.
+type serviceState struct {
+	Version   int                 `json:"version"`
+	SavedAt   time.Time           `json:"saved_at"`
+	Snapshots []snapshot.Snapshot `json:"snapshots"`
+	Signals   []signals.Record    `json:"signals"`
+	Ledger    pnl.State           `json:"ledger"`
+	Risk      risk.State          `json:"risk"`
+	Allocator allocation.Snapshot `json:"allocator"`
+	Settings  tuning.Settings     `json:"settings"`
+	Audit     []tuning.Change     `json:"audit"`
+	Rates     map[string]float64  `json:"rates"`
+	Models    []ml.Model          `json:"models"`
+}
+
+// stateSummary describes a saved or restored state.
+type stateSummary struct {
+	Path      string    `json:"path"`
+	SavedAt   time.Time `json:"saved_at"`
+	Snapshots int       `json:"snapshots"`
+	Signals   int       `json:"signals"`
+	Fills     int       `json:"fills"`
+	Models    int       `json:"models"`
+}
+
+func (server *Server) saveState(ctx *gin.Context) {
+	summary, err := server.writeState()
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+func (server *Server) restoreState(ctx *gin.Context) {
+	// An explicit restore takes the file at any age.
+	summary, err := server.loadState(0)
+	if err != nil {
+		respondError(ctx, http.StatusUnprocessableEntity, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, summary)
+}
+
+// writeState saves the state to STATE_FILE. The file is replaced by a
+// rename so that a crash mid-write leaves the previous state intact.
+func (server *Server) writeState() (stateSummary, error) {
+	state := serviceState{
+		Version:   stateVersion,
+		SavedAt:   server.clock.Now().UTC(),
+		Snapshots: server.snapshots.All(),
+		Signals:   server.journal.Records("", time.Time{}, time.Time{}),
+		Ledger:    server.ledger.State(),
+		Risk:      server.supervisor.State(),
+		Allocator: server.allocator.Snapshot(),
+		Settings:  server.tuning.Get(),
+		Audit:     server.tuning.Audit(),
+		Rates:     server.rates.Rates(),
+		Models:    server.registry.Models(),
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return stateSummary{}, err
+	}
+
+	path := server.config.StateFile
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return stateSummary{}, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return stateSummary{}, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return stateSummary{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return stateSummary{}, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return stateSummary{}, err
+	}
+	return state.summary(path), nil
+}
+
+// errStaleState is returned by loadState for a file saved too long ago.
+var errStaleState = errors.New("state is stale")
+
+// restoreOnStart restores STATE_FILE when it exists, unless it was saved
+// more than STATE_MAX_AGE ago: the kill switches, equity and day trade
+// counts of a stale file no longer describe the account, so the instance
+// starts empty and moves the file aside. Halted kill switches that are restored are
+// logged too.
+func (server *Server) restoreOnStart() error {
+	summary, err := server.loadState(server.config.StateMaxAge)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case errors.Is(err, errStaleState):
+		// Kept aside for an explicit restore, as the next save replaces it.
+		stale := server.config.StateFile + ".stale"
+		if err := os.Rename(server.config.StateFile, stale); err != nil {
+			return err
+		}
+		log.Printf("state: not restored, moved to %s: %v", stale, err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("cannot restore state: %w", err)
+	}
+	log.Printf("state: restored %s saved at %s", summary.Path, summary.SavedAt.Format(time.RFC3339))
+	status := server.supervisor.Status()
+	for _, st := range append([]risk.EquityStatus{status.Account}, status.Strategies...) {
+		if !st.Halted {
+			continue
+		}
+		name := st.Strategy
+		if name == "" {
+			name = "account"
+		}
+		log.Printf("state: %s kill switch restored halted: %s", name, st.Reason)
+	}
+	return nil
+}
+
+// loadState restores the state saved in STATE_FILE, rejecting it with
+// errStaleState when maxAge is set and it is older. Everything that can
+// be rejected is checked before anything is replaced.
+func (server *Server) loadState(maxAge time.Duration) (stateSummary, error) {
+	path := server.config.StateFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stateSummary{}, err
+	}
+	var state serviceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return stateSummary{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Version != stateVersion {
+		return stateSummary{}, fmt.Errorf("%s: unsupported state version %d", path, state.Version)
+	}
+	if age := server.clock.Now().Sub(state.SavedAt); maxAge > 0 && age > maxAge {
+		return stateSummary{}, fmt.Errorf("%s: %w: saved %s ago, more than %s", path, errStaleState, age.Round(time.Second), maxAge)
+	}
+
+	for key := range state.Rates {
+		if _, _, err := fx.ParsePair(key); err != nil {
+			return stateSummary{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := server.supervisor.Restore(state.Risk); err != nil {
+		return stateSummary{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := server.rates.Restore(state.Rates); err != nil {
+		return stateSummary{}, fmt.Errorf("%s: %w", path, err)
+	}
+	server.snapshots.Restore(state.Snapshots)
+	server.journal.Restore(state.Signals)
+	server.ledger.Restore(state.Ledger)
+	server.allocator.Restore(state.Allocator)
+	server.tuning.Restore(state.Settings, state.Audit)
+	server.registry.Restore(state.Models)
+	return state.summary(path), nil
+}
+
+func (state serviceState) summary(path string) stateSummary {
+	return stateSummary{
+		Path:      path,
+		SavedAt:   state.SavedAt,
+		Snapshots: len(state.Snapshots),
+		Signals:   len(state.Signals),
+		Fills:     len(state.Ledger.Fills),
+		Models:    len(state.Models),
+	}
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/abs/go_billing/internal/stats"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) getBreadth(ctx *gin.Context) {
+	var req models.BreadthRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := stats.BreadthParams{
+		EMAPeriod:     req.EMAPeriod,
+		RSIPeriod:     req.RSIPeriod,
+		HighLowPeriod: req.HighLowPeriod,
+	}
+	if params.EMAPeriod == 0 {
+		params.EMAPeriod = 200
+	}
+	if params.RSIPeriod == 0 {
+		params.RSIPeriod = 14
+	}
+	if params.HighLowPeriod == 0 {
+		params.HighLowPeriod = 252
+	}
+
+	ctx.JSON(http.StatusOK, stats.Breadth(req.Universe, params))
+}
+
+func (server *Server) getRelativeStrength(ctx *gin.Context) {
+	var req models.RelativeStrengthRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if _, ok := req.Universe[req.Benchmark]; !ok {
+		respondError(ctx, http.StatusBadRequest, fmt.Errorf("benchmark %q is not in the universe", req.Benchmark))
+		return
+	}
+
+	params := stats.RSParams{Lookbacks: req.Lookbacks}
+	if len(params.Lookbacks) == 0 {
+		params.Lookbacks = []int{21, 63, 126, 252}
+	}
+
+	ctx.JSON(http.StatusOK, stats.RelativeStrength(req.Universe, req.Benchmark, params))
+}
+
+func (server *Server) getEquityStats(ctx *gin.Context) {
+	var req models.EquityStatsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := stats.EquityParams{Window: req.Window, PeriodsPerYear: req.PeriodsPerYear}
+	if params.Window == 0 {
+		params.Window = 30
+	}
+	if params.PeriodsPerYear == 0 {
+		params.PeriodsPerYear = 252
+	}
+
+	equity := req.Equity
+	if len(equity) == 0 {
+		equity = stats.EquityFromReturns(req.Returns)
+	}
+	ctx.JSON(http.StatusOK, stats.Equity(equity, params))
+}
+
+func (server *Server) getRegimeStats(ctx *gin.Context) {
+	var req models.RegimeStatsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	params := stats.RegimeParams{Window: req.Window, TrendThreshold: req.TrendThreshold}
+	if params.Window == 0 {
+		params.Window = 20
+	}
+	if params.TrendThreshold == 0 {
+		params.TrendThreshold = 0.3
+	}
+
+	regimes := make(map[string][]stats.Regime, len(req.Candles))
+	for symbol, candles := range req.Candles {
+		regimes[symbol] = stats.Regimes(candles, params)
+	}
+	lots := server.ledger.ClosedLots()
+	trades := make([]stats.Trade, len(lots))
+	for i, lot := range lots {
+		trades[i] = stats.Trade{Symbol: lot.Symbol, OpenTime: lot.OpenTime, Realized: lot.Realized}
+	}
+	ctx.JSON(http.StatusOK, stats.PerformanceByRegime(trades, regimes))
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	db "github.com/abs/go_billing/internal/sqlc"
+	"github.com/abs/go_billing/models"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type storageResponse struct {
+	Tables  []db.GetTableStorageRow  `json:"tables"`
+	Candles []db.GetCandleStorageRow `json:"candles"`
+}
+
+type retentionPolicyResponse struct {
+	Timeframe           string    `json:"timeframe"`
+	MaxAge              string    `json:"max_age,omitempty"`
+	DownsampleTimeframe string    `json:"downsample_timeframe,omitempty"`
+	DownsampleInterval  string    `json:"downsample_interval,omitempty"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type compactionResponse struct {
+	AsOf    time.Time              `json:"as_of"`
+	Deleted []db.CompactCandlesRow `json:"deleted"`
+}
+
+// requireStore rejects requests that need the database when DB_SOURCE is
+// not set.
+func (server *Server) requireStore(ctx *gin.Context) {
+	if server.store == nil {
+		respondError(ctx, http.StatusServiceUnavailable, errors.New("no database configured: set DB_SOURCE"))
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+}
+
+// getStorage reports the on-disk size of every table and how many candles
+// each timeframe holds, which is what the retention policies bound.
+func (server *Server) getStorage(ctx *gin.Context) {
+	tables, err := server.store.GetTableStorage(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	candles, err := server.store.GetCandleStorage(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, storageResponse{Tables: tables, Candles: candles})
+}
+
+func (server *Server) listRetentionPolicies(ctx *gin.Context) {
+	policies, err := server.store.ListRetentionPolicies(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	response := make([]retentionPolicyResponse, len(policies))
+	for i, policy := range policies {
+		response[i] = newRetentionPolicyResponse(policy)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) putRetentionPolicy(ctx *gin.Context) {
+	var req models.RetentionPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	arg := db.UpsertRetentionPolicyParams{Timeframe: ctx.Param("timeframe")}
+	var err error
+	if arg.MaxAge, err = parseInterval(req.MaxAge); err != nil {
+		respondError(ctx, http.StatusBadRequest, fmt.Errorf("max_age: %w", err))
+		return
+	}
+	if arg.DownsampleInterval, err = parseInterval(req.DownsampleInterval); err != nil {
+		respondError(ctx, http.StatusBadRequest, fmt.Errorf("downsample_interval: %w", err))
+		return
+	}
+	if req.DownsampleTimeframe != "" {
+		arg.DownsampleTimeframe = &req.DownsampleTimeframe
+	}
+
+	policy, err := server.store.UpsertRetentionPolicy(ctx, arg)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newRetentionPolicyResponse(policy))
+}
+
+func (server *Server) deleteRetentionPolicy(ctx *gin.Context) {
+	if err := server.store.DeleteRetentionPolicy(ctx, ctx.Param("timeframe")); err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// compactCandles applies the retention policies now, as the scheduled
+// compaction does.
+func (server *Server) compactCandles(ctx *gin.Context) {
+	response, err := server.compact(ctx)
+	if err != nil {
+		respondError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) compact(ctx context.Context) (compactionResponse, error) {
+	asOf := server.clock.Now().UTC()
+	deleted, err := server.store.CompactCandles(ctx, pgtype.Timestamp{Time: asOf, Valid: true})
+	if err != nil {
+		return compactionResponse{}, fmt.Errorf("cannot compact candles: %w", err)
+	}
+	return compactionResponse{AsOf: asOf, Deleted: deleted}, nil
+}
+
+// runCompaction applies the retention policies every interval. Only the
+// leader compacts, so replicas sharing the database do not race over the
+// same candles.
+func (server *Server) runCompaction(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-server.clock.After(interval):
+			if !server.isLeader() {
+				continue
+			}
+			response, err := server.compact(ctx)
+			if err != nil {
+				log.Print("storage: ", err)
+				continue
+			}
+			for _, row := range response.Deleted {
+				if row.Deleted > 0 {
+					log.Printf("storage: compacted %d %s candles", row.Deleted, row.Timeframe)
+				}
+			}
+		}
+	}
+}
+
+func newRetentionPolicyResponse(policy db.CandleRetentionPolicy) retentionPolicyResponse {
+	response := retentionPolicyResponse{
+		Timeframe:          policy.Timeframe,
+		MaxAge:             formatInterval(policy.MaxAge),
+		DownsampleInterval: formatInterval(policy.DownsampleInterval),
+		UpdatedAt:          policy.UpdatedDatetime.Time,
+	}
+	if policy.DownsampleTimeframe != nil {
+		response.DownsampleTimeframe = *policy.DownsampleTimeframe
+	}
+	return response
+}
+
+var intervalPattern = regexp.MustCompile(`^(?:(\d+)mo)?(?:(\d+)d)?(.*)$`)
+
+// parseInterval parses a positive Go duration that may start with months
+// and days, which PostgreSQL keeps apart from the time of an interval. An
+// empty string is a NULL interval.
+func parseInterval(value string) (pgtype.Interval, error) {
+	if value == "" {
+		return pgtype.Interval{}, nil
+	}
+	match := intervalPattern.FindStringSubmatch(value)
+	var interval pgtype.Interval
+	if match[1] != "" {
+		months, err := strconv.ParseInt(match[1], 10, 32)
+		if err != nil {
+			return interval, fmt.Errorf("invalid months in %q", value)
+		}
+		interval.Months = int32(months)
+	}
+	if match[2] != "" {
+		days, err := strconv.ParseInt(match[2], 10, 32)
+		if err != nil {
+			return interval, fmt.Errorf("invalid days in %q", value)
+		}
+		interval.Days = int32(days)
+	}
+	if match[3] != "" {
+		d, err := time.ParseDuration(match[3])
+		if err != nil {
+			return interval, err
+		}
+		interval.Microseconds = d.Microseconds()
+	}
+	if interval.Months < 0 || interval.Days < 0 || interval.Microseconds < 0 ||
+		interval.Months == 0 && interval.Days == 0 && interval.Microseconds == 0 {
+		return interval, fmt.Errorf("interval %q must be positive", value)
+	}
+	interval.Valid = true
+	return interval, nil
+}
+
+// formatInterval formats an interval the way parseInterval reads it, with
+// an empty string for NULL.
+func formatInterval(interval pgtype.Interval) string {
+	if !interval.Valid {
+		return ""
+	}
+	var s string
+	if interval.Months != 0 {
+		s += fmt.Sprintf("%dmo", interval.Months)
+	}
+	if interval.Days != 0 {
+		s += fmt.Sprintf("%dd", interval.Days)
+	}
+	if interval.Microseconds != 0 || s == "" {
+		s += (time.Duration(interval.Microseconds) * time.Microsecond).String()
+	}
+	return s
+}
@@ -0,0 +1,186 @@
+// Package engine runs the platform's analysis in-process, for Go programs
+// that embed it instead of calling the HTTP API. The HTTP server and
+// quantctl are frontends over the same code.
+//
+//	eng, err := engine.New(engine.DefaultConfig())
+//	if err != nil {
+//		return err
+//	}
+//	analysis := eng.SMC(ctx, candles, nil)
+//
+// An Engine holds no per-call state and is safe for concurrent use. Result
+// types are aliases of the analysis packages' own types, so they encode to
+// the same JSON as the API.
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abs/go_billing/internal/patterns"
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/internal/snapshot"
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Result types.
+type (
+	SMCAnalysis     = smc.Analysis
+	Session         = smc.Session
+	ChartPattern    = patterns.ChartPattern
+	Compression     = patterns.Compression
+	Trendline       = patterns.Trendline
+	TrendlineParams = patterns.TrendlineParams
+	Snapshot        = snapshot.Snapshot
+	Indicator       = snapshot.Indicator
+	Signal          = signals.Signal
+	RepaintReport   = smc.RepaintReport
+)
+
+// MaxReplay is the most candles RepaintAudit replays.
+const MaxReplay = smc.MaxReplay
+
+// Config holds the detector defaults of an engine. They match the server's
+// initial settings.
+type Config struct {
+	SwingStrength int
+	MaxBase       int
+	LegRange      float64
+	ConfirmCloses int
+	VolumeFactor  float64
+	Sessions      []Session
+	// Indicators are computed for every snapshot; the snapshot defaults
+	// when nil.
+	Indicators []Indicator
+	// Clock stamps snapshots; the system clock when nil.
+	Clock utils.Clock
+}
+
+// DefaultConfig returns the default detector settings.
+func DefaultConfig() Config {
+	return Config{
+		SwingStrength: 3,
+		MaxBase:       6,
+		LegRange:      1.5,
+		ConfirmCloses: 1,
+		Sessions:      smc.DefaultSessions,
+		Clock:         utils.SystemClock,
+	}
+}
+
+// Engine runs analyses with a fixed configuration.
+type Engine struct {
+	config Config
+}
+
+// New creates an engine, rejecting settings the detectors cannot use.
+func New(config Config) (*Engine, error) {
+	if config.SwingStrength <= 0 {
+		return nil, errors.New("swing strength must be positive")
+	}
+	if config.MaxBase <= 0 {
+		return nil, errors.New("max base must be positive")
+	}
+	if config.LegRange <= 0 {
+		return nil, errors.New("leg range must be positive")
+	}
+	if config.ConfirmCloses < 0 || config.VolumeFactor < 0 {
+		return nil, errors.New("confirm closes and volume factor must not be negative")
+	}
+	if config.Clock == nil {
+		config.Clock = utils.SystemClock
+	}
+	if config.Indicators == nil {
+		config.Indicators = snapshot.DefaultIndicators
+	}
+	return &Engine{config: config}, nil
+}
+
+// Indicators computes the indicator series of POST /calculate/indicators.
+func (e *Engine) Indicators(req models.IndicatorRequest) (models.IndicatorResponse, error) {
+	return utils.CalculateIndicators(req)
+}
+
+// Pivots computes the pivot levels of POST /calculate/pivots.
+func (e *Engine) Pivots(req models.PivotRequest) (models.Pivots, error) {
+	location := time.UTC
+	if req.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(req.Timezone); err != nil {
+			return models.Pivots{}, err
+		}
+	}
+	period := req.Period
+	if period == "" {
+		period = utils.PivotDaily
+	}
+	return utils.CalculatePivots(req.Candles, period, location, req.Methods)
+}
+
+// Patterns returns the indices of the candles matching each named
+// candlestick pattern, or every pattern when names is empty.
+func (e *Engine) Patterns(candles []models.OHLC, names []string) (map[string][]int, error) {
+	return patterns.DetectIndices(candles, names)
+}
+
+// ChartPatterns detects flags, pennants and channels.
+func (e *Engine) ChartPatterns(candles []models.OHLC) []ChartPattern {
+	return patterns.DetectChartPatterns(candles)
+}
+
+// Compression finds inside-bar chains, outside bars and NR4/NR7 setups.
+func (e *Engine) Compression(candles []models.OHLC) Compression {
+	return patterns.DetectCompression(candles)
+}
+
+// Trendlines fits support and resistance trendlines.
+func (e *Engine) Trendlines(candles []models.OHLC, params TrendlineParams) []Trendline {
+	return patterns.Trendlines(candles, params)
+}
+
+// SMC runs the full SMC analysis. zones are external zones, such as order
+// blocks, checked for OTE overlaps. A partial analysis is returned if ctx
+// is done first.
+func (e *Engine) SMC(ctx context.Context, candles []models.OHLC, zones []models.Zone) SMCAnalysis {
+	return smc.Analyze(ctx, candles, e.smcParams(zones))
+}
+
+func (e *Engine) smcParams(zones []models.Zone) smc.Params {
+	return smc.Params{
+		SwingStrength: e.config.SwingStrength,
+		SupplyDemand:  smc.SDParams{MaxBase: e.config.MaxBase, LegRange: e.config.LegRange},
+		SFP: smc.SFPParams{
+			SwingStrength: e.config.SwingStrength,
+			ConfirmCloses: e.config.ConfirmCloses,
+			VolumeFactor:  e.config.VolumeFactor,
+		},
+		Sessions: e.config.Sessions,
+		Zones:    zones,
+	}
+}
+
+// RepaintAudit replays up to MaxReplay candles from index from on,
+// bar by bar, and reports per SMC detector the detections a live engine
+// would have shown that the full history withdraws or changes.
+func (e *Engine) RepaintAudit(candles []models.OHLC, from int) []RepaintReport {
+	return smc.RepaintAudit(candles, from, e.smcParams(nil))
+}
+
+// Snapshot summarises the latest state of a symbol's candles. With
+// closedOnly, a latest candle of the timeframe still forming is left out.
+// Snapshots built here carry no signals.
+func (e *Engine) Snapshot(symbol, timeframe string, candles []models.OHLC, closedOnly bool) (Snapshot, error) {
+	if len(candles) == 0 {
+		return Snapshot{}, errors.New("no candles")
+	}
+	return snapshot.Build(symbol, timeframe, candles, e.config.Clock.Now(), closedOnly, e.config.Indicators), nil
+}
+
+// Signal combines signal components into one signal with the vote or
+// weighted method; components missing from weights weigh 1.
+func (e *Engine) Signal(components []models.SignalComponent, weights map[string]float64, method string, threshold float64) (Signal, error) {
+	return signals.Combine(components, weights, method, threshold)
+}
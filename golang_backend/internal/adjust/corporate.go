@@ -0,0 +1,93 @@
+package adjust
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Corporate action types.
+const (
+	Split    = "split"
+	Dividend = "dividend"
+)
+
+// Factor is the price factor of one action, applied to every candle before
+// its ExDate.
+type Factor struct {
+	ExDate time.Time `json:"ex_date"`
+	Type   string    `json:"type"`
+	Factor float64   `json:"factor"`
+}
+
+// Candles back-adjusts candles for corporate actions so that prices and
+// volumes before each ex-date are comparable with the latest ones. Dividends are skipped
+// when splitsOnly is set. Candles must be sorted by time; the input slice is
+// not modified.
+func Candles(candles []models.OHLC, actions []models.CorporateAction, splitsOnly bool) ([]models.OHLC, []Factor, error) {
+	actions = append([]models.CorporateAction(nil), actions...)
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].ExDate.Before(actions[j].ExDate)
+	})
+
+	// factor[i] is the multiplier applied to the prices of candles[i] and
+	// shares[i] the one applied to its volume, which only splits change.
+	factor := make([]float64, len(candles))
+	shares := make([]float64, len(candles))
+	for i := range factor {
+		factor[i] = 1
+		shares[i] = 1
+	}
+
+	var factors []Factor
+	for _, action := range actions {
+		// Index of the first candle on or after the ex-date.
+		ex := sort.Search(len(candles), func(i int) bool {
+			return !candles[i].Time.Before(action.ExDate)
+		})
+		if ex == 0 {
+			continue
+		}
+
+		var f float64
+		switch action.Type {
+		case Split:
+			if action.Ratio <= 0 {
+				return nil, nil, fmt.Errorf("split on %s has no ratio", action.ExDate.Format("2006-01-02"))
+			}
+			f = 1 / action.Ratio
+		case Dividend:
+			if splitsOnly {
+				continue
+			}
+			prevClose := candles[ex-1].Close
+			if prevClose <= action.Amount {
+				return nil, nil, fmt.Errorf("dividend on %s is not below the previous close", action.ExDate.Format("2006-01-02"))
+			}
+			f = 1 - action.Amount/prevClose
+		default:
+			return nil, nil, fmt.Errorf("unknown corporate action %q", action.Type)
+		}
+
+		for i := 0; i < ex; i++ {
+			factor[i] *= f
+			if action.Type == Split {
+				shares[i] *= action.Ratio
+			}
+		}
+		factors = append(factors, Factor{ExDate: action.ExDate, Type: action.Type, Factor: f})
+	}
+
+	adjusted := make([]models.OHLC, len(candles))
+	for i, candle := range candles {
+		candle.Open *= factor[i]
+		candle.High *= factor[i]
+		candle.Low *= factor[i]
+		candle.Close *= factor[i]
+		candle.Volume *= shares[i]
+		adjusted[i] = candle
+	}
+	return adjusted, factors, nil
+}
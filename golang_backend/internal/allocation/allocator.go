@@ -0,0 +1,256 @@
+package allocation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/utils"
+)
+
+// Method selects how equity is split across strategies.
+type Method string
+
+// Supported allocation methods.
+const (
+	Fixed       Method = "fixed"
+	Volatility  Method = "volatility"
+	Performance Method = "performance"
+)
+
+// Strategy is a registered strategy and the inputs used to weight it.
+// Weight is used by Fixed, Volatility (annualised) by Volatility and
+// Performance (recent return or Sharpe) by Performance.
+type Strategy struct {
+	Name        string  `json:"name"`
+	Weight      float64 `json:"weight"`
+	Volatility  float64 `json:"volatility"`
+	Performance float64 `json:"performance"`
+}
+
+// Allocation is the capital assigned to one strategy by a rebalance.
+// Change is the difference from the previous allocation.
+type Allocation struct {
+	Strategy string  `json:"strategy"`
+	Weight   float64 `json:"weight"`
+	Capital  float64 `json:"capital"`
+	Change   float64 `json:"change"`
+}
+
+// Snapshot is the allocator state returned by the API.
+type Snapshot struct {
+	Method       Method       `json:"method"`
+	Equity       float64      `json:"equity"`
+	RebalancedAt time.Time    `json:"rebalanced_at"`
+	Strategies   []Strategy   `json:"strategies"`
+	Allocations  []Allocation `json:"allocations"`
+}
+
+// Weights returns normalised weights for the strategies under a method.
+// When no strategy has a usable input, equity is split equally.
+func Weights(method Method, strategies []Strategy) (map[string]float64, error) {
+	raw := make(map[string]float64, len(strategies))
+	var total float64
+	for _, s := range strategies {
+		var w float64
+		switch method {
+		case Fixed:
+			w = s.Weight
+		case Volatility:
+			if s.Volatility > 0 {
+				w = 1 / s.Volatility
+			}
+		case Performance:
+			w = s.Performance
+		default:
+			return nil, fmt.Errorf("unknown allocation method %q", method)
+		}
+		if w < 0 {
+			w = 0
+		}
+		raw[s.Name] = w
+		total += w
+	}
+
+	weights := make(map[string]float64, len(strategies))
+	for _, s := range strategies {
+		if total > 0 {
+			weights[s.Name] = raw[s.Name] / total
+		} else {
+			weights[s.Name] = 1 / float64(len(strategies))
+		}
+	}
+	return weights, nil
+}
+
+// Allocator keeps the registered strategies and their current capital.
+type Allocator struct {
+	mu           sync.Mutex
+	method       Method
+	strategies   map[string]Strategy
+	capital      map[string]float64
+	weights      map[string]float64
+	equity       float64
+	rebalancedAt time.Time
+	clock        utils.Clock
+}
+
+// NewAllocator creates an allocator using method by default. Rebalances
+// are timed and scheduled by clock.
+func NewAllocator(method Method, clock utils.Clock) (*Allocator, error) {
+	switch method {
+	case Fixed, Volatility, Performance:
+	default:
+		return nil, fmt.Errorf("unknown allocation method %q", method)
+	}
+	return &Allocator{
+		method:     method,
+		clock:      clock,
+		strategies: make(map[string]Strategy),
+		capital:    make(map[string]float64),
+		weights:    make(map[string]float64),
+	}, nil
+}
+
+// Register adds or updates a strategy. It takes effect on the next rebalance.
+func (a *Allocator) Register(strategy Strategy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.strategies[strategy.Name] = strategy
+}
+
+// Remove unregisters a strategy.
+func (a *Allocator) Remove(name string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.strategies[name]; !ok {
+		return fmt.Errorf("unknown strategy %q", name)
+	}
+	delete(a.strategies, name)
+	return nil
+}
+
+// Rebalance splits equity across the registered strategies. An empty method
+// uses the allocator's default; any other only applies to this rebalance.
+func (a *Allocator) Rebalance(equity float64, method Method) ([]Allocation, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.strategies) == 0 {
+		return nil, fmt.Errorf("no strategies registered")
+	}
+	if method == "" {
+		method = a.method
+	}
+
+	weights, err := Weights(method, a.sortedStrategies())
+	if err != nil {
+		return nil, err
+	}
+
+	capital := make(map[string]float64, len(weights))
+	for name, w := range weights {
+		capital[name] = w * equity
+	}
+	allocations := diff(a.capital, capital, weights)
+
+	a.capital = capital
+	a.weights = weights
+	a.equity = equity
+	a.rebalancedAt = a.clock.Now()
+	return allocations, nil
+}
+
+// Snapshot returns the current allocations.
+func (a *Allocator) Snapshot() Snapshot {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return Snapshot{
+		Method:       a.method,
+		Equity:       a.equity,
+		RebalancedAt: a.rebalancedAt,
+		Strategies:   a.sortedStrategies(),
+		Allocations:  diff(a.capital, a.capital, a.weights),
+	}
+}
+
+// Restore replaces the allocator state with a snapshot. The default method
+// is kept, so a changed ALLOCATION_METHOD is not overridden by saved state.
+func (a *Allocator) Restore(snap Snapshot) {
+	strategies := make(map[string]Strategy, len(snap.Strategies))
+	for _, s := range snap.Strategies {
+		strategies[s.Name] = s
+	}
+	capital := make(map[string]float64, len(snap.Allocations))
+	weights := make(map[string]float64, len(snap.Allocations))
+	for _, alloc := range snap.Allocations {
+		capital[alloc.Strategy] = alloc.Capital
+		weights[alloc.Strategy] = alloc.Weight
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.equity = snap.Equity
+	a.rebalancedAt = snap.RebalancedAt
+	a.strategies = strategies
+	a.capital = capital
+	a.weights = weights
+}
+
+// Run rebalances every interval using the equity reported by equity until
+// ctx is cancelled, passing failed rebalances to onError. Rebalances with
+// no strategies or no equity are skipped.
+func (a *Allocator) Run(ctx context.Context, interval time.Duration, equity func() float64, onError func(error)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.clock.After(interval):
+			if e := equity(); e > 0 && a.registered() {
+				if _, err := a.Rebalance(e, ""); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+func (a *Allocator) registered() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.strategies) > 0
+}
+
+func (a *Allocator) sortedStrategies() []Strategy {
+	strategies := make([]Strategy, 0, len(a.strategies))
+	for _, s := range a.strategies {
+		strategies = append(strategies, s)
+	}
+	sort.Slice(strategies, func(i, j int) bool {
+		return strategies[i].Name < strategies[j].Name
+	})
+	return strategies
+}
+
+func diff(previous, capital, weights map[string]float64) []Allocation {
+	allocations := make([]Allocation, 0, len(capital))
+	for name, c := range capital {
+		allocations = append(allocations, Allocation{
+			Strategy: name,
+			Weight:   weights[name],
+			Capital:  c,
+			Change:   c - previous[name],
+		})
+	}
+	sort.Slice(allocations, func(i, j int) bool {
+		return allocations[i].Strategy < allocations[j].Strategy
+	})
+	return allocations
+}
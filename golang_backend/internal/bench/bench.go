@@ -0,0 +1,201 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"runtime"
+	"time"
+
+	"github.com/abs/go_billing/internal/patterns"
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// DefaultSizes are the candle counts benchmarked when none are requested.
+// A full run takes several minutes.
+var DefaultSizes = []int{1000, 10000, 100000}
+
+// BudgetSize is the candle count at which budgets are set and enforced.
+const BudgetSize = 10000
+
+// Case is an analysis function under benchmark. Budget is its performance
+// budget in nanoseconds per input candle; a run over budget is a
+// regression.
+type Case struct {
+	Name   string
+	Budget float64
+	Run    func(candles []models.OHLC)
+}
+
+// Cases are the benchmarked analysis functions with their budgets, set at
+// roughly four times the cost measured at BudgetSize candles on one core
+// of a current server. Raise a budget only together with the change that
+// justifies it; make bench_budget fails when a case is over it.
+var Cases = []Case{
+	{Name: "utils.CalculateIndicators", Budget: 4500, Run: func(c []models.OHLC) {
+		utils.CalculateIndicators(models.IndicatorRequest{Candles: c})
+	}},
+	{Name: "utils.CalculateSMA", Budget: 40, Run: func(c []models.OHLC) { utils.CalculateSMA(utils.Closes(c), 20) }},
+	{Name: "utils.CalculateEMA", Budget: 40, Run: func(c []models.OHLC) { utils.CalculateEMA(utils.Closes(c), 20) }},
+	{Name: "utils.CalculateRSI", Budget: 60, Run: func(c []models.OHLC) { utils.CalculateRSI(utils.Closes(c), 14) }},
+	{Name: "utils.CalculateBollingerBands", Budget: 60, Run: func(c []models.OHLC) { utils.CalculateBollingerBands(utils.Closes(c), 20, 2) }},
+	{Name: "utils.CalculateSuperTrend", Budget: 80, Run: func(c []models.OHLC) {
+		utils.CalculateSuperTrend(utils.Highs(c), utils.Lows(c), utils.Closes(c), 10, 3)
+	}},
+	{Name: "utils.CalculateADX", Budget: 80, Run: func(c []models.OHLC) {
+		utils.CalculateADX(utils.Highs(c), utils.Lows(c), utils.Closes(c), 14)
+	}},
+	{Name: "utils.CalculateDonchian", Budget: 120, Run: func(c []models.OHLC) {
+		utils.CalculateDonchian(utils.Highs(c), utils.Lows(c), 55)
+	}},
+	{Name: "utils.CalculateVWAP", Budget: 60, Run: func(c []models.OHLC) { utils.CalculateVWAP(c, time.UTC) }},
+	{Name: "utils.CalculateIchimoku", Budget: 650, Run: func(c []models.OHLC) {
+		utils.CalculateIchimoku(utils.Highs(c), utils.Lows(c), utils.Closes(c), 9, 26, 52, 26)
+	}},
+	{Name: "utils.CalculateQQE", Budget: 180, Run: func(c []models.OHLC) { utils.CalculateQQE(utils.Closes(c), 14, 5, 4.236) }},
+	{Name: "utils.CalculateSTC", Budget: 350, Run: func(c []models.OHLC) { utils.CalculateSTC(utils.Closes(c), 23, 50, 10) }},
+	{Name: "patterns.Detect", Budget: 600, Run: func(c []models.OHLC) { patterns.Detect(c, nil) }},
+	{Name: "patterns.DetectCompression", Budget: 400, Run: func(c []models.OHLC) { patterns.DetectCompression(c) }},
+	{Name: "patterns.DetectChartPatterns", Budget: 400, Run: func(c []models.OHLC) { patterns.DetectChartPatterns(c) }},
+	{Name: "patterns.Trendlines", Budget: 400, Run: func(c []models.OHLC) {
+		patterns.Trendlines(c, patterns.TrendlineParams{SwingStrength: 3, MinTouches: 3, Tolerance: 0.25})
+	}},
+	{Name: "smc.Swings", Budget: 250, Run: func(c []models.OHLC) { smc.Swings(c, 3) }},
+	{Name: "smc.StructureBreaks", Budget: 400, Run: func(c []models.OHLC) { smc.StructureBreaks(c, 3) }},
+	{Name: "smc.SupplyDemand", Budget: 150, Run: func(c []models.OHLC) {
+		smc.SupplyDemand(c, smc.SDParams{MaxBase: 6, LegRange: 1.5})
+	}},
+	{Name: "smc.FVGs", Budget: 800, Run: func(c []models.OHLC) { smc.FVGs(c) }},
+	{Name: "smc.MarketMakerModels", Budget: 400, Run: func(c []models.OHLC) {
+		smc.MarketMakerModels(c, smc.MMParams{SwingStrength: 3, ConsolidationBars: 8, RangeFactor: 3})
+	}},
+	// The SMT budget includes building the companion series.
+	{Name: "smc.SMTDivergences", Budget: 1600, Run: func(c []models.OHLC) {
+		smc.SMTDivergences("A", c, "B", Companion(c), 3)
+	}},
+	{Name: "smc.SwingFailures", Budget: 8000, Run: func(c []models.OHLC) {
+		smc.SwingFailures(c, smc.SFPParams{SwingStrength: 3, ConfirmCloses: 1})
+	}},
+	{Name: "smc.TimeLevels", Budget: 100, Run: func(c []models.OHLC) { smc.TimeLevels(c, smc.DefaultSessions) }},
+	{Name: "smc.PowerOfThrees", Budget: 800, Run: func(c []models.OHLC) { smc.PowerOfThrees(c, smc.DefaultSessions) }},
+	{Name: "smc.Analyze", Budget: 12000, Run: func(c []models.OHLC) {
+		smc.Analyze(context.Background(), c, smc.Params{
+			SwingStrength: 3,
+			SupplyDemand:  smc.SDParams{MaxBase: 6, LegRange: 1.5},
+			SFP:           smc.SFPParams{SwingStrength: 3, ConfirmCloses: 1},
+			Sessions:      smc.DefaultSessions,
+		})
+	}},
+}
+
+// Result is the outcome of one case at one size.
+type Result struct {
+	Name         string  `json:"name"`
+	Size         int     `json:"size"`
+	Iterations   int     `json:"iterations"`
+	NsPerOp      int64   `json:"ns_per_op"`
+	NsPerCandle  float64 `json:"ns_per_candle"`
+	AllocsPerOp  int64   `json:"allocs_per_op"`
+	BytesPerOp   int64   `json:"bytes_per_op"`
+	Budget       float64 `json:"budget_ns_per_candle"`
+	WithinBudget bool    `json:"within_budget"`
+}
+
+// Run benchmarks every case whose name is in names (all when empty) at
+// each size, on the same synthetic series for every case.
+func Run(sizes []int, names []string) []Result {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	results := []Result{}
+	for _, size := range sizes {
+		candles := Candles(size)
+		for _, c := range Cases {
+			if len(selected) > 0 && !selected[c.Name] {
+				continue
+			}
+			n, elapsed, allocs, bytes := measure(c, candles)
+			nsPerOp := elapsed.Nanoseconds() / int64(n)
+			perCandle := float64(nsPerOp) / float64(size)
+			results = append(results, Result{
+				Name:         c.Name,
+				Size:         size,
+				Iterations:   n,
+				NsPerOp:      nsPerOp,
+				NsPerCandle:  perCandle,
+				AllocsPerOp:  int64(allocs) / int64(n),
+				BytesPerOp:   int64(bytes) / int64(n),
+				Budget:       c.Budget,
+				WithinBudget: perCandle <= c.Budget,
+			})
+		}
+	}
+	return results
+}
+
+// benchTime is how long each case runs at each size.
+const benchTime = time.Second
+
+// measure runs c over candles, growing the iteration count until a run
+// lasts benchTime as go test -bench does, and returns the iterations of
+// the last run with its duration and heap allocations.
+func measure(c Case, candles []models.OHLC) (n int, elapsed time.Duration, allocs, bytes uint64) {
+	for n = 1; ; {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			c.Run(candles)
+		}
+		elapsed = time.Since(start)
+		runtime.ReadMemStats(&after)
+		if elapsed >= benchTime || n >= 1e9 {
+			return n, elapsed, after.Mallocs - before.Mallocs, after.TotalAlloc - before.TotalAlloc
+		}
+
+		next := 2 * n
+		if elapsed > 0 {
+			next = max(next, int(1.2*float64(n)*float64(benchTime)/float64(elapsed)))
+		}
+		n = min(next, 100*n)
+	}
+}
+
+// Candles generates a deterministic hourly random walk of n candles.
+func Candles(n int) []models.OHLC {
+	rng := rand.New(rand.NewSource(1))
+	candles := make([]models.OHLC, n)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	price := 100.0
+	for i := range candles {
+		open := price
+		price *= 1 + rng.NormFloat64()*0.01
+		high := max(open, price) * (1 + rng.Float64()*0.005)
+		low := min(open, price) * (1 - rng.Float64()*0.005)
+		candles[i] = models.OHLC{
+			Time:   start.Add(time.Duration(i) * time.Hour),
+			Open:   open,
+			High:   high,
+			Low:    low,
+			Close:  price,
+			Volume: 1000 + rng.Float64()*1000,
+		}
+	}
+	return candles
+}
+
+// Companion returns a series on the same times as candles that drifts in
+// and out of step with it, for benchmarks comparing two symbols.
+func Companion(candles []models.OHLC) []models.OHLC {
+	companion := make([]models.OHLC, len(candles))
+	for i, c := range candles {
+		f := 1 + 0.05*math.Sin(float64(i)/40)
+		companion[i] = models.OHLC{Time: c.Time, Open: c.Open * f, High: c.High * f, Low: c.Low * f, Close: c.Close * f, Volume: c.Volume}
+	}
+	return companion
+}
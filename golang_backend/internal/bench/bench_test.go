@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/abs/go_billing/models"
+)
+
+// BenchmarkCases runs every case at each of DefaultSizes:
+//
+//	go test -run '^$' -bench . -benchmem ./internal/bench
+func BenchmarkCases(b *testing.B) {
+	for _, size := range DefaultSizes {
+		candles := Candles(size)
+		for _, c := range Cases {
+			b.Run(fmt.Sprintf("%s/%d", c.Name, size), benchmark(c, candles))
+		}
+	}
+}
+
+// benchmark runs c on candles, reporting allocations and the cost per
+// candle.
+func benchmark(c Case, candles []models.OHLC) func(b *testing.B) {
+	return func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			c.Run(candles)
+		}
+		b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(len(candles)), "ns/candle")
+	}
+}
+
+// TestBudgets fails when a case costs more per candle than its budget at
+// BudgetSize candles. It takes about a minute of wall-clock time, so it
+// only runs with BENCH_BUDGETS=1 (make bench_budget).
+func TestBudgets(t *testing.T) {
+	if os.Getenv("BENCH_BUDGETS") != "1" {
+		t.Skip("set BENCH_BUDGETS=1 to check the performance budgets")
+	}
+	for _, r := range Run([]int{BudgetSize}, nil) {
+		if !r.WithinBudget {
+			t.Errorf("%s: %.0f ns per candle, over its budget of %.0f (%d allocs per op)", r.Name, r.NsPerCandle, r.Budget, r.AllocsPerOp)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+	// Embedded so that the exchange time zones load on hosts without a
+	// zoneinfo database.
+	_ "time/tzdata"
+
+	"github.com/abs/go_billing/models"
+)
+
+// dateLayout is the layout of trading dates and holidays.
+const dateLayout = "2006-01-02"
+
+// Calendar is the trading hours of an exchange in its local time, so that
+// session boundaries follow its DST transitions. Open and Close are wall
+// clock offsets from local midnight; a Close at or before Open means the
+// session opens the evening before the trading date it belongs to, as with
+// the forex and CME Globex sessions.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+	Open     time.Duration
+	Close    time.Duration
+	// Days are the weekdays of trading dates.
+	Days []time.Weekday
+	// Holidays are trading dates, "2006-01-02", with no session.
+	Holidays map[string]bool
+}
+
+// Session is one trading date of a calendar.
+type Session struct {
+	Date  string    `json:"date"`
+	Open  time.Time `json:"open"`
+	Close time.Time `json:"close"`
+}
+
+// Status is the state of an exchange at a point in time. Next is the
+// session in progress or, when closed, the next one to open.
+type Status struct {
+	Name     string   `json:"name"`
+	Timezone string   `json:"timezone"`
+	Open     bool     `json:"open"`
+	Next     *Session `json:"next,omitempty"`
+}
+
+// TimeOfDay returns the wall clock offset of t from midnight in its own
+// location. Unlike subtracting midnight, it does not move by an hour on
+// DST transition days.
+func TimeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
+
+// overnight reports whether sessions open the evening before their date.
+func (c Calendar) overnight() bool {
+	return c.Close <= c.Open
+}
+
+// Bounds returns the open and close of the session of a trading date, in
+// the calendar's location, whether or not the date trades.
+func (c Calendar) Bounds(date time.Time) (time.Time, time.Time) {
+	y, m, d := date.Date()
+	closes := at(y, m, d, c.Close, c.Location)
+	if c.overnight() {
+		return at(y, m, d-1, c.Open, c.Location), closes
+	}
+	return at(y, m, d, c.Open, c.Location), closes
+}
+
+// TradingDate returns the date of the session that t falls in or would
+// fall in: for overnight sessions, times from the open onwards belong to
+// the next day.
+func (c Calendar) TradingDate(t time.Time) time.Time {
+	local := t.In(c.Location)
+	y, m, d := local.Date()
+	if c.overnight() && TimeOfDay(local) >= c.Open {
+		d++
+	}
+	return time.Date(y, m, d, 0, 0, 0, 0, c.Location)
+}
+
+// Trades reports whether a trading date has a session.
+func (c Calendar) Trades(date time.Time) bool {
+	if c.Holidays[date.Format(dateLayout)] {
+		return false
+	}
+	for _, day := range c.Days {
+		if date.Weekday() == day {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen reports whether the exchange is trading at t.
+func (c Calendar) IsOpen(t time.Time) bool {
+	date := c.TradingDate(t)
+	if !c.Trades(date) {
+		return false
+	}
+	opens, closes := c.Bounds(date)
+	return !t.Before(opens) && t.Before(closes)
+}
+
+// SessionAt returns the session t falls in, if the exchange is open.
+func (c Calendar) SessionAt(t time.Time) (Session, bool) {
+	if !c.IsOpen(t) {
+		return Session{}, false
+	}
+	return c.session(c.TradingDate(t)), true
+}
+
+// Next returns the first session that closes after t. It gives up after a
+// year without one.
+func (c Calendar) Next(t time.Time) (Session, bool) {
+	date := c.TradingDate(t)
+	for i := 0; i < 366; i++ {
+		if c.Trades(date) {
+			if _, closes := c.Bounds(date); closes.After(t) {
+				return c.session(date), true
+			}
+		}
+		date = date.AddDate(0, 0, 1)
+	}
+	return Session{}, false
+}
+
+// Status returns the state of the exchange at t.
+func (c Calendar) Status(t time.Time) Status {
+	status := Status{Name: c.Name, Timezone: c.Location.String(), Open: c.IsOpen(t)}
+	if next, ok := c.Next(t); ok {
+		status.Next = &next
+	}
+	return status
+}
+
+func (c Calendar) session(date time.Time) Session {
+	opens, closes := c.Bounds(date)
+	return Session{Date: date.Format(dateLayout), Open: opens, Close: closes}
+}
+
+// Daily resamples intraday candles into one candle per trading date,
+// stamped with the session open. Candles outside the calendar's sessions
+// are dropped. Candles must be in time order.
+func (c Calendar) Daily(candles []models.OHLC) []models.OHLC {
+	daily := []models.OHLC{}
+	var current time.Time
+	for _, candle := range candles {
+		session, ok := c.SessionAt(candle.Time)
+		if !ok {
+			continue
+		}
+		if n := len(daily); n > 0 && session.Open.Equal(current) {
+			bar := &daily[n-1]
+			bar.High = max(bar.High, candle.High)
+			bar.Low = min(bar.Low, candle.Low)
+			bar.Close = candle.Close
+			bar.Volume += candle.Volume
+			continue
+		}
+		current = session.Open
+		bar := candle
+		bar.Time = session.Open
+		daily = append(daily, bar)
+	}
+	return daily
+}
+
+// at builds the wall clock time offset from midnight of a date. Adding the
+// offset to midnight instead would land an hour off on DST days.
+func at(y int, m time.Month, d int, offset time.Duration, location *time.Location) time.Time {
+	return time.Date(y, m, d, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, location)
+}
+
+// Registry holds the calendars by name. Calendars are replaced rather than
+// modified, so values returned by Get stay consistent.
+type Registry struct {
+	mu        sync.RWMutex
+	calendars map[string]Calendar
+}
+
+// NewRegistry creates a registry with the forex, CME and NYSE calendars.
+func NewRegistry() *Registry {
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	newYork, chicago := MustLocation("America/New_York"), MustLocation("America/Chicago")
+	r := &Registry{calendars: make(map[string]Calendar)}
+	for _, c := range []Calendar{
+		// Forex trades around the clock and rolls over at 17:00 New York.
+		{Name: "forex", Location: newYork, Open: 17 * time.Hour, Close: 17 * time.Hour, Days: weekdays},
+		{Name: "cme", Location: chicago, Open: 17 * time.Hour, Close: 16 * time.Hour, Days: weekdays},
+		{Name: "nyse", Location: newYork, Open: 9*time.Hour + 30*time.Minute, Close: 16 * time.Hour, Days: weekdays},
+	} {
+		r.calendars[c.Name] = c
+	}
+	return r
+}
+
+// Get returns a calendar by name.
+func (r *Registry) Get(name string) (Calendar, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.calendars[name]
+	return c, ok
+}
+
+// Names returns the calendar names in order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.calendars))
+	for name := range r.calendars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseHolidays validates holiday dates.
+func ParseHolidays(dates []string) (map[string]bool, error) {
+	holidays := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: want YYYY-MM-DD", date)
+		}
+		holidays[date] = true
+	}
+	return holidays, nil
+}
+
+// SetAllHolidays replaces the holidays of several calendars, keyed by
+// name. Nothing changes unless every calendar exists.
+func (r *Registry) SetAllHolidays(holidays map[string]map[string]bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name := range holidays {
+		if _, ok := r.calendars[name]; !ok {
+			return fmt.Errorf("unknown calendar %q", name)
+		}
+	}
+	for name, dates := range holidays {
+		c := r.calendars[name]
+		c.Holidays = dates
+		r.calendars[name] = c
+	}
+	return nil
+}
+
+// MustLocation loads a time zone, panicking if it is unknown. The zone
+// database is embedded, so it only fails for a misspelt name.
+func MustLocation(name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return location
+}
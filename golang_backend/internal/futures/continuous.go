@@ -0,0 +1,149 @@
+package futures
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Adjustment is the method used to remove roll gaps from a continuous series.
+type Adjustment string
+
+// Supported adjustments. Backward and Ratio keep the latest contract's
+// prices and shift or scale history; Forward keeps the first contract's
+// prices and shifts later data.
+const (
+	None     Adjustment = "none"
+	Backward Adjustment = "backward"
+	Forward  Adjustment = "forward"
+	Ratio    Adjustment = "ratio"
+)
+
+// Contract is one futures contract and its candles.
+type Contract struct {
+	Symbol  string
+	Expiry  time.Time
+	Candles []models.OHLC
+}
+
+// Roll describes a switch from one contract to the next. Gap is the
+// difference between the two closes on the last bar of the old contract.
+type Roll struct {
+	Time     time.Time `json:"time"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	OldClose float64   `json:"old_close"`
+	NewClose float64   `json:"new_close"`
+	Gap      float64   `json:"gap"`
+}
+
+// Continuous is a stitched series and the rolls used to build it.
+type Continuous struct {
+	Candles []models.OHLC `json:"candles"`
+	Rolls   []Roll        `json:"rolls"`
+}
+
+// Build stitches contracts into one series, rolling rollBefore ahead of each
+// expiry. Candles of each contract must be sorted by time, and consecutive
+// contracts must both have a bar at the last timestamp before the roll.
+// Ratio adjustment also requires both closes at each roll to be positive.
+func Build(contracts []Contract, rollBefore time.Duration, adjustment Adjustment) (Continuous, error) {
+	if len(contracts) == 0 {
+		return Continuous{}, fmt.Errorf("no contracts")
+	}
+	contracts = append([]Contract(nil), contracts...)
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Expiry.Before(contracts[j].Expiry)
+	})
+
+	var (
+		segments [][]models.OHLC
+		rolls    []Roll
+		start    time.Time
+	)
+	for i, contract := range contracts {
+		last := i == len(contracts)-1
+		rollAt := contract.Expiry.Add(-rollBefore)
+
+		var candles []models.OHLC
+		for _, candle := range contract.Candles {
+			if candle.Time.Before(start) || (!last && !candle.Time.Before(rollAt)) {
+				continue
+			}
+			candles = append(candles, candle)
+		}
+		if len(candles) == 0 {
+			return Continuous{}, fmt.Errorf("contract %s has no candles between %s and its roll", contract.Symbol, start.Format(time.RFC3339))
+		}
+		segments = append(segments, candles)
+		if last {
+			break
+		}
+
+		final := candles[len(candles)-1]
+		next, ok := closeAt(contracts[i+1].Candles, final.Time)
+		if !ok {
+			return Continuous{}, fmt.Errorf("contract %s has no bar at %s to roll into", contracts[i+1].Symbol, final.Time.Format(time.RFC3339))
+		}
+		if adjustment == Ratio && (final.Close <= 0 || next <= 0) {
+			return Continuous{}, fmt.Errorf("ratio adjustment needs positive closes at the %s roll into %s", contract.Symbol, contracts[i+1].Symbol)
+		}
+		rolls = append(rolls, Roll{
+			Time:     final.Time,
+			From:     contract.Symbol,
+			To:       contracts[i+1].Symbol,
+			OldClose: final.Close,
+			NewClose: next,
+			Gap:      next - final.Close,
+		})
+		start = final.Time.Add(time.Nanosecond)
+	}
+
+	return Continuous{Candles: adjust(segments, rolls, adjustment), Rolls: rolls}, nil
+}
+
+func adjust(segments [][]models.OHLC, rolls []Roll, adjustment Adjustment) []models.OHLC {
+	// Offset and scale applied to each segment.
+	offsets := make([]float64, len(segments))
+	scales := make([]float64, len(segments))
+	for i := range scales {
+		scales[i] = 1
+	}
+	switch adjustment {
+	case Backward:
+		for i := len(segments) - 2; i >= 0; i-- {
+			offsets[i] = offsets[i+1] + rolls[i].Gap
+		}
+	case Forward:
+		for i := 1; i < len(segments); i++ {
+			offsets[i] = offsets[i-1] - rolls[i-1].Gap
+		}
+	case Ratio:
+		for i := len(segments) - 2; i >= 0; i-- {
+			scales[i] = scales[i+1] * rolls[i].NewClose / rolls[i].OldClose
+		}
+	}
+
+	var candles []models.OHLC
+	for i, segment := range segments {
+		for _, candle := range segment {
+			candle.Open = candle.Open*scales[i] + offsets[i]
+			candle.High = candle.High*scales[i] + offsets[i]
+			candle.Low = candle.Low*scales[i] + offsets[i]
+			candle.Close = candle.Close*scales[i] + offsets[i]
+			candles = append(candles, candle)
+		}
+	}
+	return candles
+}
+
+func closeAt(candles []models.OHLC, t time.Time) (float64, bool) {
+	for _, candle := range candles {
+		if candle.Time.Equal(t) {
+			return candle.Close, true
+		}
+	}
+	return 0, false
+}
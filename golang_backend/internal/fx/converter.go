@@ -0,0 +1,181 @@
+package fx
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RateSource provides the price of one unit of base in quote currency.
+type RateSource interface {
+	Rate(base, quote string) (float64, error)
+}
+
+// StaticRates is an in-memory RateSource updated through the API.
+type StaticRates struct {
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+// NewStaticRates creates an empty rate table.
+func NewStaticRates() *StaticRates {
+	return &StaticRates{rates: make(map[string]float64)}
+}
+
+// Set stores the rate of a BASE/QUOTE pair.
+func (s *StaticRates) Set(base, quote string, rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rates[pair(base, quote)] = rate
+}
+
+// SetAll stores rates keyed by "BASE/QUOTE" alongside the existing ones.
+// Nothing changes unless every pair is valid and every rate is positive.
+func (s *StaticRates) SetAll(rates map[string]float64) error {
+	parsed := make(map[string]float64, len(rates))
+	for key, rate := range rates {
+		base, quote, err := ParsePair(key)
+		if err != nil {
+			return err
+		}
+		if rate <= 0 {
+			return fmt.Errorf("invalid rate %q: %v", key, rate)
+		}
+		parsed[pair(base, quote)] = rate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, rate := range parsed {
+		s.rates[key] = rate
+	}
+	return nil
+}
+
+// Rate returns the stored rate of a pair, or the inverse of the reverse pair.
+func (s *StaticRates) Rate(base, quote string) (float64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if rate, ok := s.rates[pair(base, quote)]; ok {
+		return rate, nil
+	}
+	if rate, ok := s.rates[pair(quote, base)]; ok && rate != 0 {
+		return 1 / rate, nil
+	}
+	return 0, fmt.Errorf("no rate for %s", pair(base, quote))
+}
+
+// Rates returns a copy of every stored rate keyed by "BASE/QUOTE".
+func (s *StaticRates) Rates() map[string]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rates := make(map[string]float64, len(s.rates))
+	for k, v := range s.rates {
+		rates[k] = v
+	}
+	return rates
+}
+
+// ParsePair splits a "BASE/QUOTE" pair.
+func ParsePair(key string) (string, string, error) {
+	base, quote, ok := strings.Cut(key, "/")
+	if !ok || base == "" || quote == "" {
+		return "", "", fmt.Errorf("invalid pair %q", key)
+	}
+	return base, quote, nil
+}
+
+// Restore replaces every rate with rates keyed by "BASE/QUOTE". Nothing
+// changes unless every pair is valid.
+func (s *StaticRates) Restore(rates map[string]float64) error {
+	restored := make(map[string]float64, len(rates))
+	for key, rate := range rates {
+		base, quote, err := ParsePair(key)
+		if err != nil {
+			return err
+		}
+		restored[pair(base, quote)] = rate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rates = restored
+	return nil
+}
+
+// Converter converts amounts between currencies. Pegged currencies (e.g.
+// USDT to USD) are treated as equal, and pairs missing from the source are
+// crossed through the pivot currency.
+type Converter struct {
+	source RateSource
+	pivot  string
+	pegs   map[string]string
+}
+
+// NewConverter creates a converter crossing missing pairs through pivot.
+func NewConverter(source RateSource, pivot string) *Converter {
+	return &Converter{
+		source: source,
+		pivot:  strings.ToUpper(pivot),
+		pegs:   make(map[string]string),
+	}
+}
+
+// Peg treats currency as equal to target, e.g. Peg("USDT", "USD").
+func (c *Converter) Peg(currency, target string) {
+	c.pegs[strings.ToUpper(currency)] = strings.ToUpper(target)
+}
+
+// Rate returns how many units of to one unit of from is worth.
+func (c *Converter) Rate(from, to string) (float64, error) {
+	from, to = c.resolve(from), c.resolve(to)
+	if from == to {
+		return 1, nil
+	}
+	if rate, err := c.source.Rate(from, to); err == nil {
+		return rate, nil
+	}
+	if from == c.pivot || to == c.pivot {
+		return 0, fmt.Errorf("no rate for %s", pair(from, to))
+	}
+
+	toPivot, err := c.source.Rate(from, c.pivot)
+	if err != nil {
+		return 0, err
+	}
+	fromPivot, err := c.source.Rate(c.pivot, to)
+	if err != nil {
+		return 0, err
+	}
+	return toPivot * fromPivot, nil
+}
+
+// Convert converts amount from one currency to another. An empty currency
+// is assumed to already be in the other one.
+func (c *Converter) Convert(amount float64, from, to string) (float64, error) {
+	if from == "" || to == "" {
+		return amount, nil
+	}
+	rate, err := c.Rate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	return amount * rate, nil
+}
+
+func (c *Converter) resolve(currency string) string {
+	currency = strings.ToUpper(currency)
+	if target, ok := c.pegs[currency]; ok {
+		return target
+	}
+	return currency
+}
+
+func pair(base, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
@@ -0,0 +1,126 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/abs/go_billing/utils"
+)
+
+// Lock is a lease shared by the instances of the service. Acquire takes
+// the lock for holder, or extends it when holder already has it, and
+// reports whether holder owns it; the lease lapses after ttl unless
+// extended.
+type Lock interface {
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, holder string) error
+}
+
+// ErrNotHolder is returned when state is saved on behalf of an instance
+// that does not hold the lock.
+var ErrNotHolder = errors.New("leader: lock not held")
+
+// State is the state the leader shares with the other instances. Save
+// fails with ErrNotHolder unless holder owns the lock, so only the current
+// leader writes it. Load returns nil when nothing was saved yet.
+type State interface {
+	Save(ctx context.Context, holder string, data []byte) error
+	Load(ctx context.Context) ([]byte, error)
+}
+
+// Elector campaigns for a Lock so that work meant to run on a single
+// instance, such as scheduled jobs, runs on exactly one. It renews the
+// lease at a third of its TTL and steps down as soon as a renewal fails,
+// before the lease can lapse and another instance take over.
+type Elector struct {
+	lock   Lock
+	id     string
+	ttl    time.Duration
+	clock  utils.Clock
+	leader atomic.Bool
+	// onElected runs before the instance reports itself leader.
+	onElected func(ctx context.Context) error
+	// contacted is when the lock last answered, in Unix nanoseconds.
+	contacted atomic.Int64
+}
+
+// NewElector creates an elector campaigning as id.
+func NewElector(lock Lock, id string, ttl time.Duration, clock utils.Clock) *Elector {
+	return &Elector{lock: lock, id: id, ttl: ttl, clock: clock}
+}
+
+// ID returns the instance id the elector campaigns as.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// OnElected sets a function run each time the instance wins the lock,
+// before it reports itself leader, for instance to load the state the
+// previous leader shared. The lock is given up again when it fails. It
+// must be set before Run.
+func (e *Elector) OnElected(fn func(ctx context.Context) error) {
+	e.onElected = fn
+}
+
+// IsLeader reports whether this instance currently holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Reachable reports whether the lock answered within the last TTL, that is
+// whether the elector knows who leads.
+func (e *Elector) Reachable() bool {
+	contacted := e.contacted.Load()
+	return contacted != 0 && e.clock.Now().Sub(time.Unix(0, contacted)) < e.ttl
+}
+
+// Run campaigns until ctx is done, then releases the lock if held.
+func (e *Elector) Run(ctx context.Context) {
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			if e.leader.Swap(false) {
+				release, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+				defer cancel()
+				if err := e.lock.Release(release, e.id); err != nil {
+					log.Print("leader: cannot release lock: ", err)
+				}
+			}
+			return
+		case <-e.clock.After(e.ttl / 3):
+		}
+	}
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	attempt, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	held, err := e.lock.Acquire(attempt, e.id, e.ttl)
+	if err != nil {
+		log.Print("leader: ", err)
+		held = false
+	} else {
+		e.contacted.Store(e.clock.Now().UnixNano())
+	}
+	if held && !e.leader.Load() && e.onElected != nil {
+		if err := e.onElected(attempt); err != nil {
+			log.Print("leader: cannot take over: ", err)
+			if err := e.lock.Release(attempt, e.id); err != nil {
+				log.Print("leader: cannot release lock: ", err)
+			}
+			held = false
+		}
+	}
+	if was := e.leader.Swap(held); was != held {
+		if held {
+			log.Printf("leader: %s elected", e.id)
+		} else {
+			log.Printf("leader: %s stepped down", e.id)
+		}
+	}
+}
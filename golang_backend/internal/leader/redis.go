@@ -0,0 +1,87 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript takes the lock when it is free and extends it when holder
+// already has it, returning 1 when holder owns the lock afterwards.
+var acquireScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+return 0`)
+
+// releaseScript deletes the lock only if holder still owns it.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0`)
+
+// saveScript writes the shared state only if holder still owns the lock,
+// so that a deposed leader cannot overwrite its successor's state.
+var saveScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('SET', KEYS[2], ARGV[2])
+	return 1
+end
+return 0`)
+
+// RedisLock is a Lock held as a Redis key with a TTL. It is also the State
+// the leader shares, kept next to the lock under the key suffixed with
+// ":state".
+type RedisLock struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisLock creates a lock on key. The client carries the address,
+// credentials, TLS and database settings, as parsed from a redis:// or
+// rediss:// URL.
+func NewRedisLock(client *redis.Client, key string) *RedisLock {
+	return &RedisLock{client: client, key: key}
+}
+
+// Acquire implements Lock.
+func (l *RedisLock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	n, err := acquireScript.Run(ctx, l.client, []string{l.key}, holder, ttl.Milliseconds()).Int64()
+	return n == 1, err
+}
+
+// Release implements Lock.
+func (l *RedisLock) Release(ctx context.Context, holder string) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, holder).Err()
+}
+
+// Save implements State.
+func (l *RedisLock) Save(ctx context.Context, holder string, data []byte) error {
+	n, err := saveScript.Run(ctx, l.client, []string{l.key, l.stateKey()}, holder, data).Int64()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return ErrNotHolder
+	}
+	return nil
+}
+
+// Load implements State.
+func (l *RedisLock) Load(ctx context.Context) ([]byte, error) {
+	data, err := l.client.Get(ctx, l.stateKey()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (l *RedisLock) stateKey() string {
+	return l.key + ":state"
+}
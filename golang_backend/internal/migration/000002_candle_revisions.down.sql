@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS "historical_candles_revision" ON "historical_candles";
+DROP FUNCTION IF EXISTS "record_candle_revision";
+DROP TABLE IF EXISTS "historical_candle_revisions";
+DROP INDEX IF EXISTS "historical_candles_symbol_timeframe_timestamp_idx";
+ALTER TABLE "historical_candles"
+    ALTER COLUMN "created_datetime" DROP NOT NULL,
+    ALTER COLUMN "created_datetime" DROP DEFAULT;
\ No newline at end of file
@@ -0,0 +1,55 @@
+-- Candles were inserted without a key; keep the latest row of each.
+DELETE FROM "historical_candles" older
+USING "historical_candles" newer
+WHERE older."symbol" = newer."symbol"
+  AND older."timeframe" = newer."timeframe"
+  AND older."timestamp" = newer."timestamp"
+  AND older."id" < newer."id";
+
+-- A candle is known from created_datetime, which point-in-time reads
+-- depend on; candles recorded without one are taken as known at their
+-- own timestamp.
+UPDATE "historical_candles" SET "created_datetime" = "timestamp" WHERE "created_datetime" IS NULL;
+ALTER TABLE "historical_candles"
+    ALTER COLUMN "created_datetime" SET DEFAULT now(),
+    ALTER COLUMN "created_datetime" SET NOT NULL;
+
+CREATE UNIQUE INDEX "historical_candles_symbol_timeframe_timestamp_idx"
+    ON "historical_candles" ("symbol", "timeframe", "timestamp");
+
+-- Every value a candle held before a correction, valid from valid_from
+-- until the correction at valid_to.
+CREATE TABLE "historical_candle_revisions" (
+    "id" bigserial PRIMARY KEY,
+    "symbol" varchar NOT NULL,
+    "timeframe" varchar NOT NULL,
+    "timestamp" timestamp NOT NULL,
+    "open" double precision NOT NULL,
+    "close" double precision NOT NULL,
+    "high" double precision NOT NULL,
+    "low" double precision NOT NULL,
+    "volume" double precision NOT NULL,
+    "valid_from" timestamp NOT NULL,
+    "valid_to" timestamp NOT NULL
+);
+
+CREATE INDEX "historical_candle_revisions_lookup_idx"
+    ON "historical_candle_revisions" ("symbol", "timeframe", "timestamp", "valid_from");
+
+CREATE FUNCTION "record_candle_revision"() RETURNS trigger AS $$
+BEGIN
+    IF (OLD."open", OLD."high", OLD."low", OLD."close", OLD."volume") IS DISTINCT FROM
+       (NEW."open", NEW."high", NEW."low", NEW."close", NEW."volume") THEN
+        INSERT INTO "historical_candle_revisions"
+            ("symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume", "valid_from", "valid_to")
+        VALUES
+            (OLD."symbol", OLD."timeframe", OLD."timestamp", OLD."open", OLD."close", OLD."high", OLD."low", OLD."volume",
+             COALESCE(OLD."updated_datetime", OLD."created_datetime"), COALESCE(NEW."updated_datetime", now()));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "historical_candles_revision"
+    BEFORE UPDATE ON "historical_candles"
+    FOR EACH ROW EXECUTE FUNCTION "record_candle_revision"();
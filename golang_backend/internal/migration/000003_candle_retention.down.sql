@@ -0,0 +1,2 @@
+DROP FUNCTION IF EXISTS "compact_candles";
+DROP TABLE IF EXISTS "candle_retention_policies";
\ No newline at end of file
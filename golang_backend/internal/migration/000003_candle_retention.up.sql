@@ -0,0 +1,72 @@
+-- Candles of a timeframe older than max_age are rolled up into
+-- downsample_timeframe bars of downsample_interval, then deleted. A NULL
+-- max_age keeps them forever and a NULL downsample_timeframe deletes them
+-- without a roll-up.
+CREATE TABLE "candle_retention_policies" (
+    "timeframe" varchar PRIMARY KEY,
+    "max_age" interval NULL,
+    "downsample_timeframe" varchar NULL,
+    "downsample_interval" interval NULL,
+    "updated_datetime" timestamp NOT NULL DEFAULT now(),
+    CHECK (("downsample_timeframe" IS NULL) = ("downsample_interval" IS NULL)),
+    CHECK ("downsample_timeframe" IS NULL OR "max_age" IS NOT NULL)
+);
+
+INSERT INTO "candle_retention_policies" ("timeframe", "max_age", "downsample_timeframe", "downsample_interval") VALUES
+    ('1m', '90 days', '1h', '1 hour'),
+    ('5m', '180 days', '1h', '1 hour'),
+    ('15m', '1 year', '1h', '1 hour'),
+    ('1h', NULL, NULL, NULL),
+    ('4h', NULL, NULL, NULL),
+    ('1d', NULL, NULL, NULL);
+
+-- compact_candles applies every policy as of now and returns the number
+-- of candles deleted per timeframe. Only buckets that have fully expired
+-- are rolled up, so a bar is never built from part of its candles, and
+-- bars that already exist in the target timeframe are kept.
+CREATE FUNCTION "compact_candles"("as_of" timestamp)
+RETURNS TABLE ("timeframe" varchar, "deleted" bigint) AS $$
+#variable_conflict use_column
+DECLARE
+    policy record;
+    cutoff timestamp;
+    removed bigint;
+BEGIN
+    FOR policy IN
+        SELECT * FROM "candle_retention_policies" p WHERE p."max_age" IS NOT NULL ORDER BY p."timeframe"
+    LOOP
+        cutoff := as_of - policy."max_age";
+        IF policy."downsample_interval" IS NOT NULL THEN
+            cutoff := date_bin(policy."downsample_interval", cutoff, timestamp '2000-01-03');
+
+            INSERT INTO "historical_candles"
+                ("symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume", "created_datetime")
+            SELECT
+                c."symbol",
+                policy."downsample_timeframe",
+                date_bin(policy."downsample_interval", c."timestamp", timestamp '2000-01-03') AS bucket,
+                (array_agg(c."open" ORDER BY c."timestamp"))[1],
+                (array_agg(c."close" ORDER BY c."timestamp" DESC))[1],
+                max(c."high"),
+                min(c."low"),
+                sum(c."volume"),
+                as_of
+            FROM "historical_candles" c
+            WHERE c."timeframe" = policy."timeframe" AND c."timestamp" < cutoff
+            GROUP BY c."symbol", bucket
+            ON CONFLICT ("symbol", "timeframe", "timestamp") DO NOTHING;
+        END IF;
+
+        DELETE FROM "historical_candles" c
+        WHERE c."timeframe" = policy."timeframe" AND c."timestamp" < cutoff;
+        GET DIAGNOSTICS removed = ROW_COUNT;
+
+        DELETE FROM "historical_candle_revisions" r
+        WHERE r."timeframe" = policy."timeframe" AND r."timestamp" < cutoff;
+
+        "timeframe" := policy."timeframe";
+        "deleted" := removed;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,188 @@
+package ml
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Feature names used by the anomaly detector.
+var anomalyFeatures = []string{"return", "volume", "range"}
+
+// Anomaly is a bar scored as unusual. Score is the isolation forest score
+// in (0, 1]; Kind names the feature furthest from its mean.
+type Anomaly struct {
+	Index  int       `json:"index"`
+	Time   time.Time `json:"time"`
+	Score  float64   `json:"score"`
+	Kind   string    `json:"kind"`
+	Return float64   `json:"return"`
+	Volume float64   `json:"volume"`
+	Range  float64   `json:"range"`
+}
+
+// ForestParams configures the isolation forest.
+type ForestParams struct {
+	Trees      int
+	SampleSize int
+	Threshold  float64
+	Seed       int64
+}
+
+type isolationNode struct {
+	feature     int
+	split       float64
+	left, right *isolationNode
+	size        int
+}
+
+// DetectAnomalies scores every bar after the first by log return, volume
+// and relative range (high-low over close) with an isolation forest and
+// returns the bars scoring at least params.Threshold. Bars whose close or
+// previous close is not positive are not scored.
+func DetectAnomalies(candles []models.OHLC, params ForestParams) []Anomaly {
+	if len(candles) < 3 {
+		return nil
+	}
+
+	// index[j] is the candle scored by data[j]; bars without a positive
+	// close on both sides have no log return and are skipped.
+	data := make([][]float64, 0, len(candles)-1)
+	index := make([]int, 0, len(candles)-1)
+	for i := 1; i < len(candles); i++ {
+		if candles[i-1].Close <= 0 || candles[i].Close <= 0 {
+			continue
+		}
+		data = append(data, []float64{
+			math.Log(candles[i].Close / candles[i-1].Close),
+			candles[i].Volume,
+			(candles[i].High - candles[i].Low) / candles[i].Close,
+		})
+		index = append(index, i)
+	}
+	if len(data) < 2 {
+		return nil
+	}
+
+	sampleSize := min(params.SampleSize, len(data))
+	maxDepth := int(math.Ceil(math.Log2(float64(sampleSize))))
+	rng := rand.New(rand.NewSource(params.Seed))
+	trees := make([]*isolationNode, params.Trees)
+	for t := range trees {
+		sample := rng.Perm(len(data))[:sampleSize]
+		trees[t] = buildIsolationTree(data, sample, 0, maxDepth, rng)
+	}
+
+	means, stdDevs := columnStats(data)
+	var anomalies []Anomaly
+	for i, x := range data {
+		var total float64
+		for _, tree := range trees {
+			total += pathLength(x, tree, 0)
+		}
+		score := math.Pow(2, -(total/float64(len(trees)))/averagePathLength(sampleSize))
+		if score < params.Threshold {
+			continue
+		}
+
+		kind, furthest := 0, -1.0
+		for f := range x {
+			if stdDevs[f] == 0 {
+				continue
+			}
+			if z := math.Abs(x[f]-means[f]) / stdDevs[f]; z > furthest {
+				kind, furthest = f, z
+			}
+		}
+		anomalies = append(anomalies, Anomaly{
+			Index:  index[i],
+			Time:   candles[index[i]].Time,
+			Score:  score,
+			Kind:   anomalyFeatures[kind],
+			Return: x[0],
+			Volume: x[1],
+			Range:  x[2],
+		})
+	}
+	return anomalies
+}
+
+func buildIsolationTree(data [][]float64, rows []int, depth, maxDepth int, rng *rand.Rand) *isolationNode {
+	if depth >= maxDepth || len(rows) <= 1 {
+		return &isolationNode{size: len(rows)}
+	}
+
+	feature := rng.Intn(len(data[rows[0]]))
+	lo, hi := data[rows[0]][feature], data[rows[0]][feature]
+	for _, r := range rows {
+		lo = math.Min(lo, data[r][feature])
+		hi = math.Max(hi, data[r][feature])
+	}
+	if lo == hi {
+		return &isolationNode{size: len(rows)}
+	}
+
+	split := lo + rng.Float64()*(hi-lo)
+	var left, right []int
+	for _, r := range rows {
+		if data[r][feature] < split {
+			left = append(left, r)
+		} else {
+			right = append(right, r)
+		}
+	}
+	return &isolationNode{
+		feature: feature,
+		split:   split,
+		left:    buildIsolationTree(data, left, depth+1, maxDepth, rng),
+		right:   buildIsolationTree(data, right, depth+1, maxDepth, rng),
+	}
+}
+
+func pathLength(x []float64, node *isolationNode, depth int) float64 {
+	if node.left == nil {
+		return float64(depth) + averagePathLength(node.size)
+	}
+	if x[node.feature] < node.split {
+		return pathLength(x, node.left, depth+1)
+	}
+	return pathLength(x, node.right, depth+1)
+}
+
+// averagePathLength is the average path length of an unsuccessful search
+// in a binary search tree of n nodes, used to normalise path lengths.
+func averagePathLength(n int) float64 {
+	switch {
+	case n > 2:
+		return 2*(math.Log(float64(n-1))+0.5772156649) - 2*float64(n-1)/float64(n)
+	case n == 2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func columnStats(data [][]float64) ([]float64, []float64) {
+	width := len(data[0])
+	means := make([]float64, width)
+	stdDevs := make([]float64, width)
+	for _, row := range data {
+		for f, v := range row {
+			means[f] += v
+		}
+	}
+	for f := range means {
+		means[f] /= float64(len(data))
+	}
+	for _, row := range data {
+		for f, v := range row {
+			stdDevs[f] += (v - means[f]) * (v - means[f])
+		}
+	}
+	for f := range stdDevs {
+		stdDevs[f] = math.Sqrt(stdDevs[f] / float64(len(data)))
+	}
+	return means, stdDevs
+}
@@ -0,0 +1,175 @@
+package ml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Calibration methods.
+const (
+	Platt    = "platt"
+	Isotonic = "isotonic"
+)
+
+// Calibrator maps a raw signal score to an empirical win probability.
+type Calibrator interface {
+	Probability(score float64) float64
+}
+
+// Calibrated is a raw score with its calibrated win probability.
+type Calibrated struct {
+	Score       float64 `json:"score"`
+	Probability float64 `json:"probability"`
+}
+
+// Fit fits a calibrator of the given method to historical outcomes.
+func Fit(method string, outcomes []models.Outcome) (Calibrator, error) {
+	switch method {
+	case Platt:
+		return FitPlatt(outcomes), nil
+	case Isotonic:
+		return FitIsotonic(outcomes), nil
+	default:
+		return nil, fmt.Errorf("unknown calibration method %q", method)
+	}
+}
+
+// Calibrate applies calibrator to every score.
+func Calibrate(calibrator Calibrator, scores []float64) []Calibrated {
+	calibrated := make([]Calibrated, len(scores))
+	for i, score := range scores {
+		calibrated[i] = Calibrated{Score: score, Probability: calibrator.Probability(score)}
+	}
+	return calibrated
+}
+
+// PlattCalibrator is the sigmoid 1 / (1 + exp(A*score + B)).
+type PlattCalibrator struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// Probability implements Calibrator.
+func (c PlattCalibrator) Probability(score float64) float64 {
+	return 1 / (1 + math.Exp(c.A*score+c.B))
+}
+
+// FitPlatt fits Platt scaling with Newton's method, using Platt's smoothed
+// targets so that perfectly separated outcomes do not diverge.
+func FitPlatt(outcomes []models.Outcome) PlattCalibrator {
+	var wins, losses float64
+	for _, o := range outcomes {
+		if o.Win {
+			wins++
+		} else {
+			losses++
+		}
+	}
+	hiTarget := (wins + 1) / (wins + 2)
+	loTarget := 1 / (losses + 2)
+
+	c := PlattCalibrator{B: math.Log((losses + 1) / (wins + 1))}
+	for iter := 0; iter < 100; iter++ {
+		// Gradient and Hessian of the cross-entropy in (A, B).
+		var gA, gB, hAA, hAB, hBB float64
+		for _, o := range outcomes {
+			target := loTarget
+			if o.Win {
+				target = hiTarget
+			}
+			p := c.Probability(o.Score)
+			d := target - p
+			w := p * (1 - p)
+			gA += d * o.Score
+			gB += d
+			hAA += w * o.Score * o.Score
+			hAB += w * o.Score
+			hBB += w
+		}
+		det := hAA*hBB - hAB*hAB
+		if det == 0 {
+			break
+		}
+		dA := -(hBB*gA - hAB*gB) / det
+		dB := -(hAA*gB - hAB*gA) / det
+		c.A += dA
+		c.B += dB
+		if math.Abs(dA) < 1e-9 && math.Abs(dB) < 1e-9 {
+			break
+		}
+	}
+	return c
+}
+
+// IsotonicCalibrator is a non-decreasing step function of the score,
+// interpolated linearly between the centres of its blocks.
+type IsotonicCalibrator struct {
+	Scores        []float64 `json:"scores"`
+	Probabilities []float64 `json:"probabilities"`
+}
+
+// Probability implements Calibrator.
+func (c IsotonicCalibrator) Probability(score float64) float64 {
+	n := len(c.Scores)
+	if n == 0 {
+		return 0.5
+	}
+	i := sort.SearchFloat64s(c.Scores, score)
+	switch {
+	case i == 0:
+		return c.Probabilities[0]
+	case i == n:
+		return c.Probabilities[n-1]
+	}
+	lo, hi := c.Scores[i-1], c.Scores[i]
+	t := (score - lo) / (hi - lo)
+	return c.Probabilities[i-1] + t*(c.Probabilities[i]-c.Probabilities[i-1])
+}
+
+// FitIsotonic fits isotonic regression with the pool adjacent violators
+// algorithm. Wins sort before losses of the same score so that tied scores
+// always pool into one block.
+func FitIsotonic(outcomes []models.Outcome) IsotonicCalibrator {
+	sorted := append([]models.Outcome(nil), outcomes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score < sorted[j].Score
+		}
+		return sorted[i].Win && !sorted[j].Win
+	})
+
+	type block struct{ scoreSum, winSum, weight float64 }
+	var blocks []block
+	for _, o := range sorted {
+		b := block{scoreSum: o.Score, weight: 1}
+		if o.Win {
+			b.winSum = 1
+		}
+		blocks = append(blocks, b)
+		for len(blocks) > 1 {
+			last, prev := blocks[len(blocks)-1], blocks[len(blocks)-2]
+			if prev.winSum/prev.weight < last.winSum/last.weight {
+				break
+			}
+			blocks = blocks[:len(blocks)-1]
+			blocks[len(blocks)-1] = block{
+				scoreSum: prev.scoreSum + last.scoreSum,
+				winSum:   prev.winSum + last.winSum,
+				weight:   prev.weight + last.weight,
+			}
+		}
+	}
+
+	c := IsotonicCalibrator{
+		Scores:        make([]float64, len(blocks)),
+		Probabilities: make([]float64, len(blocks)),
+	}
+	for i, b := range blocks {
+		c.Scores[i] = b.scoreSum / b.weight
+		c.Probabilities[i] = b.winSum / b.weight
+	}
+	return c
+}
@@ -0,0 +1,66 @@
+package ml
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Fold is one train/test split of sample indices.
+type Fold struct {
+	Train []int `json:"train"`
+	Test  []int `json:"test"`
+}
+
+// PurgedKFold splits time ordered samples into k contiguous test folds
+// (López de Prado, Advances in Financial Machine Learning, ch. 7). A sample
+// spans times[i] to labelEnds[i]; training samples whose span overlaps the
+// test fold's span are purged, and the embargo fraction of all samples
+// right after the test fold is dropped as well to limit serial leakage.
+func PurgedKFold(times, labelEnds []time.Time, k int, embargo float64) ([]Fold, error) {
+	n := len(times)
+	if len(labelEnds) != n {
+		return nil, fmt.Errorf("%d label ends for %d samples", len(labelEnds), n)
+	}
+	if k < 2 || k > n {
+		return nil, fmt.Errorf("cannot split %d samples into %d folds", n, k)
+	}
+	for i := range times {
+		if i > 0 && times[i].Before(times[i-1]) {
+			return nil, fmt.Errorf("sample %d is before sample %d", i, i-1)
+		}
+		if labelEnds[i].Before(times[i]) {
+			return nil, fmt.Errorf("sample %d ends before it starts", i)
+		}
+	}
+	embargoSize := int(math.Ceil(float64(n) * embargo))
+
+	folds := make([]Fold, k)
+	for f := range folds {
+		start, end := f*n/k, (f+1)*n/k
+		testStart := times[start]
+		testEnd := labelEnds[start]
+		for _, t := range labelEnds[start:end] {
+			if t.After(testEnd) {
+				testEnd = t
+			}
+		}
+
+		fold := Fold{Train: []int{}, Test: make([]int, 0, end-start)}
+		for i := start; i < end; i++ {
+			fold.Test = append(fold.Test, i)
+		}
+		for i := 0; i < start; i++ {
+			if labelEnds[i].Before(testStart) {
+				fold.Train = append(fold.Train, i)
+			}
+		}
+		for i := end + embargoSize; i < n; i++ {
+			if times[i].After(testEnd) {
+				fold.Train = append(fold.Train, i)
+			}
+		}
+		folds[f] = fold
+	}
+	return folds, nil
+}
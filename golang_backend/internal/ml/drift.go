@@ -0,0 +1,114 @@
+package ml
+
+import (
+	"math"
+	"sort"
+)
+
+// DriftThresholds decide when a feature has drifted: a population
+// stability index of at least PSI or a KS p-value below KSPValue.
+type DriftThresholds struct {
+	PSI      float64
+	KSPValue float64
+	Bins     int
+}
+
+// Drift compares the live distribution of one feature with its training
+// distribution.
+type Drift struct {
+	Feature  string  `json:"feature"`
+	PSI      float64 `json:"psi"`
+	KS       float64 `json:"ks"`
+	KSPValue float64 `json:"ks_p_value"`
+	Drifted  bool    `json:"drifted"`
+}
+
+// CompareDistributions computes PSI and the two-sample KS test between the
+// reference and live samples of a feature.
+func CompareDistributions(feature string, reference, live []float64, thresholds DriftThresholds) Drift {
+	d := Drift{Feature: feature}
+	if len(reference) == 0 || len(live) == 0 {
+		return d
+	}
+
+	ref := append([]float64(nil), reference...)
+	cur := append([]float64(nil), live...)
+	sort.Float64s(ref)
+	sort.Float64s(cur)
+
+	d.PSI = psi(ref, cur, thresholds.Bins)
+	d.KS = ksStatistic(ref, cur)
+	d.KSPValue = ksPValue(d.KS, len(ref), len(cur))
+	d.Drifted = d.PSI >= thresholds.PSI || d.KSPValue < thresholds.KSPValue
+	return d
+}
+
+// psi bins both sorted samples on the reference quantiles and sums
+// (live - ref) * ln(live / ref) over the bins. Bin shares are floored so
+// that empty bins stay finite.
+func psi(ref, cur []float64, bins int) float64 {
+	edges := make([]float64, 0, bins-1)
+	for b := 1; b < bins; b++ {
+		edges = append(edges, ref[b*len(ref)/bins])
+	}
+
+	share := func(sample []float64) []float64 {
+		shares := make([]float64, bins)
+		lo := 0
+		for b := range shares {
+			hi := len(sample)
+			if b < len(edges) {
+				hi = sort.SearchFloat64s(sample, edges[b])
+			}
+			shares[b] = math.Max(float64(hi-lo)/float64(len(sample)), 1e-4)
+			lo = hi
+		}
+		return shares
+	}
+
+	expected, actual := share(ref), share(cur)
+	var total float64
+	for b := range expected {
+		total += (actual[b] - expected[b]) * math.Log(actual[b]/expected[b])
+	}
+	return total
+}
+
+// ksStatistic is the largest distance between the empirical CDFs of two
+// sorted samples.
+func ksStatistic(a, b []float64) float64 {
+	var i, j int
+	var d float64
+	for i < len(a) && j < len(b) {
+		x := math.Min(a[i], b[j])
+		for i < len(a) && a[i] == x {
+			i++
+		}
+		for j < len(b) && b[j] == x {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/float64(len(a))-float64(j)/float64(len(b))))
+	}
+	return d
+}
+
+// ksPValue approximates the two-sample KS p-value with the asymptotic
+// Kolmogorov distribution (Numerical Recipes, 14.3).
+func ksPValue(d float64, n, m int) float64 {
+	en := math.Sqrt(float64(n*m) / float64(n+m))
+	lambda := (en + 0.12 + 0.11/en) * d
+	if lambda < 1e-3 {
+		return 1
+	}
+
+	var sum, sign float64 = 0, 1
+	for k := 1; k <= 100; k++ {
+		term := 2 * sign * math.Exp(-2*lambda*lambda*float64(k*k))
+		sum += term
+		if math.Abs(term) < 1e-10 {
+			return math.Min(math.Max(sum, 0), 1)
+		}
+		sign = -sign
+	}
+	return 1
+}
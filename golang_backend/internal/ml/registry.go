@@ -0,0 +1,207 @@
+package ml
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/utils"
+)
+
+// Version is one registered version of a model. Traffic is the fraction of
+// requests routed to it, zero when it is not deployed.
+type Version struct {
+	Version      string             `json:"version"`
+	Metadata     map[string]string  `json:"metadata,omitempty"`
+	Metrics      map[string]float64 `json:"metrics,omitempty"`
+	RegisteredAt time.Time          `json:"registered_at"`
+	Traffic      float64            `json:"traffic"`
+	Routed       int                `json:"routed"`
+	Outcomes     int                `json:"outcomes"`
+	OutcomeSum   float64            `json:"outcome_sum"`
+	OutcomeMean  float64            `json:"outcome_mean"`
+}
+
+// Model is a named model and its versions, ordered by registration.
+type Model struct {
+	Name     string    `json:"name"`
+	Versions []Version `json:"versions"`
+}
+
+// Registry keeps model versions, their traffic split and the outcomes
+// recorded for each version so that rollouts can be compared.
+type Registry struct {
+	mu     sync.Mutex
+	models map[string]*Model
+	rng    *rand.Rand
+	clock  utils.Clock
+}
+
+// NewRegistry creates an empty registry that stamps versions with clock.
+func NewRegistry(clock utils.Clock) *Registry {
+	return &Registry{
+		models: make(map[string]*Model),
+		rng:    rand.New(rand.NewSource(clock.Now().UnixNano())),
+		clock:  clock,
+	}
+}
+
+// Register adds a new version of a model. The first version of a model
+// receives all traffic until a deployment says otherwise.
+func (r *Registry) Register(name, version string, metadata map[string]string, metrics map[string]float64) (Model, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.models[name]
+	if !ok {
+		m = &Model{Name: name}
+		r.models[name] = m
+	}
+	if m.version(version) != nil {
+		return Model{}, fmt.Errorf("model %s already has version %s", name, version)
+	}
+
+	v := Version{
+		Version:      version,
+		Metadata:     metadata,
+		Metrics:      metrics,
+		RegisteredAt: r.clock.Now(),
+	}
+	if len(m.Versions) == 0 {
+		v.Traffic = 1
+	}
+	m.Versions = append(m.Versions, v)
+	return m.copy(), nil
+}
+
+// Deploy sets the traffic split of a model. Fractions are normalised to sum
+// to one and versions left out receive no traffic.
+func (r *Registry) Deploy(name string, traffic map[string]float64) (Model, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.models[name]
+	if !ok {
+		return Model{}, fmt.Errorf("unknown model %q", name)
+	}
+	var total float64
+	for version, fraction := range traffic {
+		if m.version(version) == nil {
+			return Model{}, fmt.Errorf("model %s has no version %s", name, version)
+		}
+		if fraction < 0 {
+			return Model{}, fmt.Errorf("traffic for %s %s is negative", name, version)
+		}
+		total += fraction
+	}
+	if total == 0 {
+		return Model{}, fmt.Errorf("deployment of %s routes no traffic", name)
+	}
+
+	for i := range m.Versions {
+		m.Versions[i].Traffic = traffic[m.Versions[i].Version] / total
+	}
+	return m.copy(), nil
+}
+
+// Route picks the version serving a request. A non-empty key, such as a
+// symbol or account, always maps to the same version for a given split;
+// otherwise the version is drawn at random.
+func (r *Registry) Route(name, key string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.models[name]
+	if !ok {
+		return "", fmt.Errorf("unknown model %q", name)
+	}
+
+	var u float64
+	if key != "" {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		u = float64(h.Sum64()>>11) / (1 << 53)
+	} else {
+		u = r.rng.Float64()
+	}
+
+	last := -1
+	for i, v := range m.Versions {
+		if v.Traffic == 0 {
+			continue
+		}
+		last = i
+		if u < v.Traffic {
+			break
+		}
+		u -= v.Traffic
+	}
+	if last < 0 {
+		return "", fmt.Errorf("model %s is not deployed", name)
+	}
+	m.Versions[last].Routed++
+	return m.Versions[last].Version, nil
+}
+
+// Record adds the outcome of a prediction, such as its PnL or whether it
+// was correct, to the version that made it.
+func (r *Registry) Record(name, version string, outcome float64) (Model, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.models[name]
+	if !ok {
+		return Model{}, fmt.Errorf("unknown model %q", name)
+	}
+	v := m.version(version)
+	if v == nil {
+		return Model{}, fmt.Errorf("model %s has no version %s", name, version)
+	}
+	v.Outcomes++
+	v.OutcomeSum += outcome
+	v.OutcomeMean = v.OutcomeSum / float64(v.Outcomes)
+	return m.copy(), nil
+}
+
+// Models returns every model sorted by name.
+func (r *Registry) Models() []Model {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	models := make([]Model, 0, len(r.models))
+	for _, m := range r.models {
+		models = append(models, m.copy())
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models
+}
+
+// Restore replaces every model.
+func (r *Registry) Restore(models []Model) {
+	restored := make(map[string]*Model, len(models))
+	for _, m := range models {
+		m := m.copy()
+		restored[m.Name] = &m
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.models = restored
+}
+
+func (m *Model) version(version string) *Version {
+	for i := range m.Versions {
+		if m.Versions[i].Version == version {
+			return &m.Versions[i]
+		}
+	}
+	return nil
+}
+
+func (m *Model) copy() Model {
+	return Model{Name: m.Name, Versions: append([]Version(nil), m.Versions...)}
+}
@@ -0,0 +1,120 @@
+package onchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/abs/go_billing/internal/upstream"
+	"github.com/abs/go_billing/models"
+)
+
+// Supported metrics.
+const (
+	ExchangeNetFlow = "exchange_net_flow"
+	ActiveAddresses = "active_addresses"
+	MVRV            = "mvrv"
+)
+
+// Point is one observation of an on-chain metric.
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Provider fetches on-chain metric series for an asset (e.g. "BTC").
+type Provider interface {
+	Series(ctx context.Context, asset, metric string, from, to time.Time) ([]Point, error)
+}
+
+// HTTPProvider reads metrics from a Glassnode-compatible REST API, which
+// returns [{"t": unix, "v": value}] for /v1/metrics/{path}.
+type HTTPProvider struct {
+	baseURL string
+	apiKey  string
+	client  *upstream.Client
+}
+
+var metricPaths = map[string]string{
+	ExchangeNetFlow: "transactions/transfers_volume_exchanges_net",
+	ActiveAddresses: "addresses/active_count",
+	MVRV:            "market/mvrv",
+}
+
+// NewHTTPProvider creates a provider for the API at baseURL that sends its
+// requests through client.
+func NewHTTPProvider(baseURL, apiKey string, client *upstream.Client) *HTTPProvider {
+	return &HTTPProvider{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  client,
+	}
+}
+
+// Series implements Provider.
+func (p *HTTPProvider) Series(ctx context.Context, asset, metric string, from, to time.Time) ([]Point, error) {
+	path, ok := metricPaths[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown on-chain metric %q", metric)
+	}
+
+	query := url.Values{}
+	query.Set("a", asset)
+	query.Set("s", strconv.FormatInt(from.Unix(), 10))
+	query.Set("u", strconv.FormatInt(to.Unix(), 10))
+	query.Set("api_key", p.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/v1/metrics/"+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("on-chain provider returned %s for %s", resp.Status, metric)
+	}
+
+	var rows []struct {
+		T int64   `json:"t"`
+		V float64 `json:"v"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("cannot decode %s: %w", metric, err)
+	}
+
+	points := make([]Point, len(rows))
+	for i, row := range rows {
+		points[i] = Point{Time: time.Unix(row.T, 0).UTC(), Value: row.V}
+	}
+	return points, nil
+}
+
+// Align returns, for every candle, the latest metric value published at or
+// before the candle time, or nil before the first point. Later points are
+// never used, so aligned series carry no lookahead.
+func Align(points []Point, candles []models.OHLC) []*float64 {
+	points = append([]Point(nil), points...)
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+
+	aligned := make([]*float64, len(candles))
+	for i, candle := range candles {
+		n := sort.Search(len(points), func(j int) bool {
+			return points[j].Time.After(candle.Time)
+		})
+		if n > 0 {
+			value := points[n-1].Value
+			aligned[i] = &value
+		}
+	}
+	return aligned
+}
@@ -0,0 +1,233 @@
+package patterns
+
+import (
+	"math"
+	"time"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Chart pattern types.
+const (
+	Flag    = "flag"
+	Pennant = "pennant"
+	Channel = "channel"
+)
+
+const (
+	poleBars         = 8
+	minConsolidation = 5
+	maxConsolidation = 20
+	channelBars      = 30
+)
+
+// Line is a boundary of a pattern from its first to its last bar.
+type Line struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// ChartPattern is a flag, pennant or channel. For flags and pennants
+// StartIndex is the start of the pole, Upper and Lower bound the
+// consolidation and Target is the measured move: the pole height added to
+// the breakout side. Channels project their own width instead.
+type ChartPattern struct {
+	Type       string    `json:"type"`
+	Direction  string    `json:"direction,omitempty"`
+	StartIndex int       `json:"start_index"`
+	PoleEnd    int       `json:"pole_end,omitempty"`
+	EndIndex   int       `json:"end_index"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Upper      Line      `json:"upper"`
+	Lower      Line      `json:"lower"`
+	Target     float64   `json:"target,omitempty"`
+}
+
+// DetectChartPatterns finds flags and pennants (an impulsive pole followed
+// by a tight consolidation) and parallel channels. Patterns of one family
+// do not overlap each other.
+func DetectChartPatterns(candles []models.OHLC) []ChartPattern {
+	found := []ChartPattern{}
+	for p := poleBars; p+minConsolidation < len(candles); p++ {
+		if pattern, ok := continuation(candles, p); ok {
+			found = append(found, pattern)
+			p = pattern.EndIndex
+		}
+	}
+	for s := 0; s+channelBars <= len(candles); s++ {
+		if pattern, ok := channel(candles, s); ok {
+			found = append(found, pattern)
+			s = pattern.EndIndex
+		}
+	}
+	return found
+}
+
+// continuation tests for a flag or pennant whose pole ends at p, preferring
+// the longest consolidation that qualifies. The pole must cover at least
+// 60% of the ranges of its bars, and the consolidation may neither be
+// taller than nor retrace more than half of the pole.
+func continuation(c []models.OHLC, p int) (ChartPattern, bool) {
+	s := p - poleBars
+	move := c[p].Close - c[s].Close
+	var ranges float64
+	for _, bar := range c[s+1 : p+1] {
+		ranges += bar.High - bar.Low
+	}
+	if move == 0 || math.Abs(move) < 0.6*ranges {
+		return ChartPattern{}, false
+	}
+	up := move > 0
+
+	highBuf, lowBuf := utils.GetFloats(maxConsolidation), utils.GetFloats(maxConsolidation)
+	defer utils.PutFloats(highBuf)
+	defer utils.PutFloats(lowBuf)
+	for n := min(maxConsolidation, len(c)-1-p); n >= minConsolidation; n-- {
+		cons := c[p+1 : p+1+n]
+		high, low := cons[0].High, cons[0].Low
+		for _, bar := range cons {
+			high = math.Max(high, bar.High)
+			low = math.Min(low, bar.Low)
+		}
+		if high-low > 0.5*math.Abs(move) {
+			continue
+		}
+		if up && low < c[p].Close-0.5*move || !up && high > c[p].Close-0.5*move {
+			continue
+		}
+
+		su, iu := fitLine(highs(*highBuf, cons))
+		sl, il := fitLine(lows(*lowBuf, cons))
+		var kind string
+		switch {
+		case su < 0 && sl > 0:
+			kind = Pennant
+		case parallel(su, sl, high-low, n) && (up && su <= 0 || !up && su >= 0):
+			kind = Flag
+		default:
+			continue
+		}
+
+		pattern := ChartPattern{
+			Type:       kind,
+			Direction:  models.Bearish,
+			StartIndex: s,
+			PoleEnd:    p,
+			EndIndex:   p + n,
+			StartTime:  c[s].Time,
+			EndTime:    c[p+n].Time,
+			Upper:      Line{Start: iu, End: iu + su*float64(n-1)},
+			Lower:      Line{Start: il, End: il + sl*float64(n-1)},
+		}
+		if up {
+			pattern.Direction = models.Bullish
+			pattern.Target = pattern.Upper.End + move
+		} else {
+			pattern.Target = pattern.Lower.End + move
+		}
+		return pattern, true
+	}
+	return ChartPattern{}, false
+}
+
+// channel tests the channelBars bars from s for a parallel channel: the
+// regression lines of highs and lows are parallel, shifted out to contain
+// every bar, and each side is touched at least twice.
+func channel(c []models.OHLC, s int) (ChartPattern, bool) {
+	bars := c[s : s+channelBars]
+	buf := utils.GetFloats(channelBars)
+	defer utils.PutFloats(buf)
+	su, _ := fitLine(highs(*buf, bars))
+	sl, _ := fitLine(lows(*buf, bars))
+	slope := (su + sl) / 2
+
+	upper, lower := math.Inf(-1), math.Inf(1)
+	for i, bar := range bars {
+		upper = math.Max(upper, bar.High-slope*float64(i))
+		lower = math.Min(lower, bar.Low-slope*float64(i))
+	}
+	width := upper - lower
+	if width <= 0 || !parallel(su, sl, width, channelBars) {
+		return ChartPattern{}, false
+	}
+
+	var upperTouches, lowerTouches int
+	for i, bar := range bars {
+		if upper+slope*float64(i)-bar.High <= 0.1*width {
+			upperTouches++
+		}
+		if bar.Low-(lower+slope*float64(i)) <= 0.1*width {
+			lowerTouches++
+		}
+	}
+	if upperTouches < 2 || lowerTouches < 2 {
+		return ChartPattern{}, false
+	}
+
+	last := float64(channelBars - 1)
+	pattern := ChartPattern{
+		Type:       Channel,
+		StartIndex: s,
+		EndIndex:   s + channelBars - 1,
+		StartTime:  bars[0].Time,
+		EndTime:    bars[channelBars-1].Time,
+		Upper:      Line{Start: upper, End: upper + slope*last},
+		Lower:      Line{Start: lower, End: lower + slope*last},
+	}
+	switch {
+	case slope*last > 0.5*width:
+		pattern.Direction = models.Bullish
+		pattern.Target = pattern.Upper.End + width
+	case slope*last < -0.5*width:
+		pattern.Direction = models.Bearish
+		pattern.Target = pattern.Lower.End - width
+	}
+	return pattern, true
+}
+
+// parallel reports whether two slopes diverge by less than 30% of height
+// over n bars.
+func parallel(a, b, height float64, n int) bool {
+	return math.Abs(a-b)*float64(n) <= 0.3*height
+}
+
+// fitLine fits ys against 0..n-1 by least squares and returns the slope and
+// the value at 0.
+func fitLine(ys []float64) (float64, float64) {
+	n := float64(len(ys))
+	var sx, sy, sxx, sxy float64
+	for i, y := range ys {
+		x := float64(i)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0, sy / n
+	}
+	slope := (n*sxy - sx*sy) / den
+	return slope, (sy - slope*sx) / n
+}
+
+// highs writes the highs of c into dst, which must be at least as long as
+// c, and returns it truncated to len(c).
+func highs(dst []float64, c []models.OHLC) []float64 {
+	values := dst[:len(c)]
+	for i, bar := range c {
+		values[i] = bar.High
+	}
+	return values
+}
+
+// lows writes the lows of c into dst like highs.
+func lows(dst []float64, c []models.OHLC) []float64 {
+	values := dst[:len(c)]
+	for i, bar := range c {
+		values[i] = bar.Low
+	}
+	return values
+}
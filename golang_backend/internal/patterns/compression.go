@@ -0,0 +1,144 @@
+package patterns
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Compression setup types.
+const (
+	InsideBar = "inside_bar"
+	NR4       = "nr4"
+	NR7       = "nr7"
+)
+
+// Setup is a volatility compression and how it resolved. High and Low are
+// the range to break: the mother bar of an inside-bar chain or the narrow
+// bar itself. Breakout is the direction of the first close outside the
+// range, empty while it has not broken.
+type Setup struct {
+	Type          string    `json:"type"`
+	Index         int       `json:"index"`
+	Time          time.Time `json:"time"`
+	ChainLength   int       `json:"chain_length,omitempty"`
+	High          float64   `json:"high"`
+	Low           float64   `json:"low"`
+	Breakout      string    `json:"breakout,omitempty"`
+	BreakoutIndex int       `json:"breakout_index,omitempty"`
+}
+
+// Compression holds the compression series aligned with candles and the
+// setups they produced. InsideChain is the number of consecutive inside
+// bars ending at each candle, all measured against the same mother bar.
+type Compression struct {
+	InsideChain []int   `json:"inside_chain"`
+	OutsideBar  []bool  `json:"outside_bar"`
+	NR4         []bool  `json:"nr4"`
+	NR7         []bool  `json:"nr7"`
+	Setups      []Setup `json:"setups"`
+}
+
+// DetectCompression finds inside-bar chains, outside bars and NR4/NR7 bars
+// and tracks the breakout direction of each setup.
+func DetectCompression(candles []models.OHLC) Compression {
+	comp := Compression{
+		InsideChain: make([]int, len(candles)),
+		OutsideBar:  make([]bool, len(candles)),
+		NR4:         make([]bool, len(candles)),
+		NR7:         make([]bool, len(candles)),
+		Setups:      []Setup{},
+	}
+
+	mother := -1
+	for i := 1; i < len(candles); i++ {
+		comp.OutsideBar[i] = outsideBar(candles, i)
+		comp.NR4[i] = narrowest(candles, i, 4)
+		comp.NR7[i] = narrowest(candles, i, 7)
+
+		// A chain keeps its mother bar while bars stay inside it.
+		if mother >= 0 && within(candles[i], candles[mother]) {
+			comp.InsideChain[i] = comp.InsideChain[i-1] + 1
+		} else if insideBar(candles, i) {
+			mother = i - 1
+			comp.InsideChain[i] = 1
+		} else {
+			mother = -1
+		}
+
+		// Report a chain once, on its last inside bar.
+		if comp.InsideChain[i-1] > 0 && comp.InsideChain[i] == 0 {
+			comp.Setups = append(comp.Setups, chainSetup(candles, i-1, comp.InsideChain[i-1]))
+		}
+		if comp.NR7[i] {
+			comp.Setups = append(comp.Setups, rangeSetup(candles, i, NR7))
+		} else if comp.NR4[i] {
+			comp.Setups = append(comp.Setups, rangeSetup(candles, i, NR4))
+		}
+	}
+	if last := len(candles) - 1; last > 0 && comp.InsideChain[last] > 0 {
+		comp.Setups = append(comp.Setups, chainSetup(candles, last, comp.InsideChain[last]))
+	}
+	return comp
+}
+
+func chainSetup(c []models.OHLC, end, length int) Setup {
+	mother := c[end-length]
+	setup := Setup{
+		Type:        InsideBar,
+		Index:       end,
+		Time:        c[end].Time,
+		ChainLength: length,
+		High:        mother.High,
+		Low:         mother.Low,
+	}
+	trackBreakout(c, &setup)
+	return setup
+}
+
+func rangeSetup(c []models.OHLC, i int, kind string) Setup {
+	setup := Setup{Type: kind, Index: i, Time: c[i].Time, High: c[i].High, Low: c[i].Low}
+	trackBreakout(c, &setup)
+	return setup
+}
+
+func trackBreakout(c []models.OHLC, setup *Setup) {
+	for j := setup.Index + 1; j < len(c); j++ {
+		switch {
+		case c[j].Close > setup.High:
+			setup.Breakout, setup.BreakoutIndex = models.Bullish, j
+			return
+		case c[j].Close < setup.Low:
+			setup.Breakout, setup.BreakoutIndex = models.Bearish, j
+			return
+		}
+	}
+}
+
+func within(inner, outer models.OHLC) bool {
+	return inner.High <= outer.High && inner.Low >= outer.Low
+}
+
+// insideBar: the bar's range lies within the previous bar's range.
+func insideBar(c []models.OHLC, i int) bool {
+	return within(c[i], c[i-1])
+}
+
+// outsideBar: the bar takes out both the previous high and low.
+func outsideBar(c []models.OHLC, i int) bool {
+	return c[i].High > c[i-1].High && c[i].Low < c[i-1].Low
+}
+
+// narrowest: the bar's range is narrower than each of the previous n-1.
+func narrowest(c []models.OHLC, i, n int) bool {
+	if i < n-1 {
+		return false
+	}
+	r := c[i].High - c[i].Low
+	for j := i - n + 1; j < i; j++ {
+		if c[j].High-c[j].Low <= r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package patterns
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/abs/go_billing/models"
+)
+
+// trendLookback is the number of bars before a pattern used to decide the
+// trend it reverses.
+const trendLookback = 5
+
+// Definition describes a supported pattern. Candles is the number of bars
+// it spans; a detection is reported on its last bar. Direction is empty for
+// neutral patterns such as compression bars. DescriptionKey is the
+// key frontends use to look up localised names and tooltips.
+type Definition struct {
+	Name           string `json:"name"`
+	Candles        int    `json:"candles"`
+	Direction      string `json:"direction,omitempty"`
+	Trend          string `json:"trend,omitempty"`
+	DescriptionKey string `json:"description_key"`
+
+	detect func(c []models.OHLC, i int) bool
+}
+
+var definitions = []Definition{
+	{Name: "three_line_strike_bullish", Candles: 4, Direction: models.Bullish, Trend: models.Bearish, detect: threeLineStrikeBullish},
+	{Name: "three_line_strike_bearish", Candles: 4, Direction: models.Bearish, Trend: models.Bullish, detect: threeLineStrikeBearish},
+	{Name: "kicker_bullish", Candles: 2, Direction: models.Bullish, Trend: models.Bearish, detect: kickerBullish},
+	{Name: "kicker_bearish", Candles: 2, Direction: models.Bearish, Trend: models.Bullish, detect: kickerBearish},
+	{Name: "abandoned_baby_bullish", Candles: 3, Direction: models.Bullish, Trend: models.Bearish, detect: abandonedBabyBullish},
+	{Name: "abandoned_baby_bearish", Candles: 3, Direction: models.Bearish, Trend: models.Bullish, detect: abandonedBabyBearish},
+	{Name: InsideBar, Candles: 2, detect: insideBar},
+	{Name: "outside_bar", Candles: 2, detect: outsideBar},
+	{Name: NR4, Candles: 4, detect: func(c []models.OHLC, i int) bool { return narrowest(c, i, 4) }},
+	{Name: NR7, Candles: 7, detect: func(c []models.OHLC, i int) bool { return narrowest(c, i, 7) }},
+}
+
+// Catalog returns the definitions of every supported pattern sorted by name.
+func Catalog() []Definition {
+	catalog := append([]Definition(nil), definitions...)
+	for i := range catalog {
+		catalog[i].DescriptionKey = "patterns." + catalog[i].Name
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Name < catalog[j].Name })
+	return catalog
+}
+
+// Detect runs the named patterns, or all of them when names is empty, and
+// returns one series per pattern aligned with candles.
+func Detect(candles []models.OHLC, names []string) (map[string][]bool, error) {
+	selected, err := selectDefinitions(names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]bool, len(selected))
+	for _, def := range selected {
+		series := make([]bool, len(candles))
+		for i := def.Candles - 1; i < len(candles); i++ {
+			series[i] = def.detect(candles, i)
+		}
+		result[def.Name] = series
+	}
+	return result, nil
+}
+
+// DetectIndices runs the named patterns like Detect but only returns the
+// indices where each pattern completed, which is far smaller than the full
+// series for rare patterns and never allocates the series.
+func DetectIndices(candles []models.OHLC, names []string) (map[string][]int, error) {
+	selected, err := selectDefinitions(names)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]int, len(selected))
+	for _, def := range selected {
+		hits := []int{}
+		for i := def.Candles - 1; i < len(candles); i++ {
+			if def.detect(candles, i) {
+				hits = append(hits, i)
+			}
+		}
+		result[def.Name] = hits
+	}
+	return result, nil
+}
+
+// selectDefinitions resolves pattern names, dropping duplicates, or returns
+// every definition when names is empty.
+func selectDefinitions(names []string) ([]Definition, error) {
+	if len(names) == 0 {
+		return definitions, nil
+	}
+	selected := make([]Definition, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		def, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown pattern %q", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			selected = append(selected, def)
+		}
+	}
+	return selected, nil
+}
+
+func lookup(name string) (Definition, bool) {
+	for _, def := range definitions {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+func bullish(c models.OHLC) bool { return c.Close > c.Open }
+func bearish(c models.OHLC) bool { return c.Close < c.Open }
+
+func body(c models.OHLC) float64 { return math.Abs(c.Close - c.Open) }
+
+// bodyRatio is the share of the bar's range taken by its body.
+func bodyRatio(c models.OHLC) float64 {
+	if c.High == c.Low {
+		return 0
+	}
+	return body(c) / (c.High - c.Low)
+}
+
+// trend returns the direction of closes over the lookback bars ending just
+// before start, or "" when there is not enough history or no move.
+func trend(c []models.OHLC, start int) string {
+	end := start - 1
+	if end-trendLookback < 0 {
+		return ""
+	}
+	switch {
+	case c[end].Close > c[end-trendLookback].Close:
+		return models.Bullish
+	case c[end].Close < c[end-trendLookback].Close:
+		return models.Bearish
+	default:
+		return ""
+	}
+}
+
+// threeLineStrikeBullish: three falling bearish bars in a downtrend, then a
+// bar opening below the third close that closes above the first bar's high.
+func threeLineStrikeBullish(c []models.OHLC, i int) bool {
+	a, b, d, e := c[i-3], c[i-2], c[i-1], c[i]
+	return trend(c, i-3) == models.Bearish &&
+		bearish(a) && bearish(b) && bearish(d) &&
+		b.Close < a.Close && d.Close < b.Close &&
+		e.Open < d.Close && e.Close > a.High
+}
+
+// threeLineStrikeBearish mirrors threeLineStrikeBullish in an uptrend.
+func threeLineStrikeBearish(c []models.OHLC, i int) bool {
+	a, b, d, e := c[i-3], c[i-2], c[i-1], c[i]
+	return trend(c, i-3) == models.Bullish &&
+		bullish(a) && bullish(b) && bullish(d) &&
+		b.Close > a.Close && d.Close > b.Close &&
+		e.Open > d.Close && e.Close < a.Low
+}
+
+// kickerBullish: a strong bearish bar followed by a strong bullish bar that
+// gaps open above the bearish bar's open.
+func kickerBullish(c []models.OHLC, i int) bool {
+	a, b := c[i-1], c[i]
+	return trend(c, i-1) == models.Bearish &&
+		bearish(a) && bullish(b) &&
+		bodyRatio(a) >= 0.6 && bodyRatio(b) >= 0.6 &&
+		b.Open > a.Open
+}
+
+// kickerBearish mirrors kickerBullish.
+func kickerBearish(c []models.OHLC, i int) bool {
+	a, b := c[i-1], c[i]
+	return trend(c, i-1) == models.Bullish &&
+		bullish(a) && bearish(b) &&
+		bodyRatio(a) >= 0.6 && bodyRatio(b) >= 0.6 &&
+		b.Open < a.Open
+}
+
+// abandonedBabyBullish: a bearish bar, a doji gapping fully below it and a
+// bullish bar gapping fully above the doji.
+func abandonedBabyBullish(c []models.OHLC, i int) bool {
+	a, b, d := c[i-2], c[i-1], c[i]
+	return trend(c, i-2) == models.Bearish &&
+		bearish(a) && bodyRatio(b) <= 0.1 && bullish(d) &&
+		b.High < a.Low && d.Low > b.High
+}
+
+// abandonedBabyBearish mirrors abandonedBabyBullish.
+func abandonedBabyBearish(c []models.OHLC, i int) bool {
+	a, b, d := c[i-2], c[i-1], c[i]
+	return trend(c, i-2) == models.Bullish &&
+		bullish(a) && bodyRatio(b) <= 0.1 && bearish(d) &&
+		b.Low > a.High && d.High < b.Low
+}
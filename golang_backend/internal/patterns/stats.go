@@ -0,0 +1,145 @@
+package patterns
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/abs/go_billing/models"
+)
+
+// StatsParams configures pattern statistics: returns are measured Horizon
+// bars after a pattern completes, and combinations seen fewer than
+// MinOccurrences times are left out as noise.
+type StatsParams struct {
+	Horizon        int
+	MinOccurrences int
+}
+
+// PatternStats is the forward-return record of one pattern on one symbol.
+// Returns are signed by the pattern's direction, so a bearish pattern
+// followed by a fall scores positive; neutral patterns, which predict a
+// move but not its direction, score the absolute return. Edge is the mean
+// return less the same measure over every bar of the symbol, the return a
+// trader would have had without the pattern.
+type PatternStats struct {
+	Symbol      string  `json:"symbol"`
+	Pattern     string  `json:"pattern"`
+	Direction   string  `json:"direction,omitempty"`
+	Occurrences int     `json:"occurrences"`
+	WinRate     float64 `json:"win_rate"`
+	MeanReturn  float64 `json:"mean_return"`
+	Baseline    float64 `json:"baseline"`
+	Edge        float64 `json:"edge"`
+}
+
+// UniverseStats runs the named patterns, or all of them, over every symbol
+// of a universe in parallel and returns the symbol/pattern combinations
+// ranked by edge. A win is a positive signed return or, for neutral
+// patterns, a move larger than the symbol's average.
+func UniverseStats(universe map[string][]models.OHLC, names []string, params StatsParams) ([]PatternStats, error) {
+	selected, err := selectDefinitions(names)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make(chan string)
+	results := make(chan []PatternStats)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.GOMAXPROCS(0), len(universe)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for symbol := range symbols {
+				results <- symbolStats(symbol, universe[symbol], selected, params)
+			}
+		}()
+	}
+	go func() {
+		for symbol := range universe {
+			symbols <- symbol
+		}
+		close(symbols)
+		wg.Wait()
+		close(results)
+	}()
+
+	ranked := []PatternStats{}
+	for stats := range results {
+		ranked = append(ranked, stats...)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Edge != b.Edge {
+			return a.Edge > b.Edge
+		}
+		if a.Occurrences != b.Occurrences {
+			return a.Occurrences > b.Occurrences
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Pattern < b.Pattern
+	})
+	return ranked, nil
+}
+
+func symbolStats(symbol string, candles []models.OHLC, selected []Definition, params StatsParams) []PatternStats {
+	n := len(candles) - params.Horizon
+	if n <= 0 {
+		return nil
+	}
+	returns := make([]float64, n)
+	var baseline, baselineMove float64
+	for i := range returns {
+		if candles[i].Close != 0 {
+			returns[i] = (candles[i+params.Horizon].Close - candles[i].Close) / candles[i].Close
+		}
+		baseline += returns[i]
+		baselineMove += math.Abs(returns[i])
+	}
+	baseline /= float64(n)
+	baselineMove /= float64(n)
+
+	var stats []PatternStats
+	for _, def := range selected {
+		s := PatternStats{Symbol: symbol, Pattern: def.Name, Direction: def.Direction}
+		var sum float64
+		var wins int
+		for i := def.Candles - 1; i < n; i++ {
+			if !def.detect(candles, i) {
+				continue
+			}
+			r := returns[i]
+			switch def.Direction {
+			case models.Bearish:
+				r = -r
+			case "":
+				r = math.Abs(r)
+			}
+			s.Occurrences++
+			sum += r
+			if (def.Direction == "" && r > baselineMove) || (def.Direction != "" && r > 0) {
+				wins++
+			}
+		}
+		if s.Occurrences == 0 || s.Occurrences < params.MinOccurrences {
+			continue
+		}
+
+		switch def.Direction {
+		case models.Bullish:
+			s.Baseline = baseline
+		case models.Bearish:
+			s.Baseline = -baseline
+		default:
+			s.Baseline = baselineMove
+		}
+		s.MeanReturn = sum / float64(s.Occurrences)
+		s.WinRate = float64(wins) / float64(s.Occurrences)
+		s.Edge = s.MeanReturn - s.Baseline
+		stats = append(stats, s)
+	}
+	return stats
+}
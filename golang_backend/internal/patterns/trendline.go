@@ -0,0 +1,163 @@
+package patterns
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+)
+
+// Trendline types.
+const (
+	Support    = "support"
+	Resistance = "resistance"
+)
+
+// MaxTrendlineSwings is the most swing highs, and swing lows, Trendlines
+// fits lines through: the latest ones. Every pair of them is checked
+// against the closes between, so the cost grows with their square.
+const MaxTrendlineSwings = 50
+
+// TrendlineParams configures trendline fitting. Tolerance is the distance,
+// as a fraction of the average bar range, within which a swing touches a
+// line and beyond which a close breaks it.
+type TrendlineParams struct {
+	SwingStrength int
+	MinTouches    int
+	Tolerance     float64
+}
+
+// Trendline is a line through swing points, price = Intercept + Slope*i
+// for candle index i. Distance is the last close minus the line's current
+// value.
+type Trendline struct {
+	Type         string     `json:"type"`
+	Slope        float64    `json:"slope"`
+	Intercept    float64    `json:"intercept"`
+	StartIndex   int        `json:"start_index"`
+	StartTime    time.Time  `json:"start_time"`
+	Touches      int        `json:"touches"`
+	TouchIndices []int      `json:"touch_indices"`
+	Current      float64    `json:"current"`
+	Distance     float64    `json:"distance"`
+	DistancePct  float64    `json:"distance_pct"`
+	Broken       bool       `json:"broken"`
+	BreakIndex   int        `json:"break_index,omitempty"`
+	BreakTime    *time.Time `json:"break_time,omitempty"`
+}
+
+// Trendlines fits support lines through swing lows and resistance lines
+// through swing highs with a pairwise scan of the latest
+// MaxTrendlineSwings swings of each kind. A pair of swings defines a
+// candidate if no close between them crosses the line; each first anchor
+// keeps its candidate with the most touches, then the longest. Anchors
+// already touching a kept line do not start another one.
+func Trendlines(candles []models.OHLC, params TrendlineParams) []Trendline {
+	lines := []Trendline{}
+	if len(candles) == 0 {
+		return lines
+	}
+
+	var ranges float64
+	for _, c := range candles {
+		ranges += c.High - c.Low
+	}
+	tolerance := params.Tolerance * ranges / float64(len(candles))
+
+	var highs, lows []smc.Swing
+	for _, s := range smc.Swings(candles, params.SwingStrength) {
+		if s.Type == smc.SwingHigh {
+			highs = append(highs, s)
+		} else {
+			lows = append(lows, s)
+		}
+	}
+	lines = append(lines, fitTrendlines(candles, latest(lows), Support, tolerance, params.MinTouches)...)
+	lines = append(lines, fitTrendlines(candles, latest(highs), Resistance, tolerance, params.MinTouches)...)
+	return lines
+}
+
+func latest(swings []smc.Swing) []smc.Swing {
+	return swings[max(len(swings)-MaxTrendlineSwings, 0):]
+}
+
+func fitTrendlines(candles []models.OHLC, swings []smc.Swing, kind string, tolerance float64, minTouches int) []Trendline {
+	// side is +1 when closes must stay above the line, -1 below.
+	side := 1.0
+	if kind == Resistance {
+		side = -1
+	}
+	last := len(candles) - 1
+
+	var lines []Trendline
+	covered := make(map[int]bool)
+	for a := range swings {
+		if covered[swings[a].Index] {
+			continue
+		}
+		var best *Trendline
+		var bestEnd int
+		for b := a + 1; b < len(swings); b++ {
+			sa, sb := swings[a], swings[b]
+			slope := (sb.Price - sa.Price) / float64(sb.Index-sa.Index)
+			intercept := sa.Price - slope*float64(sa.Index)
+			at := func(i int) float64 { return intercept + slope*float64(i) }
+
+			valid := true
+			for i := sa.Index; i <= sb.Index; i++ {
+				if side*(candles[i].Close-at(i)) < -tolerance {
+					valid = false
+					break
+				}
+			}
+			if !valid {
+				continue
+			}
+
+			line := Trendline{
+				Type:       kind,
+				Slope:      slope,
+				Intercept:  intercept,
+				StartIndex: sa.Index,
+				StartTime:  sa.Time,
+			}
+			end := last
+			for i := sb.Index + 1; i <= last; i++ {
+				if side*(candles[i].Close-at(i)) < -tolerance {
+					t := candles[i].Time
+					line.Broken, line.BreakIndex, line.BreakTime = true, i, &t
+					end = i
+					break
+				}
+			}
+			for _, s := range swings[a:] {
+				if s.Index > end {
+					break
+				}
+				if math.Abs(s.Price-at(s.Index)) <= tolerance {
+					line.TouchIndices = append(line.TouchIndices, s.Index)
+				}
+			}
+			line.Touches = len(line.TouchIndices)
+
+			if best == nil || line.Touches > best.Touches || line.Touches == best.Touches && sb.Index > bestEnd {
+				best, bestEnd = &line, sb.Index
+			}
+		}
+		if best == nil || best.Touches < minTouches {
+			continue
+		}
+		best.Current = best.Intercept + best.Slope*float64(last)
+		best.Distance = candles[last].Close - best.Current
+		best.DistancePct = 100 * best.Distance / candles[last].Close
+		for _, i := range best.TouchIndices {
+			covered[i] = true
+		}
+		lines = append(lines, *best)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Touches > lines[j].Touches })
+	return lines
+}
@@ -0,0 +1,29 @@
+package pnl
+
+import "github.com/abs/go_billing/internal/fx"
+
+// Convert returns the report with PnL amounts and totals expressed in
+// currency. Prices stay in each symbol's own currency.
+func (r Report) Convert(converter *fx.Converter, currency string) (Report, error) {
+	converted := Report{
+		Method:    r.Method,
+		Currency:  currency,
+		Positions: make([]PositionPnL, len(r.Positions)),
+	}
+	for i, position := range r.Positions {
+		rate, err := converter.Convert(1, position.Currency, currency)
+		if err != nil {
+			return Report{}, err
+		}
+		position.Realized *= rate
+		position.Unrealized *= rate
+		position.Fees *= rate
+
+		converted.Positions[i] = position
+		converted.Realized += position.Realized
+		converted.Unrealized += position.Unrealized
+		converted.Fees += position.Fees
+	}
+	converted.Total = converted.Realized + converted.Unrealized
+	return converted, nil
+}
@@ -0,0 +1,80 @@
+package pnl
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// longTerm is the holding period after which a lot counts as long-term.
+const longTerm = 365 * 24 * time.Hour
+
+// WriteFillsCSV writes one row per fill.
+func WriteFillsCSV(w io.Writer, fills []models.Fill) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "date", "symbol", "side", "quantity", "price", "fee", "total"})
+	for _, fill := range fills {
+		total := fill.Quantity * fill.Price
+		if fill.Side == models.Buy {
+			total += fill.Fee
+		} else {
+			total -= fill.Fee
+		}
+		writer.Write([]string{
+			fill.ID,
+			fill.Time.UTC().Format(time.RFC3339),
+			fill.Symbol,
+			fill.Side,
+			formatFloat(fill.Quantity),
+			formatFloat(fill.Price),
+			formatFloat(fill.Fee),
+			formatFloat(total),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteLotsCSV writes one row per closed lot in the usual capital gains
+// layout (acquired/sold dates, proceeds, cost basis, gain), with amounts in
+// the currency of the row. Lots whose proceeds are their opening value,
+// shorts and longs of inverse contracts, are acquired at the closing fill
+// and sold at the opening one.
+func WriteLotsCSV(w io.Writer, lots []ClosedLot) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"description", "date_acquired", "date_sold", "proceeds", "cost_basis", "fees", "gain", "holding_days", "term", "currency"})
+	for _, lot := range lots {
+		qty := math.Abs(lot.Quantity)
+		acquired, sold := lot.OpenTime, lot.CloseTime
+		if (lot.Quantity < 0) != lot.Inverse {
+			acquired, sold = lot.CloseTime, lot.OpenTime
+		}
+
+		term := "short"
+		if lot.Holding > longTerm {
+			term = "long"
+		}
+		writer.Write([]string{
+			formatFloat(qty) + " " + lot.Symbol,
+			acquired.UTC().Format("2006-01-02"),
+			sold.UTC().Format("2006-01-02"),
+			formatFloat(lot.Proceeds),
+			formatFloat(lot.CostBasis),
+			formatFloat(lot.Fees),
+			formatFloat(lot.Realized),
+			strconv.Itoa(int(lot.Holding.Hours() / 24)),
+			term,
+			lot.Currency,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
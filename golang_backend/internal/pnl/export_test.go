@@ -0,0 +1,72 @@
+package pnl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+func TestWriteLotsCSVDates(t *testing.T) {
+	opened := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	closed := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		instrument string
+		open       string
+		close      string
+		acquired   time.Time
+		sold       time.Time
+	}{
+		{"linear long", models.Linear, models.Buy, models.Sell, opened, closed},
+		{"linear short", models.Linear, models.Sell, models.Buy, closed, opened},
+		{"inverse long", models.Inverse, models.Buy, models.Sell, closed, opened},
+		{"inverse short", models.Inverse, models.Sell, models.Buy, opened, closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledger, err := NewLedger(FIFO)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger.SetInstrument(models.Instrument{Symbol: "XBTUSD", Type: tt.instrument, Multiplier: 100})
+			ledger.Apply(models.Fill{ID: "1", Symbol: "XBTUSD", Side: tt.open, Quantity: 2, Price: 20000, Fee: 0.001, Time: opened})
+			ledger.Apply(models.Fill{ID: "2", Symbol: "XBTUSD", Side: tt.close, Quantity: 2, Price: 25000, Fee: 0.001, Time: closed})
+
+			var buf bytes.Buffer
+			if err := WriteLotsCSV(&buf, ledger.ClosedLots()); err != nil {
+				t.Fatal(err)
+			}
+			rows, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != 2 {
+				t.Fatalf("got %d rows, want a header and one lot", len(rows))
+			}
+			row := rows[1]
+			if want := tt.acquired.Format("2006-01-02"); row[1] != want {
+				t.Errorf("date_acquired = %s, want %s", row[1], want)
+			}
+			if want := tt.sold.Format("2006-01-02"); row[2] != want {
+				t.Errorf("date_sold = %s, want %s", row[2], want)
+			}
+
+			var amounts [4]float64
+			for i := range amounts {
+				if amounts[i], err = strconv.ParseFloat(row[3+i], 64); err != nil {
+					t.Fatal(err)
+				}
+			}
+			proceeds, cost, fees, gain := amounts[0], amounts[1], amounts[2], amounts[3]
+			if math.Abs(proceeds-cost-fees-gain) > 1e-9 {
+				t.Errorf("proceeds %v - cost %v - fees %v != gain %v", proceeds, cost, fees, gain)
+			}
+		})
+	}
+}
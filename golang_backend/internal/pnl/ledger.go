@@ -0,0 +1,392 @@
+package pnl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Method is the lot matching method used when a fill reduces a position.
+type Method string
+
+// Supported accounting methods.
+const (
+	FIFO    Method = "fifo"
+	LIFO    Method = "lifo"
+	Average Method = "average"
+)
+
+const epsilon = 1e-9
+
+// ClosedLot is the part of an opening lot closed by a later fill.
+// Quantity is negative for closed shorts. Fees include the prorated entry
+// and exit fees, and Realized is net of them. Proceeds and CostBasis are
+// the lot's values at its two fills in Currency, the settlement currency,
+// such that Proceeds - CostBasis - Fees = Realized. An inverse contract
+// valued in its base currency loses value as the price rises, so a long
+// lot's proceeds are its value at the opening fill; Inverse marks such lots.
+type ClosedLot struct {
+	Symbol      string        `json:"symbol"`
+	Currency    string        `json:"currency"`
+	Inverse     bool          `json:"inverse,omitempty"`
+	Quantity    float64       `json:"quantity"`
+	OpenFillID  string        `json:"open_fill_id"`
+	CloseFillID string        `json:"close_fill_id"`
+	OpenTime    time.Time     `json:"open_time"`
+	CloseTime   time.Time     `json:"close_time"`
+	OpenPrice   float64       `json:"open_price"`
+	ClosePrice  float64       `json:"close_price"`
+	Fees        float64       `json:"fees"`
+	Proceeds    float64       `json:"proceeds"`
+	CostBasis   float64       `json:"cost_basis"`
+	Realized    float64       `json:"realized"`
+	Holding     time.Duration `json:"holding"`
+}
+
+// PositionPnL is the PnL of one symbol. Realized is net of the fees of the
+// closed quantity; Fees is every fee paid on the symbol. Prices are in the
+// symbol's Currency.
+type PositionPnL struct {
+	Symbol       string  `json:"symbol"`
+	Currency     string  `json:"currency"`
+	Quantity     float64 `json:"quantity"`
+	AveragePrice float64 `json:"average_price"`
+	MarkPrice    float64 `json:"mark_price"`
+	Realized     float64 `json:"realized"`
+	Unrealized   float64 `json:"unrealized"`
+	Fees         float64 `json:"fees"`
+}
+
+// Report is the PnL of every symbol in the ledger. Totals are in Currency;
+// a report straight from the ledger has no currency and its totals only make
+// sense when every symbol settles in the same currency.
+type Report struct {
+	Method     Method        `json:"method"`
+	Currency   string        `json:"currency,omitempty"`
+	Positions  []PositionPnL `json:"positions"`
+	Realized   float64       `json:"realized"`
+	Unrealized float64       `json:"unrealized"`
+	Fees       float64       `json:"fees"`
+	Total      float64       `json:"total"`
+}
+
+// lot is an open lot. Fee is the entry fee not yet charged to a closed lot.
+// Instrument and currency are those the opening fill was booked under, so
+// redefining an instrument does not re-price lots opened before.
+type lot struct {
+	quantity   float64
+	price      float64
+	fee        float64
+	time       time.Time
+	fillID     string
+	instrument models.Instrument
+	currency   string
+}
+
+// book is the position in one symbol. Currency is the settlement currency
+// of the latest lot opened.
+type book struct {
+	currency string
+	lots     []lot
+	realized float64
+	fees     float64
+	mark     float64
+}
+
+// BookedFill is a fill and the instrument it was booked under.
+type BookedFill struct {
+	models.Fill
+	Instrument models.Instrument `json:"instrument"`
+}
+
+// Ledger consumes fills and keeps open lots and realized PnL per symbol.
+type Ledger struct {
+	mu          sync.Mutex
+	method      Method
+	instruments map[string]models.Instrument
+	books       map[string]*book
+	fills       []BookedFill
+	closed      []ClosedLot
+}
+
+// NewLedger creates an empty ledger using the given matching method.
+func NewLedger(method Method) (*Ledger, error) {
+	switch method {
+	case FIFO, LIFO, Average:
+	default:
+		return nil, fmt.Errorf("unknown accounting method %q", method)
+	}
+	return &Ledger{
+		method:      method,
+		instruments: make(map[string]models.Instrument),
+		books:       make(map[string]*book),
+	}, nil
+}
+
+// SetInstrument registers the contract specification of a symbol. Symbols
+// without one are treated as linear with a multiplier of 1. It applies to
+// later fills only; lots already booked keep the specification they were
+// booked under.
+func (l *Ledger) SetInstrument(instrument models.Instrument) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.instruments[instrument.Symbol] = instrument
+}
+
+// Instrument returns the registered instrument of a symbol.
+func (l *Ledger) Instrument(symbol string) (models.Instrument, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	instrument, ok := l.instruments[symbol]
+	return instrument, ok
+}
+
+// Instruments returns the registered instruments.
+func (l *Ledger) Instruments() []models.Instrument {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	instruments := make([]models.Instrument, 0, len(l.instruments))
+	for _, instrument := range l.instruments {
+		instruments = append(instruments, instrument)
+	}
+	sort.Slice(instruments, func(i, j int) bool {
+		return instruments[i].Symbol < instruments[j].Symbol
+	})
+	return instruments
+}
+
+// Apply books a fill under the symbol's registered instrument: it first
+// closes lots on the opposite side, then opens a new lot with whatever
+// quantity remains. Fees are expected in the settlement currency of the
+// instrument.
+func (l *Ledger) Apply(fill models.Fill) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	instrument, ok := l.instruments[fill.Symbol]
+	if !ok {
+		instrument = models.LinearInstrument(fill.Symbol)
+	}
+	l.apply(BookedFill{Fill: fill, Instrument: instrument})
+}
+
+func (l *Ledger) apply(booked BookedFill) {
+	fill, instrument := booked.Fill, booked.Instrument
+	currency := fill.Currency
+	if instrument.SettlementCurrency != "" {
+		currency = instrument.SettlementCurrency
+	}
+
+	b, ok := l.books[fill.Symbol]
+	if !ok {
+		b = &book{currency: currency}
+		l.books[fill.Symbol] = b
+	}
+	l.fills = append(l.fills, booked)
+	b.fees += fill.Fee
+	b.mark = fill.Price
+
+	remaining := fill.SignedQuantity()
+	for math.Abs(remaining) > epsilon && len(b.lots) > 0 && sign(b.lots[0].quantity) != sign(remaining) {
+		idx := 0
+		if l.method == LIFO {
+			idx = len(b.lots) - 1
+		}
+		open := &b.lots[idx]
+
+		direction := sign(open.quantity)
+		qty := math.Min(math.Abs(remaining), math.Abs(open.quantity))
+		entryFee := open.fee * qty / math.Abs(open.quantity)
+		exitFee := fill.Fee * qty / fill.Quantity
+		gross := open.instrument.PnL(open.price, fill.Price, qty*direction)
+		proceeds, cost := open.instrument.Value(fill.Price, qty), open.instrument.Value(open.price, qty)
+		if (direction < 0) != (open.instrument.Type == models.Inverse) {
+			proceeds, cost = cost, proceeds
+		}
+
+		l.closed = append(l.closed, ClosedLot{
+			Symbol:      fill.Symbol,
+			Currency:    open.currency,
+			Inverse:     open.instrument.Type == models.Inverse,
+			Quantity:    qty * direction,
+			OpenFillID:  open.fillID,
+			CloseFillID: fill.ID,
+			OpenTime:    open.time,
+			CloseTime:   fill.Time,
+			OpenPrice:   open.price,
+			ClosePrice:  fill.Price,
+			Fees:        entryFee + exitFee,
+			Proceeds:    proceeds,
+			CostBasis:   cost,
+			Realized:    gross - entryFee - exitFee,
+			Holding:     fill.Time.Sub(open.time),
+		})
+		b.realized += gross - entryFee - exitFee
+
+		open.quantity -= qty * direction
+		open.fee -= entryFee
+		remaining += qty * direction
+		if math.Abs(open.quantity) <= epsilon {
+			b.lots = append(b.lots[:idx], b.lots[idx+1:]...)
+		}
+	}
+
+	if math.Abs(remaining) <= epsilon {
+		return
+	}
+	fee := fill.Fee * math.Abs(remaining) / fill.Quantity
+	b.currency = currency
+	if l.method == Average && len(b.lots) == 1 && b.lots[0].instrument == instrument && b.lots[0].currency == currency {
+		pooled := &b.lots[0]
+		pooled.price = instrument.AveragePrice(pooled.price, pooled.quantity, fill.Price, remaining)
+		pooled.quantity += remaining
+		pooled.fee += fee
+		return
+	}
+	b.lots = append(b.lots, lot{
+		quantity:   remaining,
+		price:      fill.Price,
+		fee:        fee,
+		time:       fill.Time,
+		fillID:     fill.ID,
+		instrument: instrument,
+		currency:   currency,
+	})
+}
+
+// Mark sets the price used for the unrealized PnL of a symbol. Until marked,
+// the last fill price is used.
+func (l *Ledger) Mark(symbol string, price float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.books[symbol]; ok {
+		b.mark = price
+	}
+}
+
+// Report returns realized and unrealized PnL per symbol and in total.
+func (l *Ledger) Report() Report {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	report := Report{Method: l.method, Positions: make([]PositionPnL, 0, len(l.books))}
+	for symbol, b := range l.books {
+		position := PositionPnL{
+			Symbol:    symbol,
+			Currency:  b.currency,
+			MarkPrice: b.mark,
+			Realized:  b.realized,
+			Fees:      b.fees,
+		}
+		for _, open := range b.lots {
+			if position.Quantity == 0 {
+				position.AveragePrice = open.price
+			} else {
+				position.AveragePrice = open.instrument.AveragePrice(position.AveragePrice, position.Quantity, open.price, open.quantity)
+			}
+			position.Quantity += open.quantity
+			position.Unrealized += open.instrument.PnL(open.price, b.mark, open.quantity)
+		}
+
+		report.Positions = append(report.Positions, position)
+		report.Realized += position.Realized
+		report.Unrealized += position.Unrealized
+		report.Fees += position.Fees
+	}
+	sort.Slice(report.Positions, func(i, j int) bool {
+		return report.Positions[i].Symbol < report.Positions[j].Symbol
+	})
+	report.Total = report.Realized + report.Unrealized
+	return report
+}
+
+// State is what a ledger is rebuilt from: the instruments, every fill in
+// order with the instrument it was booked under, and the marks. Fills saved
+// without an instrument are booked under the symbol's registered one.
+type State struct {
+	Instruments []models.Instrument `json:"instruments"`
+	Fills       []BookedFill        `json:"fills"`
+	Marks       map[string]float64  `json:"marks"`
+}
+
+// State returns the current ledger state.
+func (l *Ledger) State() State {
+	instruments := l.Instruments()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := State{
+		Instruments: instruments,
+		Fills:       append([]BookedFill{}, l.fills...),
+		Marks:       make(map[string]float64, len(l.books)),
+	}
+	for symbol, b := range l.books {
+		state.Marks[symbol] = b.mark
+	}
+	return state
+}
+
+// Restore replaces the ledger with one rebuilt by replaying state. The
+// matching method is kept.
+func (l *Ledger) Restore(state State) {
+	rebuilt := &Ledger{
+		method:      l.method,
+		instruments: make(map[string]models.Instrument),
+		books:       make(map[string]*book),
+	}
+	for _, instrument := range state.Instruments {
+		rebuilt.SetInstrument(instrument)
+	}
+	for _, booked := range state.Fills {
+		if booked.Instrument.Symbol == "" {
+			rebuilt.Apply(booked.Fill)
+			continue
+		}
+		rebuilt.apply(booked)
+	}
+	for symbol, mark := range state.Marks {
+		rebuilt.Mark(symbol, mark)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.instruments, l.books = rebuilt.instruments, rebuilt.books
+	l.fills, l.closed = rebuilt.fills, rebuilt.closed
+}
+
+// Fills returns every fill applied to the ledger, in order.
+func (l *Ledger) Fills() []models.Fill {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fills := make([]models.Fill, len(l.fills))
+	for i, booked := range l.fills {
+		fills[i] = booked.Fill
+	}
+	return fills
+}
+
+// ClosedLots returns every closed lot, in the order they were closed.
+func (l *Ledger) ClosedLots() []ClosedLot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]ClosedLot(nil), l.closed...)
+}
+
+func sign(x float64) float64 {
+	if x < 0 {
+		return -1
+	}
+	return 1
+}
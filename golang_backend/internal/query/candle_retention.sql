@@ -0,0 +1,46 @@
+-- name: ListRetentionPolicies :many
+SELECT * FROM "candle_retention_policies"
+ORDER BY "timeframe";
+
+-- name: UpsertRetentionPolicy :one
+INSERT INTO "candle_retention_policies" (
+    "timeframe", "max_age", "downsample_timeframe", "downsample_interval", "updated_datetime"
+) VALUES (
+    @timeframe, @max_age, @downsample_timeframe, @downsample_interval, now()
+)
+ON CONFLICT ("timeframe") DO UPDATE SET
+    "max_age" = EXCLUDED."max_age",
+    "downsample_timeframe" = EXCLUDED."downsample_timeframe",
+    "downsample_interval" = EXCLUDED."downsample_interval",
+    "updated_datetime" = EXCLUDED."updated_datetime"
+RETURNING *;
+
+-- name: DeleteRetentionPolicy :exec
+DELETE FROM "candle_retention_policies"
+WHERE "timeframe" = @timeframe;
+
+-- name: CompactCandles :many
+SELECT "timeframe"::varchar AS "timeframe", "deleted"::bigint AS "deleted"
+FROM compact_candles(@as_of::timestamp);
+
+-- name: GetTableStorage :many
+-- On-disk size of each table, including its indexes and TOAST data.
+SELECT
+    c.relname::varchar AS "table_name",
+    pg_total_relation_size(c.oid)::bigint AS "total_bytes",
+    c.reltuples::bigint AS "estimated_rows"
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = current_schema() AND c.relkind = 'r'
+ORDER BY "total_bytes" DESC;
+
+-- name: GetCandleStorage :many
+-- Candle counts and time range per timeframe.
+SELECT
+    "timeframe",
+    count(*)::bigint AS "candles",
+    min("timestamp")::timestamp AS "oldest",
+    max("timestamp")::timestamp AS "newest"
+FROM "historical_candles"
+GROUP BY "timeframe"
+ORDER BY "timeframe";
@@ -0,0 +1,59 @@
+-- name: UpsertHistoricalCandle :exec
+-- Corrections overwrite the candle and the trigger keeps the value they
+-- replaced. Rewriting the same values is a no-op, so it does not move the
+-- time the candle has been known since. A NULL recorded_at records the
+-- candle as known now.
+INSERT INTO "historical_candles" (
+    "symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume", "created_datetime"
+) VALUES (
+    @symbol, @timeframe, @timestamp, @open, @close, @high, @low, @volume,
+    COALESCE(sqlc.narg(recorded_at)::timestamp, now())
+)
+ON CONFLICT ("symbol", "timeframe", "timestamp") DO UPDATE SET
+    "open" = EXCLUDED."open",
+    "close" = EXCLUDED."close",
+    "high" = EXCLUDED."high",
+    "low" = EXCLUDED."low",
+    "volume" = EXCLUDED."volume",
+    "updated_datetime" = EXCLUDED."created_datetime"
+WHERE ("historical_candles"."open", "historical_candles"."high", "historical_candles"."low",
+       "historical_candles"."close", "historical_candles"."volume")
+    IS DISTINCT FROM
+      (EXCLUDED."open", EXCLUDED."high", EXCLUDED."low", EXCLUDED."close", EXCLUDED."volume");
+
+-- name: ListLatestCandles :many
+-- The latest known value of every candle in [from, to].
+SELECT "symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume"
+FROM "historical_candles"
+WHERE "symbol" = @symbol AND "timeframe" = @timeframe
+  AND "timestamp" BETWEEN @from_time AND @to_time
+ORDER BY "timestamp";
+
+-- name: ListCandlesAsOf :many
+-- The candles in [from, to] as they were known at as_of: candles recorded
+-- later are left out and corrected ones take the value they had then.
+-- Each candle is read once, from the version known most recently.
+SELECT DISTINCT ON (known."timestamp")
+    known."symbol", known."timeframe", known."timestamp",
+    known."open", known."close", known."high", known."low", known."volume"
+FROM (
+    SELECT "symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume",
+           COALESCE("updated_datetime", "created_datetime") AS "known_since"
+    FROM "historical_candles"
+    WHERE "symbol" = @symbol AND "timeframe" = @timeframe
+      AND "timestamp" BETWEEN @from_time AND @to_time
+      AND COALESCE("updated_datetime", "created_datetime") <= @as_of
+    UNION ALL
+    SELECT "symbol", "timeframe", "timestamp", "open", "close", "high", "low", "volume",
+           "valid_from" AS "known_since"
+    FROM "historical_candle_revisions"
+    WHERE "symbol" = @symbol AND "timeframe" = @timeframe
+      AND "timestamp" BETWEEN @from_time AND @to_time
+      AND "valid_from" <= @as_of AND "valid_to" > @as_of
+) known
+ORDER BY known."timestamp", known."known_since" DESC;
+
+-- name: ListCandleRevisions :many
+SELECT * FROM "historical_candle_revisions"
+WHERE "symbol" = @symbol AND "timeframe" = @timeframe AND "timestamp" = @timestamp
+ORDER BY "valid_from";
@@ -0,0 +1,95 @@
+package reload
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Watcher polls a directory of JSON configuration files and hands the
+// ones added or modified since the last poll to apply, so that editing one
+// file does not re-apply the others over changes made since through the
+// API. Removing a file leaves its configuration in place. apply must
+// validate everything before changing any state, so that a bad edit
+// leaves the running configuration in place.
+type Watcher struct {
+	dir   string
+	apply func(files map[string][]byte) error
+
+	// seen holds the fingerprint of each file as last applied or rejected,
+	// so a bad edit is reported once rather than on every poll.
+	seen map[string]string
+}
+
+// NewWatcher creates a watcher of the *.json files in dir.
+func NewWatcher(dir string, apply func(files map[string][]byte) error) *Watcher {
+	return &Watcher{dir: dir, apply: apply}
+}
+
+// Load applies the files added or modified since the last call, all of
+// them on the first. Files are keyed by base name.
+func (w *Watcher) Load() error {
+	paths, err := filepath.Glob(filepath.Join(w.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]string, len(paths))
+	var changed []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(path)
+		seen[name] = fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+		if w.seen[name] != seen[name] {
+			changed = append(changed, path)
+		}
+	}
+	w.seen = seen
+	if len(changed) == 0 {
+		return nil
+	}
+
+	files := make(map[string][]byte, len(changed))
+	for _, path := range changed {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.Base(path)] = data
+	}
+	if err := w.apply(files); err != nil {
+		return fmt.Errorf("config in %s rejected, keeping the running one: %w", w.dir, err)
+	}
+	names := make([]string, len(changed))
+	for i, path := range changed {
+		names[i] = filepath.Base(path)
+	}
+	log.Printf("reload: applied %s from %s", strings.Join(names, ", "), w.dir)
+	return nil
+}
+
+// Run polls every interval until ctx is done, logging rejected changes.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Load(); err != nil {
+				log.Print("reload: ", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/patterns"
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+)
+
+// Annotation kinds.
+const (
+	Line  = "line"
+	Box   = "box"
+	Label = "label"
+)
+
+// Anchor is a point on the chart. Unix is the time in seconds, which is
+// what TradingView Lightweight Charts expects; Plotly can use Time.
+type Anchor struct {
+	Time  time.Time `json:"time"`
+	Unix  int64     `json:"unix"`
+	Price float64   `json:"price"`
+}
+
+// Annotation is a frontend-agnostic drawing. Lines and boxes span From to
+// To (opposite corners for boxes); labels sit at From.
+type Annotation struct {
+	Kind    string  `json:"kind"`
+	From    Anchor  `json:"from"`
+	To      *Anchor `json:"to,omitempty"`
+	Color   string  `json:"color"`
+	Opacity float64 `json:"opacity"`
+	Text    string  `json:"text,omitempty"`
+	Source  string  `json:"source"`
+}
+
+// Annotations maps the zones and labels of a chart to drawings, using the
+// same layout as SVG: zones are boxes extending to the last candle.
+func Annotations(chart Chart) ([]Annotation, error) {
+	if len(chart.Candles) == 0 {
+		return nil, fmt.Errorf("no candles to annotate")
+	}
+	last := chart.Candles[len(chart.Candles)-1].Time
+
+	annotations := make([]Annotation, 0, len(chart.Zones)+len(chart.Labels))
+	for _, zone := range chart.Zones {
+		if zone.StartIndex < 0 || zone.StartIndex >= len(chart.Candles) {
+			continue
+		}
+		annotations = append(annotations, zoneBox(chart.Candles[zone.StartIndex].Time, last, zone, "zone"))
+	}
+
+	for _, label := range chart.Labels {
+		if label.Index < 0 || label.Index >= len(chart.Candles) {
+			continue
+		}
+		annotations = append(annotations, Annotation{
+			Kind:    Label,
+			From:    anchor(chart.Candles[label.Index].Time, label.Price),
+			Color:   textColor,
+			Opacity: 1,
+			Text:    label.Text,
+			Source:  "label",
+		})
+	}
+	return annotations, nil
+}
+
+// SMCAnnotations draws an SMC analysis of candles: supply and demand
+// zones and FVGs as boxes to the last candle, structure breaks as lines
+// from the broken swing to the breaking candle, time levels as lines until
+// they are swept, and SFPs as labels at their extreme. Sources are the
+// analysis component names.
+func SMCAnnotations(candles []models.OHLC, analysis smc.Analysis) []Annotation {
+	annotations := []Annotation{}
+	if len(candles) == 0 {
+		return annotations
+	}
+	last := candles[len(candles)-1].Time
+
+	for _, zone := range analysis.Zones {
+		annotations = append(annotations, zoneBox(zone.StartTime, last, zone.Zone, "zones"))
+	}
+	for _, gap := range analysis.FVGs {
+		annotations = append(annotations, zoneBox(gap.StartTime, last, gap, "fvgs"))
+	}
+	for _, b := range analysis.Structure {
+		annotations = append(annotations, HorizontalLine(candles[b.SwingIndex].Time, b.Time, b.Level, directionColor(b.Direction), b.Type, "structure"))
+	}
+	for _, level := range analysis.Levels {
+		end := last
+		if level.SweptTime != nil {
+			end = *level.SweptTime
+		}
+		annotations = append(annotations, HorizontalLine(level.Time, end, level.Price, textColor, level.Name, "levels"))
+	}
+	for _, sfp := range analysis.SFPs {
+		annotations = append(annotations, label(sfp.Time, sfp.Extreme, directionColor(sfp.Direction), "sfp", "sfps"))
+	}
+	return annotations
+}
+
+// PatternAnnotations labels the candles matching each candlestick pattern,
+// as returned by patterns.DetectIndices, above their high, in time order.
+func PatternAnnotations(candles []models.OHLC, matches map[string][]int) []Annotation {
+	names := make([]string, 0, len(matches))
+	for name := range matches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	annotations := []Annotation{}
+	for _, name := range names {
+		for _, i := range matches[name] {
+			annotations = append(annotations, label(candles[i].Time, candles[i].High, textColor, name, "patterns"))
+		}
+	}
+	sort.SliceStable(annotations, func(i, j int) bool { return annotations[i].From.Time.Before(annotations[j].From.Time) })
+	return annotations
+}
+
+// ChartPatternAnnotations draws the upper and lower bounds of flags,
+// pennants and channels over their consolidation.
+func ChartPatternAnnotations(candles []models.OHLC, found []patterns.ChartPattern) []Annotation {
+	annotations := []Annotation{}
+	for _, p := range found {
+		from := p.StartIndex
+		if p.PoleEnd > 0 {
+			from = p.PoleEnd
+		}
+		color := directionColor(p.Direction)
+		annotations = append(annotations,
+			line(anchor(candles[from].Time, p.Upper.Start), anchor(p.EndTime, p.Upper.End), color, p.Type, "chart_patterns"),
+			line(anchor(candles[from].Time, p.Lower.Start), anchor(p.EndTime, p.Lower.End), color, p.Type, "chart_patterns"),
+		)
+	}
+	return annotations
+}
+
+// TrendlineAnnotations draws trendlines from their first anchor to the
+// candle that broke them, or the last candle.
+func TrendlineAnnotations(candles []models.OHLC, lines []patterns.Trendline) []Annotation {
+	annotations := []Annotation{}
+	for _, l := range lines {
+		end := len(candles) - 1
+		if l.Broken {
+			end = l.BreakIndex
+		}
+		color := bullColor
+		if l.Type == patterns.Resistance {
+			color = bearColor
+		}
+		annotations = append(annotations, line(
+			anchor(l.StartTime, l.Intercept+l.Slope*float64(l.StartIndex)),
+			anchor(candles[end].Time, l.Intercept+l.Slope*float64(end)),
+			color, l.Type, "trendlines",
+		))
+	}
+	return annotations
+}
+
+// HorizontalLine is a line at price from a start time to end, e.g. a
+// pivot level or a session high.
+func HorizontalLine(from, to time.Time, price float64, color, text, source string) Annotation {
+	return line(anchor(from, price), anchor(to, price), color, text, source)
+}
+
+func line(from, to Anchor, color, text, source string) Annotation {
+	return Annotation{
+		Kind:    Line,
+		From:    from,
+		To:      &to,
+		Color:   color,
+		Opacity: 1,
+		Text:    text,
+		Source:  source,
+	}
+}
+
+func label(t time.Time, price float64, color, text, source string) Annotation {
+	return Annotation{
+		Kind:    Label,
+		From:    anchor(t, price),
+		Color:   color,
+		Opacity: 1,
+		Text:    text,
+		Source:  source,
+	}
+}
+
+// zoneBox draws a zone from its start to end.
+func zoneBox(start, end time.Time, zone models.Zone, source string) Annotation {
+	to := anchor(end, zone.Bottom)
+	return Annotation{
+		Kind:    Box,
+		From:    anchor(start, zone.Top),
+		To:      &to,
+		Color:   directionColor(zone.Direction),
+		Opacity: 0.2,
+		Text:    zone.Type,
+		Source:  source,
+	}
+}
+
+func directionColor(direction string) string {
+	if direction == models.Bearish {
+		return bearColor
+	}
+	return bullColor
+}
+
+func anchor(t time.Time, price float64) Anchor {
+	return Anchor{Time: t, Unix: t.Unix(), Price: price}
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+
+	"github.com/abs/go_billing/models"
+)
+
+const (
+	defaultWidth  = 960
+	defaultHeight = 540
+	padding       = 40
+
+	bullColor  = "#26a69a"
+	bearColor  = "#ef5350"
+	textColor  = "#d1d4dc"
+	background = "#131722"
+)
+
+// Chart holds everything drawn on one chart image.
+type Chart struct {
+	Candles []models.OHLC
+	Zones   []models.Zone
+	Labels  []models.ChartLabel
+	Width   int
+	Height  int
+}
+
+// SVG renders the chart as an SVG document: candles, zones as translucent
+// boxes extending to the last candle, and labels above/below their price.
+func SVG(chart Chart) ([]byte, error) {
+	if len(chart.Candles) == 0 {
+		return nil, fmt.Errorf("no candles to render")
+	}
+
+	width, height := chart.Width, chart.Height
+	if width <= 0 {
+		width = defaultWidth
+	}
+	if height <= 0 {
+		height = defaultHeight
+	}
+
+	low, high := priceRange(chart)
+	plotW := float64(width - 2*padding)
+	plotH := float64(height - 2*padding)
+	step := plotW / float64(len(chart.Candles))
+
+	x := func(i int) float64 { return padding + step*(float64(i)+0.5) }
+	y := func(p float64) float64 { return padding + (high-p)/(high-low)*plotH }
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, background)
+
+	for _, zone := range chart.Zones {
+		if zone.StartIndex < 0 || zone.StartIndex >= len(chart.Candles) {
+			continue
+		}
+		color := bullColor
+		if zone.Direction == models.Bearish {
+			color = bearColor
+		}
+		left := x(zone.StartIndex) - step/2
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s" fill-opacity="0.2" stroke="%s" stroke-opacity="0.6"/>`,
+			left, y(zone.Top), float64(width-padding)-left, math.Max(y(zone.Bottom)-y(zone.Top), 1), color, color)
+		fmt.Fprintf(&buf, `<text x="%.2f" y="%.2f" fill="%s" font-size="10" font-family="sans-serif">%s</text>`,
+			left+2, y(zone.Top)+10, textColor, html.EscapeString(zone.Type))
+	}
+
+	bodyW := math.Max(step*0.7, 1)
+	for i, candle := range chart.Candles {
+		color := bullColor
+		if candle.Close < candle.Open {
+			color = bearColor
+		}
+		top, bottom := math.Max(candle.Open, candle.Close), math.Min(candle.Open, candle.Close)
+		fmt.Fprintf(&buf, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s"/>`,
+			x(i), y(candle.High), x(i), y(candle.Low), color)
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`,
+			x(i)-bodyW/2, y(top), bodyW, math.Max(y(bottom)-y(top), 1), color)
+	}
+
+	for _, label := range chart.Labels {
+		if label.Index < 0 || label.Index >= len(chart.Candles) {
+			continue
+		}
+		// Labels at or above the candle high go on top, the rest below.
+		offset := 14.0
+		if label.Price >= chart.Candles[label.Index].High {
+			offset = -6
+		}
+		fmt.Fprintf(&buf, `<text x="%.2f" y="%.2f" fill="%s" font-size="11" font-family="sans-serif" text-anchor="middle">%s</text>`,
+			x(label.Index), y(label.Price)+offset, textColor, html.EscapeString(label.Text))
+	}
+
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// priceRange returns the visible price range covering candles and zones.
+func priceRange(chart Chart) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, candle := range chart.Candles {
+		low = math.Min(low, candle.Low)
+		high = math.Max(high, candle.High)
+	}
+	for _, zone := range chart.Zones {
+		low = math.Min(low, zone.Bottom)
+		high = math.Max(high, zone.Top)
+	}
+	if high == low {
+		high, low = high+1, low-1
+	}
+	margin := (high - low) * 0.05
+	return low - margin, high + margin
+}
@@ -0,0 +1,292 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/internal/calendar"
+	"github.com/abs/go_billing/models"
+)
+
+// Reason codes returned when an intraday constraint fails.
+const (
+	CodeOutsideHours = "OUTSIDE_TRADING_HOURS"
+	CodeFlatBy       = "FLAT_BY_TIME"
+	CodeMaxTrades    = "MAX_TRADES_PER_DAY"
+)
+
+// schedule is a strategy's constraints with the times parsed into offsets
+// from local midnight; a negative offset disables the rule.
+type schedule struct {
+	constraints models.TradingConstraints
+	location    *time.Location
+	entryStart  time.Duration
+	entryEnd    time.Duration
+	flatBy      time.Duration
+}
+
+// TradeCount counts the entries of a strategy on one local day.
+type TradeCount struct {
+	Day   string `json:"day"`
+	Count int    `json:"count"`
+}
+
+// SetConstraints replaces the intraday constraints of a strategy.
+func (s *Supervisor) SetConstraints(strategy string, constraints models.TradingConstraints) error {
+	sched, err := newSchedule(constraints)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[strategy] = sched
+	return nil
+}
+
+// CheckConstraints reports whether SetConstraints would accept
+// constraints.
+func CheckConstraints(constraints models.TradingConstraints) error {
+	_, err := newSchedule(constraints)
+	return err
+}
+
+// ReplaceConstraints replaces the constraints of every strategy. Nothing
+// changes unless all of them are valid.
+func (s *Supervisor) ReplaceConstraints(constraints map[string]models.TradingConstraints) error {
+	schedules := make(map[string]schedule, len(constraints))
+	for strategy, c := range constraints {
+		sched, err := newSchedule(c)
+		if err != nil {
+			return fmt.Errorf("strategy %s: %w", strategy, err)
+		}
+		schedules[strategy] = sched
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules = schedules
+	return nil
+}
+
+func newSchedule(constraints models.TradingConstraints) (schedule, error) {
+	sched := schedule{constraints: constraints, location: time.UTC}
+	if constraints.Timezone != "" {
+		location, err := time.LoadLocation(constraints.Timezone)
+		if err != nil {
+			return schedule{}, fmt.Errorf("invalid timezone: %w", err)
+		}
+		sched.location = location
+	}
+	if (constraints.EntryStart == "") != (constraints.EntryEnd == "") {
+		return schedule{}, fmt.Errorf("entry_start and entry_end must be set together")
+	}
+	for _, field := range []struct {
+		value string
+		into  *time.Duration
+	}{
+		{constraints.EntryStart, &sched.entryStart},
+		{constraints.EntryEnd, &sched.entryEnd},
+		{constraints.FlatBy, &sched.flatBy},
+	} {
+		offset, err := parseClock(field.value)
+		if err != nil {
+			return schedule{}, err
+		}
+		*field.into = offset
+	}
+	return sched, nil
+}
+
+// Constraints returns the intraday constraints of a strategy.
+func (s *Supervisor) Constraints(strategy string) (models.TradingConstraints, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[strategy]
+	return sched.constraints, ok
+}
+
+// constraintChecks evaluates the intraday constraints of the order's
+// strategy at now. Exits, orders that reduce the position held, are exempt
+// from the entry window and the trade count.
+func (s *Supervisor) constraintChecks(order models.Order, held float64, now time.Time) []Check {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[order.Strategy]
+	if !ok {
+		return nil
+	}
+	return sched.checks(held, held+order.SignedQuantity(), now, s.trades[order.Strategy])
+}
+
+// checks evaluates the constraints for taking a position from held to
+// after at now, given the entries counted so far.
+func (sched schedule) checks(held, after float64, now time.Time, count TradeCount) []Check {
+	local := now.In(sched.location)
+	tod := calendar.TimeOfDay(local)
+	entry := math.Abs(after) > math.Abs(held)
+
+	var checks []Check
+	if entry && sched.entryStart >= 0 {
+		passed := inWindow(tod, sched.entryStart, sched.entryEnd)
+		checks = append(checks, Check{Name: "trading_hours", Passed: passed, Code: failCode(passed, CodeOutsideHours)})
+	}
+	if sched.flatBy >= 0 && tod >= sched.flatBy {
+		// Past the flat-by time only orders that reduce the position are
+		// allowed.
+		passed := math.Abs(after) < math.Abs(held) && after*held >= 0
+		checks = append(checks, Check{Name: "flat_by", Passed: passed, Code: failCode(passed, CodeFlatBy)})
+	}
+	if entry && sched.constraints.MaxTradesPerDay > 0 {
+		if count.Day != local.Format("2006-01-02") {
+			count = TradeCount{Day: local.Format("2006-01-02")}
+		}
+		passed := count.Count < sched.constraints.MaxTradesPerDay
+		checks = append(checks, Check{
+			Name:   "max_trades_per_day",
+			Passed: passed,
+			Code:   failCode(passed, CodeMaxTrades),
+			Value:  float64(count.Count),
+			Limit:  float64(sched.constraints.MaxTradesPerDay),
+		})
+	}
+	return checks
+}
+
+// recordEntry counts an accepted entry against the strategy's daily limit.
+func (s *Supervisor) recordEntry(strategy string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[strategy]
+	if !ok {
+		return
+	}
+	s.trades[strategy] = sched.count(s.trades[strategy], now)
+}
+
+// count adds an entry at now to count, restarting it on a new local day.
+func (sched schedule) count(count TradeCount, now time.Time) TradeCount {
+	day := now.In(sched.location).Format("2006-01-02")
+	if count.Day != day {
+		count = TradeCount{Day: day}
+	}
+	count.Count++
+	return count
+}
+
+// RejectedFill is a fill the constraints would have blocked and the reason
+// code of the first failed check.
+type RejectedFill struct {
+	Fill models.Fill `json:"fill"`
+	Code string      `json:"code"`
+}
+
+// FlatByBreach is a position still open when the flat-by time passed
+// before the next fill.
+type FlatByBreach struct {
+	Time     time.Time `json:"time"`
+	Quantity float64   `json:"quantity"`
+}
+
+// ConstraintReplay is the outcome of running a backtest's fills through a
+// strategy's constraints.
+type ConstraintReplay struct {
+	Accepted []models.Fill  `json:"accepted"`
+	Rejected []RejectedFill `json:"rejected"`
+	Breaches []FlatByBreach `json:"flat_by_breaches"`
+	Trades   []TradeCount   `json:"trades"`
+}
+
+// ReplayConstraints applies constraints to a backtest's fills in time
+// order, starting flat, with the same checks as live pre-trade orders.
+// Rejected fills do not change the position. A position left open past the
+// flat-by time until the next fill is reported as a breach, since fills
+// alone cannot tell when the backtest would have closed it.
+func ReplayConstraints(constraints models.TradingConstraints, fills []models.Fill) (ConstraintReplay, error) {
+	sched, err := newSchedule(constraints)
+	if err != nil {
+		return ConstraintReplay{}, err
+	}
+	fills = append([]models.Fill(nil), fills...)
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time.Before(fills[j].Time) })
+
+	replay := ConstraintReplay{Accepted: []models.Fill{}, Rejected: []RejectedFill{}, Breaches: []FlatByBreach{}, Trades: []TradeCount{}}
+	held := make(map[string]float64)
+	var count TradeCount
+	for i, fill := range fills {
+		after := held[fill.Symbol] + fill.SignedQuantity()
+		code := ""
+		for _, check := range sched.checks(held[fill.Symbol], after, fill.Time, count) {
+			if !check.Passed {
+				code = check.Code
+				break
+			}
+		}
+		if code != "" {
+			replay.Rejected = append(replay.Rejected, RejectedFill{Fill: fill, Code: code})
+			continue
+		}
+		if math.Abs(after) > math.Abs(held[fill.Symbol]) {
+			count = sched.count(count, fill.Time)
+			if n := len(replay.Trades); n > 0 && replay.Trades[n-1].Day == count.Day {
+				replay.Trades[n-1] = count
+			} else {
+				replay.Trades = append(replay.Trades, count)
+			}
+		}
+		held[fill.Symbol] = after
+		replay.Accepted = append(replay.Accepted, fill)
+
+		if sched.flatBy < 0 || i == len(fills)-1 {
+			continue
+		}
+		var open float64
+		for _, quantity := range held {
+			open += math.Abs(quantity)
+		}
+		if open < 1e-9 {
+			continue
+		}
+		if deadline := sched.nextFlatBy(fill.Time); !fills[i+1].Time.Before(deadline) {
+			replay.Breaches = append(replay.Breaches, FlatByBreach{Time: deadline, Quantity: open})
+		}
+	}
+	return replay, nil
+}
+
+// nextFlatBy returns the first flat-by time after t.
+func (sched schedule) nextFlatBy(t time.Time) time.Time {
+	local := t.In(sched.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, sched.location)
+	deadline := midnight.Add(sched.flatBy)
+	if !deadline.After(local) {
+		deadline = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, sched.location).Add(sched.flatBy)
+	}
+	return deadline
+}
+
+// parseClock parses "15:04" into an offset from midnight, -1 when empty.
+func parseClock(value string) (time.Duration, error) {
+	if value == "" {
+		return -1, nil
+	}
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func inWindow(tod, start, end time.Duration) bool {
+	if start <= end {
+		return tod >= start && tod < end
+	}
+	return tod >= start || tod < end
+}
@@ -0,0 +1,153 @@
+package risk
+
+import (
+	"math"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Reason codes returned when a pre-trade check fails.
+const (
+	CodeKillSwitch         = "KILL_SWITCH_ACTIVE"
+	CodeMaxPosition        = "MAX_POSITION_EXCEEDED"
+	CodeMaxExposure        = "MAX_EXPOSURE_EXCEEDED"
+	CodeMaxCorrelated      = "MAX_CORRELATED_EXPOSURE_EXCEEDED"
+	CodeInsufficientMargin = "INSUFFICIENT_MARGIN"
+)
+
+// PreTradeLimits are notional limits applied after the order would fill.
+// A zero limit disables the corresponding check. An order that only
+// reduces a position lowers every exposure and passes them all, so a
+// position over its limit can always be cut.
+type PreTradeLimits struct {
+	MaxSymbolExposure float64
+	MaxTotalExposure  float64
+	MaxGroupExposure  float64
+}
+
+// Check is the outcome of a single pre-trade check.
+type Check struct {
+	Name   string  `json:"name"`
+	Passed bool    `json:"passed"`
+	Code   string  `json:"code,omitempty"`
+	Value  float64 `json:"value"`
+	Limit  float64 `json:"limit"`
+}
+
+// PreTradeResult lists every check evaluated for an order. Code is the
+// reason code of the first failed check.
+type PreTradeResult struct {
+	Accepted bool    `json:"accepted"`
+	Code     string  `json:"code,omitempty"`
+	Checks   []Check `json:"checks"`
+}
+
+// PreTrade evaluates an order at now against the limits, the kill-switch
+// state, the strategy's intraday constraints and the account's open
+// positions and available margin. The kill switch only blocks orders that
+// open or add to a position. With commit, an accepted entry counts
+// towards the strategy's trades for the day; without, the check has no
+// effect and can be repeated. Committing checks run one at a time, so two
+// concurrent entries cannot both take the day's last trade.
+func (s *Supervisor) PreTrade(limits PreTradeLimits, order models.Order, positions []models.Position, availableMargin float64, now time.Time, commit bool) PreTradeResult {
+	if commit {
+		s.commit.Lock()
+		defer s.commit.Unlock()
+	}
+
+	result := PreTradeResult{Accepted: true}
+	add := func(check Check) {
+		result.Checks = append(result.Checks, check)
+		if !check.Passed && result.Accepted {
+			result.Accepted = false
+			result.Code = check.Code
+		}
+	}
+
+	var held float64
+	for _, position := range positions {
+		if position.Symbol == order.Symbol {
+			held += position.Quantity
+		}
+	}
+	reduce := reduces(held, held+order.SignedQuantity())
+
+	// A halted strategy may still close what it holds.
+	if !reduce {
+		allowed := s.Allowed(order.Strategy)
+		add(Check{Name: "kill_switch", Passed: allowed, Code: failCode(allowed, CodeKillSwitch)})
+	}
+	for _, check := range s.constraintChecks(order, held, now) {
+		add(check)
+	}
+
+	// Exposure after the fill, valued at the order price for the traded
+	// symbol and at the position price for everything else.
+	exposure := make(map[string]float64)
+	groups := make(map[string]string)
+	for _, position := range positions {
+		exposure[position.Symbol] += position.Quantity * position.Price
+		groups[position.Symbol] = position.Group
+	}
+	exposure[order.Symbol] = (held + order.SignedQuantity()) * order.Price
+	if order.Group != "" {
+		groups[order.Symbol] = order.Group
+	}
+
+	if limits.MaxSymbolExposure > 0 {
+		value := math.Abs(exposure[order.Symbol])
+		passed := value <= limits.MaxSymbolExposure || reduce
+		add(Check{Name: "max_position", Passed: passed, Code: failCode(passed, CodeMaxPosition), Value: value, Limit: limits.MaxSymbolExposure})
+	}
+
+	if limits.MaxTotalExposure > 0 {
+		var value float64
+		for _, notional := range exposure {
+			value += math.Abs(notional)
+		}
+		passed := value <= limits.MaxTotalExposure || reduce
+		add(Check{Name: "max_exposure", Passed: passed, Code: failCode(passed, CodeMaxExposure), Value: value, Limit: limits.MaxTotalExposure})
+	}
+
+	if limits.MaxGroupExposure > 0 && groups[order.Symbol] != "" {
+		var value float64
+		for symbol, notional := range exposure {
+			if groups[symbol] == groups[order.Symbol] {
+				value += math.Abs(notional)
+			}
+		}
+		passed := value <= limits.MaxGroupExposure || reduce
+		add(Check{Name: "max_correlated_exposure", Passed: passed, Code: failCode(passed, CodeMaxCorrelated), Value: value, Limit: limits.MaxGroupExposure})
+	}
+
+	// Reducing a position releases margin rather than using it.
+	leverage := order.Leverage
+	if leverage <= 0 {
+		leverage = 1
+	}
+	var required float64
+	if !reduce {
+		required = order.Quantity * order.Price / leverage
+	}
+	passed := required <= availableMargin
+	add(Check{Name: "margin", Passed: passed, Code: failCode(passed, CodeInsufficientMargin), Value: required, Limit: availableMargin})
+
+	if commit && result.Accepted && math.Abs(held+order.SignedQuantity()) > math.Abs(held) {
+		s.recordEntry(order.Strategy, now)
+	}
+	return result
+}
+
+// reduces reports whether taking a position from held to after only
+// reduces it, without opening the other side.
+func reduces(held, after float64) bool {
+	return math.Abs(after) <= math.Abs(held) && after*held >= 0
+}
+
+func failCode(passed bool, code string) string {
+	if passed {
+		return ""
+	}
+	return code
+}
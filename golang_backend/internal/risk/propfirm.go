@@ -0,0 +1,137 @@
+package risk
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Prop-firm rule names.
+const (
+	RuleDailyLoss   = "daily_loss"
+	RuleMaxDrawdown = "max_drawdown"
+)
+
+// Prop-firm evaluation outcomes.
+const (
+	EvaluationPassed     = "passed"
+	EvaluationFailed     = "failed"
+	EvaluationInProgress = "in_progress"
+)
+
+// PropFirmRules are the challenge rules. Limits and the target are
+// fractions of InitialBalance. The daily loss is measured from the equity
+// at the start of each day and the drawdown from InitialBalance or, when
+// Trailing, from the highest equity so far.
+type PropFirmRules struct {
+	InitialBalance float64
+	DailyLossLimit float64
+	MaxDrawdown    float64
+	Trailing       bool
+	ProfitTarget   float64
+	MinTradingDays int
+	Location       *time.Location
+}
+
+// Violation is the first breach of a rule. Value is the loss or drawdown
+// that broke Limit, both in account currency.
+type Violation struct {
+	Rule   string    `json:"rule"`
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+	Value  float64   `json:"value"`
+	Limit  float64   `json:"limit"`
+}
+
+// Evaluation is the outcome of a challenge. A run passes once the profit
+// target is reached with at least MinTradingDays trading days and no
+// violation, fails on any violation and is otherwise still in progress.
+type Evaluation struct {
+	Status          string      `json:"status"`
+	Violations      []Violation `json:"violations"`
+	FinalEquity     float64     `json:"final_equity"`
+	Profit          float64     `json:"profit"`
+	ProfitPct       float64     `json:"profit_pct"`
+	MaxDailyLoss    float64     `json:"max_daily_loss"`
+	MaxDrawdown     float64     `json:"max_drawdown"`
+	TradingDays     int         `json:"trading_days"`
+	TargetReached   bool        `json:"target_reached"`
+	TargetReachedAt *time.Time  `json:"target_reached_at,omitempty"`
+}
+
+// EvaluatePropFirm replays an equity curve against the rules. A trading day
+// is a day on which equity changed. Each rule reports its first violation
+// only; evaluation continues so every broken rule is listed.
+func EvaluatePropFirm(equity []models.EquityPoint, rules PropFirmRules) Evaluation {
+	location := rules.Location
+	if location == nil {
+		location = time.UTC
+	}
+	eval := Evaluation{Status: EvaluationInProgress, Violations: []Violation{}}
+	violated := make(map[string]bool)
+	violate := func(v Violation) {
+		if !violated[v.Rule] {
+			violated[v.Rule] = true
+			eval.Violations = append(eval.Violations, v)
+		}
+	}
+
+	dailyLimit := rules.DailyLossLimit * rules.InitialBalance
+	ddLimit := rules.MaxDrawdown * rules.InitialBalance
+	target := rules.InitialBalance * (1 + rules.ProfitTarget)
+
+	peak, prev := rules.InitialBalance, rules.InitialBalance
+	var day string
+	var dayStart float64
+	days := make(map[string]bool)
+	for _, point := range equity {
+		d := point.Time.In(location).Format("2006-01-02")
+		if d != day {
+			day, dayStart = d, prev
+		}
+		if point.Equity != prev {
+			days[d] = true
+		}
+		prev = point.Equity
+
+		if loss := dayStart - point.Equity; loss > eval.MaxDailyLoss {
+			eval.MaxDailyLoss = loss
+		}
+		if dailyLimit > 0 && dayStart-point.Equity >= dailyLimit {
+			violate(Violation{Rule: RuleDailyLoss, Time: point.Time, Equity: point.Equity, Value: dayStart - point.Equity, Limit: dailyLimit})
+		}
+
+		reference := rules.InitialBalance
+		if rules.Trailing {
+			if point.Equity > peak {
+				peak = point.Equity
+			}
+			reference = peak
+		}
+		if dd := reference - point.Equity; dd > eval.MaxDrawdown {
+			eval.MaxDrawdown = dd
+		}
+		if ddLimit > 0 && reference-point.Equity >= ddLimit {
+			violate(Violation{Rule: RuleMaxDrawdown, Time: point.Time, Equity: point.Equity, Value: reference - point.Equity, Limit: ddLimit})
+		}
+
+		if !eval.TargetReached && rules.ProfitTarget > 0 && point.Equity >= target {
+			t := point.Time
+			eval.TargetReached, eval.TargetReachedAt = true, &t
+		}
+	}
+
+	last := equity[len(equity)-1]
+	eval.FinalEquity = last.Equity
+	eval.Profit = last.Equity - rules.InitialBalance
+	eval.ProfitPct = 100 * eval.Profit / rules.InitialBalance
+	eval.TradingDays = len(days)
+
+	switch {
+	case len(eval.Violations) > 0:
+		eval.Status = EvaluationFailed
+	case eval.TargetReached && eval.TradingDays >= rules.MinTradingDays:
+		eval.Status = EvaluationPassed
+	}
+	return eval
+}
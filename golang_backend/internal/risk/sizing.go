@@ -0,0 +1,215 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Sizing modes.
+const (
+	SizeByATR  = "atr"
+	SizeByBeta = "beta"
+)
+
+// SizingParams configures volatility-adjusted position sizing. Each
+// position is sized so that its typical move over one bar is Risk times
+// Equity, so every position adds the same volatility to the portfolio
+// whatever the instrument. The typical move is ATRMultiple ATRs over
+// ATRPeriod bars in atr mode, and in beta mode the instrument's beta to
+// the benchmark times the benchmark's standard deviation of returns, both
+// over BetaPeriod bars.
+type SizingParams struct {
+	Mode        string
+	Equity      float64
+	Risk        float64
+	ATRPeriod   int
+	ATRMultiple float64
+	BetaPeriod  int
+}
+
+// SizingInput is an instrument to size. ATR and Beta, when zero, and
+// Price, when zero, come from Candles.
+type SizingInput struct {
+	Instrument models.Instrument
+	Price      float64
+	ATR        float64
+	Beta       float64
+	Candles    []models.OHLC
+}
+
+// PositionSize is the size of a position. UnitRisk is the typical move of
+// one unit over a bar in the settlement currency, and Quantity the units
+// whose typical move is the risk budget. Error is set instead, in a batch,
+// when the instrument could not be sized.
+type PositionSize struct {
+	Symbol   string  `json:"symbol"`
+	Price    float64 `json:"price,omitempty"`
+	ATR      float64 `json:"atr,omitempty"`
+	Beta     float64 `json:"beta,omitempty"`
+	UnitRisk float64 `json:"unit_risk,omitempty"`
+	Quantity float64 `json:"quantity"`
+	Notional float64 `json:"notional"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// BatchSizing sizes a watchlist. GrossNotional sums the notional of the
+// sized positions, to compare with the equity for the leverage taken.
+type BatchSizing struct {
+	Positions     []PositionSize `json:"positions"`
+	GrossNotional float64        `json:"gross_notional"`
+}
+
+// SizePosition sizes one instrument. benchmark is only used in beta mode,
+// where its volatility is always needed and its returns give the beta
+// when the input has none.
+func SizePosition(params SizingParams, benchmark []models.OHLC, input SizingInput) (PositionSize, error) {
+	size := PositionSize{Symbol: input.Instrument.Symbol, Price: input.Price, ATR: input.ATR, Beta: input.Beta}
+	if size.Price == 0 && len(input.Candles) > 0 {
+		size.Price = input.Candles[len(input.Candles)-1].Close
+	}
+	if size.Price <= 0 {
+		return PositionSize{}, errors.New("no price: give a price or candles")
+	}
+
+	var move float64
+	switch params.Mode {
+	case SizeByATR:
+		if size.ATR == 0 {
+			if len(input.Candles) <= params.ATRPeriod {
+				return PositionSize{}, fmt.Errorf("need an atr or more than %d candles", params.ATRPeriod)
+			}
+			size.ATR = lastATR(input.Candles, params.ATRPeriod)
+		}
+		move = params.ATRMultiple * size.ATR
+	case SizeByBeta:
+		marketReturns := returns(benchmark)
+		if params.BetaPeriod < 2 {
+			return PositionSize{}, errors.New("beta period must be at least 2")
+		}
+		if len(marketReturns) < params.BetaPeriod {
+			return PositionSize{}, fmt.Errorf("need more than %d benchmark candles", params.BetaPeriod)
+		}
+		if size.Beta == 0 {
+			beta, err := Beta(input.Candles, benchmark, params.BetaPeriod)
+			if err != nil {
+				return PositionSize{}, err
+			}
+			size.Beta = beta
+		}
+		move = math.Abs(size.Beta) * stdDev(marketReturns[len(marketReturns)-params.BetaPeriod:]) * size.Price
+	default:
+		return PositionSize{}, fmt.Errorf("unknown sizing mode %q", params.Mode)
+	}
+
+	size.UnitRisk = math.Abs(input.Instrument.PnL(size.Price, size.Price+move, 1))
+	if size.UnitRisk == 0 {
+		return PositionSize{}, errors.New("the instrument does not move: cannot size by volatility")
+	}
+	size.Quantity = params.Equity * params.Risk / size.UnitRisk
+	size.Notional = size.Quantity * size.Price
+	return size, nil
+}
+
+// SizePositions sizes every input; those that cannot be sized carry their
+// error and a zero quantity.
+func SizePositions(params SizingParams, benchmark []models.OHLC, inputs []SizingInput) BatchSizing {
+	batch := BatchSizing{Positions: make([]PositionSize, len(inputs))}
+	for i, input := range inputs {
+		size, err := SizePosition(params, benchmark, input)
+		if err != nil {
+			batch.Positions[i] = PositionSize{Symbol: input.Instrument.Symbol, Error: err.Error()}
+			continue
+		}
+		batch.Positions[i] = size
+		batch.GrossNotional += size.Notional
+	}
+	return batch
+}
+
+// Beta returns the beta of candles to benchmark over the last period
+// returns of the bars they share: the covariance of their returns over the
+// variance of the benchmark's.
+func Beta(candles, benchmark []models.OHLC, period int) (float64, error) {
+	closes := make(map[int64]float64, len(candles))
+	for _, candle := range candles {
+		closes[candle.Time.UnixNano()] = candle.Close
+	}
+	// x and y are the benchmark's and the instrument's returns between
+	// consecutive shared bars.
+	var x, y []float64
+	var prevMarket, prevPrice float64
+	for _, candle := range benchmark {
+		price, ok := closes[candle.Time.UnixNano()]
+		if !ok {
+			continue
+		}
+		if prevMarket != 0 && prevPrice != 0 {
+			x = append(x, candle.Close/prevMarket-1)
+			y = append(y, price/prevPrice-1)
+		}
+		prevMarket, prevPrice = candle.Close, price
+	}
+	if len(x) < period || period < 2 {
+		return 0, fmt.Errorf("need more than %d candles shared with the benchmark for a beta", period)
+	}
+	x, y = x[len(x)-period:], y[len(y)-period:]
+
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i] / float64(period)
+		meanY += y[i] / float64(period)
+	}
+	var covariance, variance float64
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+		variance += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if variance == 0 {
+		return 0, errors.New("the benchmark does not move: beta is undefined")
+	}
+	return covariance / variance, nil
+}
+
+// returns are the bar-to-bar returns of closes, skipping bars after a
+// zero close.
+func returns(candles []models.OHLC) []float64 {
+	r := make([]float64, 0, max(len(candles)-1, 0))
+	for i := 1; i < len(candles); i++ {
+		if candles[i-1].Close != 0 {
+			r = append(r, candles[i].Close/candles[i-1].Close-1)
+		}
+	}
+	return r
+}
+
+func stdDev(values []float64) float64 {
+	var mean float64
+	for _, v := range values {
+		mean += v / float64(len(values))
+	}
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean) / float64(len(values))
+	}
+	return math.Sqrt(variance)
+}
+
+// lastATR returns the ATR over period at the last candle, computing the
+// series in pooled buffers.
+func lastATR(candles []models.OHLC, period int) float64 {
+	highBuf, lowBuf := utils.GetFloats(len(candles)), utils.GetFloats(len(candles))
+	closeBuf, atrBuf := utils.GetFloats(len(candles)), utils.GetFloats(len(candles))
+	defer utils.PutFloats(highBuf)
+	defer utils.PutFloats(lowBuf)
+	defer utils.PutFloats(closeBuf)
+	defer utils.PutFloats(atrBuf)
+
+	highs, lows := utils.HighsInto(*highBuf, candles), utils.LowsInto(*lowBuf, candles)
+	closes := utils.ClosesInto(*closeBuf, candles)
+	atr := utils.CalculateATRInto(*atrBuf, highs, lows, closes, period)
+	return atr[len(atr)-1]
+}
@@ -0,0 +1,266 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Limits are the drawdown thresholds, as fractions of peak equity
+// (0.1 = 10%). A zero limit disables the check.
+type Limits struct {
+	MaxStrategyDrawdown float64
+	MaxAccountDrawdown  float64
+}
+
+// EquityStatus is the drawdown state of a strategy or of the account.
+type EquityStatus struct {
+	Strategy string  `json:"strategy,omitempty"`
+	Equity   float64 `json:"equity"`
+	Peak     float64 `json:"peak"`
+	Drawdown float64 `json:"drawdown"`
+	Halted   bool    `json:"halted"`
+	Reason   string  `json:"reason,omitempty"`
+}
+
+// Status is a snapshot of the kill-switch state.
+type Status struct {
+	Account    EquityStatus   `json:"account"`
+	Strategies []EquityStatus `json:"strategies"`
+}
+
+type tracker struct {
+	equity float64
+	peak   float64
+	halted bool
+	reason string
+}
+
+func (t *tracker) update(equity float64) {
+	t.equity = equity
+	if equity > t.peak {
+		t.peak = equity
+	}
+}
+
+func (t *tracker) drawdown() float64 {
+	if t.peak <= 0 {
+		return 0
+	}
+	return (t.peak - t.equity) / t.peak
+}
+
+func (t *tracker) status(strategy string) EquityStatus {
+	return EquityStatus{
+		Strategy: strategy,
+		Equity:   t.equity,
+		Peak:     t.peak,
+		Drawdown: t.drawdown(),
+		Halted:   t.halted,
+		Reason:   t.reason,
+	}
+}
+
+// Supervisor tracks strategy and account equity and halts trading when a
+// drawdown limit is breached. Halts stay in place until re-armed manually.
+type Supervisor struct {
+	// commit serialises committing pre-trade checks, so that the trade
+	// count read by one is only seen after the other has recorded its entry.
+	commit     sync.Mutex
+	mu         sync.Mutex
+	limits     Limits
+	account    tracker
+	strategies map[string]*tracker
+	schedules  map[string]schedule
+	trades     map[string]TradeCount
+}
+
+// NewSupervisor creates a supervisor enforcing the given limits.
+func NewSupervisor(limits Limits) *Supervisor {
+	return &Supervisor{
+		limits:     limits,
+		strategies: make(map[string]*tracker),
+		schedules:  make(map[string]schedule),
+		trades:     make(map[string]TradeCount),
+	}
+}
+
+// UpdateEquity records the latest equity of a strategy. Account equity is
+// the sum of the latest equity of every strategy.
+func (s *Supervisor) UpdateEquity(strategy string, equity float64) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.strategies[strategy]
+	if !ok {
+		t = &tracker{}
+		s.strategies[strategy] = t
+	}
+	t.update(equity)
+	if !t.halted && s.limits.MaxStrategyDrawdown > 0 && t.drawdown() >= s.limits.MaxStrategyDrawdown {
+		t.halted = true
+		t.reason = fmt.Sprintf("drawdown %.2f%% breached limit %.2f%%", t.drawdown()*100, s.limits.MaxStrategyDrawdown*100)
+	}
+
+	var total float64
+	for _, st := range s.strategies {
+		total += st.equity
+	}
+	s.account.update(total)
+	if !s.account.halted && s.limits.MaxAccountDrawdown > 0 && s.account.drawdown() >= s.limits.MaxAccountDrawdown {
+		s.account.halted = true
+		s.account.reason = fmt.Sprintf("account drawdown %.2f%% breached limit %.2f%%", s.account.drawdown()*100, s.limits.MaxAccountDrawdown*100)
+	}
+
+	return s.status()
+}
+
+// Allowed reports whether a strategy may open new positions.
+func (s *Supervisor) Allowed(strategy string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.account.halted {
+		return false
+	}
+	t, ok := s.strategies[strategy]
+	return !ok || !t.halted
+}
+
+// Trip halts a strategy, or the whole account when strategy is empty.
+func (s *Supervisor) Trip(strategy, reason string) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reason == "" {
+		reason = "manual kill-switch"
+	}
+	if strategy == "" {
+		s.account.halted = true
+		s.account.reason = reason
+		return s.status()
+	}
+
+	t, ok := s.strategies[strategy]
+	if !ok {
+		t = &tracker{}
+		s.strategies[strategy] = t
+	}
+	t.halted = true
+	t.reason = reason
+	return s.status()
+}
+
+// Rearm clears the halt of a strategy, or of the account and every strategy
+// when strategy is empty. Peaks are reset to current equity so the same
+// drawdown does not trip the switch again immediately.
+func (s *Supervisor) Rearm(strategy string) (Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strategy == "" {
+		s.account.rearm()
+		for _, t := range s.strategies {
+			t.rearm()
+		}
+		return s.status(), nil
+	}
+
+	t, ok := s.strategies[strategy]
+	if !ok {
+		return Status{}, fmt.Errorf("unknown strategy %q", strategy)
+	}
+	t.rearm()
+	return s.status(), nil
+}
+
+func (t *tracker) rearm() {
+	t.halted = false
+	t.reason = ""
+	t.peak = t.equity
+}
+
+// State is the supervisor state that survives a restart: equity and halts,
+// intraday constraints and the entries counted against them.
+type State struct {
+	Status      Status                               `json:"status"`
+	Constraints map[string]models.TradingConstraints `json:"constraints"`
+	Trades      map[string]TradeCount                `json:"trades"`
+}
+
+// State returns the current supervisor state.
+func (s *Supervisor) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := State{
+		Status:      s.status(),
+		Constraints: make(map[string]models.TradingConstraints, len(s.schedules)),
+		Trades:      make(map[string]TradeCount, len(s.trades)),
+	}
+	for strategy, sched := range s.schedules {
+		state.Constraints[strategy] = sched.constraints
+	}
+	for strategy, count := range s.trades {
+		state.Trades[strategy] = count
+	}
+	return state
+}
+
+// Restore replaces the supervisor state. Nothing changes unless every
+// constraint is valid. Limits are kept from the configuration.
+func (s *Supervisor) Restore(state State) error {
+	schedules := make(map[string]schedule, len(state.Constraints))
+	for strategy, c := range state.Constraints {
+		sched, err := newSchedule(c)
+		if err != nil {
+			return fmt.Errorf("strategy %s: %w", strategy, err)
+		}
+		schedules[strategy] = sched
+	}
+	strategies := make(map[string]*tracker, len(state.Status.Strategies))
+	for _, st := range state.Status.Strategies {
+		strategies[st.Strategy] = restoreTracker(st)
+	}
+	trades := make(map[string]TradeCount, len(state.Trades))
+	for strategy, count := range state.Trades {
+		trades[strategy] = count
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.account = *restoreTracker(state.Status.Account)
+	s.strategies = strategies
+	s.schedules = schedules
+	s.trades = trades
+	return nil
+}
+
+func restoreTracker(status EquityStatus) *tracker {
+	return &tracker{equity: status.Equity, peak: status.Peak, halted: status.Halted, reason: status.Reason}
+}
+
+// Status returns the current kill-switch state.
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status()
+}
+
+func (s *Supervisor) status() Status {
+	status := Status{
+		Account:    s.account.status(""),
+		Strategies: make([]EquityStatus, 0, len(s.strategies)),
+	}
+	for name, t := range s.strategies {
+		status.Strategies = append(status.Strategies, t.status(name))
+	}
+	sort.Slice(status.Strategies, func(i, j int) bool {
+		return status.Strategies[i].Strategy < status.Strategies[j].Strategy
+	})
+	return status
+}
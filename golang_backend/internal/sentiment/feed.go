@@ -0,0 +1,116 @@
+package sentiment
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// MaxFeedBytes bounds the size of an RSS feed body.
+const MaxFeedBytes = 4 << 20
+
+// FeedHosts is the set of hosts RSS feeds may be fetched from. Feeds come
+// from API clients, so only operator-configured hosts are reachable.
+type FeedHosts map[string]bool
+
+// NewFeedHosts creates the set from host names, case-insensitively.
+func NewFeedHosts(hosts []string) FeedHosts {
+	allowed := make(FeedHosts, len(hosts))
+	for _, host := range hosts {
+		if host = strings.TrimSpace(host); host != "" {
+			allowed[strings.ToLower(host)] = true
+		}
+	}
+	return allowed
+}
+
+// Check returns an error unless feed is an http or https URL on an allowed
+// host.
+func (hosts FeedHosts) Check(feed string) error {
+	u, err := url.Parse(feed)
+	if err != nil {
+		return fmt.Errorf("invalid feed %q: %w", feed, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("feed %s must use http or https", feed)
+	}
+	if !hosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("feed host %s is not allowed", u.Hostname())
+	}
+	return nil
+}
+
+// CheckRedirect is an http.Client CheckRedirect that only follows
+// redirects to allowed hosts.
+func (hosts FeedHosts) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after %d redirects", len(via))
+	}
+	return hosts.Check(req.URL.String())
+}
+
+// Doer sends HTTP requests, e.g. an *http.Client or an *upstream.Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// FetchRSS reads the items of an RSS 2.0 feed as headlines. Feeds larger
+// than MaxFeedBytes are rejected.
+func FetchRSS(ctx context.Context, client Doer, feedURL string) ([]models.Headline, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed %s returned %s", feedURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxFeedBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read feed %s: %w", feedURL, err)
+	}
+	if len(body) > MaxFeedBytes {
+		return nil, fmt.Errorf("feed %s is larger than %d bytes", feedURL, MaxFeedBytes)
+	}
+
+	var feed struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title   string `xml:"title"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("cannot decode feed %s: %w", feedURL, err)
+	}
+
+	headlines := make([]models.Headline, 0, len(feed.Channel.Items))
+	for _, item := range feed.Channel.Items {
+		published, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			published, err = time.Parse(time.RFC1123, item.PubDate)
+		}
+		if err != nil {
+			continue
+		}
+		headlines = append(headlines, models.Headline{
+			Title:  item.Title,
+			Time:   published.UTC(),
+			Source: feed.Channel.Title,
+		})
+	}
+	return headlines, nil
+}
@@ -0,0 +1,95 @@
+package sentiment
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Scorer rates a piece of text between -1 (negative) and 1 (positive).
+type Scorer interface {
+	Score(text string) float64
+}
+
+// LexiconScorer scores text by counting finance-specific positive and
+// negative words, flipping words preceded by a negation.
+type LexiconScorer struct {
+	Positive  map[string]bool
+	Negative  map[string]bool
+	Negations map[string]bool
+}
+
+// NewLexiconScorer returns a scorer with the built-in headline lexicon.
+func NewLexiconScorer() *LexiconScorer {
+	return &LexiconScorer{
+		Positive:  wordSet("beat beats surge surges soar soars rally rallies gain gains upgrade upgraded record growth profit profits bullish strong outperform rise rises jump jumps boost boosts approval approved exceed exceeds optimism rebound rebounds buyback raises"),
+		Negative:  wordSet("miss misses plunge plunges fall falls drop drops downgrade downgraded loss losses bearish weak lawsuit probe fraud decline declines slump slumps cut cuts recession default bankruptcy warning warns fear fears selloff crash crashes tumble tumbles layoffs investigation halt"),
+		Negations: wordSet("not no never without fails"),
+	}
+}
+
+// Score implements Scorer. It returns (positive - negative) / matched words,
+// or 0 when no lexicon word matches.
+func (s *LexiconScorer) Score(text string) float64 {
+	words := tokenize(text)
+
+	var positive, negative float64
+	for i, word := range words {
+		polarity := 0.0
+		if s.Positive[word] {
+			polarity = 1
+		} else if s.Negative[word] {
+			polarity = -1
+		}
+		if polarity == 0 {
+			continue
+		}
+		// A negation up to two words earlier flips the polarity.
+		for j := i - 1; j >= 0 && j >= i-2; j-- {
+			if s.Negations[words[j]] {
+				polarity = -polarity
+				break
+			}
+		}
+		if polarity > 0 {
+			positive++
+		} else {
+			negative++
+		}
+	}
+
+	if positive+negative == 0 {
+		return 0
+	}
+	return (positive - negative) / (positive + negative)
+}
+
+// Mentions returns the symbols whose keywords appear in text. Keywords are
+// matched as whole words, case-insensitively.
+func Mentions(text string, keywords map[string][]string) []string {
+	words := wordSet(strings.Join(tokenize(text), " "))
+
+	var symbols []string
+	for symbol, list := range keywords {
+		for _, keyword := range list {
+			if words[strings.ToLower(keyword)] {
+				symbols = append(symbols, symbol)
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
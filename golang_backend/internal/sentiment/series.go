@@ -0,0 +1,97 @@
+package sentiment
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Scored is a headline with its score and the symbols it mentions.
+type Scored struct {
+	models.Headline
+	Symbols []string `json:"symbols"`
+	Score   float64  `json:"score"`
+}
+
+// Point is one interval of a symbol's sentiment series. Score is the mean
+// score of the interval's headlines and Rolling the headline-weighted mean
+// over the trailing window of intervals.
+type Point struct {
+	Time    time.Time `json:"time"`
+	Count   int       `json:"count"`
+	Score   float64   `json:"score"`
+	Rolling float64   `json:"rolling"`
+}
+
+// ScoreHeadlines scores every headline and tags the symbols it mentions.
+func ScoreHeadlines(scorer Scorer, headlines []models.Headline, keywords map[string][]string) []Scored {
+	scored := make([]Scored, len(headlines))
+	for i, headline := range headlines {
+		scored[i] = Scored{
+			Headline: headline,
+			Symbols:  Mentions(headline.Title, keywords),
+			Score:    scorer.Score(headline.Title),
+		}
+	}
+	return scored
+}
+
+// MaxSeriesPoints bounds the number of intervals in one series.
+const MaxSeriesPoints = 10000
+
+// Series buckets the scored headlines mentioning symbol by interval, from
+// the first to the last mention, and rolls them over window intervals. It
+// fails when that span has more than MaxSeriesPoints intervals.
+func Series(scored []Scored, symbol string, interval time.Duration, window int) ([]Point, error) {
+	var mentions []Scored
+	for _, s := range scored {
+		for _, sym := range s.Symbols {
+			if sym == symbol {
+				mentions = append(mentions, s)
+				break
+			}
+		}
+	}
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	sort.Slice(mentions, func(i, j int) bool {
+		return mentions[i].Time.Before(mentions[j].Time)
+	})
+
+	start := mentions[0].Time.Truncate(interval)
+	end := mentions[len(mentions)-1].Time.Truncate(interval)
+	if end.Sub(start)/interval >= MaxSeriesPoints {
+		return nil, fmt.Errorf("%s headlines span more than %d intervals of %s", symbol, MaxSeriesPoints, interval)
+	}
+	points := make([]Point, int(end.Sub(start)/interval)+1)
+	sums := make([]float64, len(points))
+	for i := range points {
+		points[i].Time = start.Add(time.Duration(i) * interval)
+	}
+	for _, s := range mentions {
+		i := int(s.Time.Truncate(interval).Sub(start) / interval)
+		points[i].Count++
+		sums[i] += s.Score
+	}
+
+	var windowSum float64
+	var windowCount int
+	for i := range points {
+		if points[i].Count > 0 {
+			points[i].Score = sums[i] / float64(points[i].Count)
+		}
+		windowSum += sums[i]
+		windowCount += points[i].Count
+		if i >= window {
+			windowSum -= sums[i-window]
+			windowCount -= points[i-window].Count
+		}
+		if windowCount > 0 {
+			points[i].Rolling = windowSum / float64(windowCount)
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,137 @@
+package signals
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Component types.
+const (
+	Score       = "score"
+	Probability = "probability"
+	Pattern     = "pattern"
+)
+
+// Combination methods.
+const (
+	Vote     = "vote"
+	Weighted = "weighted"
+)
+
+// Flat is the side of an ensemble signal too weak to act on.
+const Flat = "flat"
+
+// Contribution is one component's normalised view and its share of the
+// ensemble. Normalized is in [-1, 1], positive for bullish.
+type Contribution struct {
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Normalized   float64 `json:"normalized"`
+	Weight       float64 `json:"weight"`
+	Contribution float64 `json:"contribution"`
+}
+
+// Signal is the combined signal. Strength is the signed ensemble value in
+// [-1, 1] and Side is buy, sell or flat when |Strength| is below the
+// threshold.
+type Signal struct {
+	Method     string         `json:"method"`
+	Side       string         `json:"side"`
+	Strength   float64        `json:"strength"`
+	Components []Contribution `json:"components"`
+}
+
+// Normalize maps a component onto [-1, 1]. Scores are divided by their
+// scale, probabilities of an up move are centred on 0.5 and patterns are
+// their confidence signed by direction.
+func Normalize(c models.SignalComponent) (float64, error) {
+	var v float64
+	switch c.Type {
+	case Score:
+		scale := c.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		v = c.Value / scale
+	case Probability:
+		v = 2*c.Value - 1
+	case Pattern:
+		v = c.Value
+		if v == 0 {
+			v = 1
+		}
+		switch c.Direction {
+		case models.Bullish:
+		case models.Bearish:
+			v = -v
+		default:
+			return 0, fmt.Errorf("pattern %s has no direction", c.Name)
+		}
+	default:
+		return 0, fmt.Errorf("unknown signal type %q", c.Type)
+	}
+	return math.Max(-1, math.Min(1, v)), nil
+}
+
+// Combine merges components with weights (1 when missing). Vote sums the
+// weighted signs of the components, Weighted averages their normalised
+// values; both divide by the total weight.
+func Combine(components []models.SignalComponent, weights map[string]float64, method string, threshold float64) (Signal, error) {
+	if method != Vote && method != Weighted {
+		return Signal{}, fmt.Errorf("unknown ensemble method %q", method)
+	}
+
+	signal := Signal{Method: method, Side: Flat, Components: make([]Contribution, 0, len(components))}
+	var total float64
+	for _, c := range components {
+		v, err := Normalize(c)
+		if err != nil {
+			return Signal{}, err
+		}
+		w, ok := weights[c.Name]
+		if !ok {
+			w = 1
+		}
+		contribution := v * w
+		if method == Vote {
+			contribution = sign(v) * w
+		}
+		signal.Components = append(signal.Components, Contribution{
+			Name:         c.Name,
+			Type:         c.Type,
+			Normalized:   v,
+			Weight:       w,
+			Contribution: contribution,
+		})
+		signal.Strength += contribution
+		total += w
+	}
+	if total == 0 {
+		return Signal{}, fmt.Errorf("ensemble weights sum to zero")
+	}
+
+	signal.Strength /= total
+	for i := range signal.Components {
+		signal.Components[i].Contribution /= total
+	}
+	switch {
+	case signal.Strength >= threshold && signal.Strength > 0:
+		signal.Side = models.Buy
+	case signal.Strength <= -threshold && signal.Strength < 0:
+		signal.Side = models.Sell
+	}
+	return signal, nil
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,165 @@
+package signals
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Record is a generated signal with the normalised values of the
+// components it was built from as context features.
+type Record struct {
+	Time     time.Time          `json:"time"`
+	Symbol   string             `json:"symbol"`
+	Method   string             `json:"method"`
+	Side     string             `json:"side"`
+	Strength float64            `json:"strength"`
+	Features map[string]float64 `json:"features"`
+}
+
+// Journal keeps the latest generated signals and detected events in
+// memory, in the order they were added. Past its limit the oldest ones are dropped.
+type Journal struct {
+	mu      sync.RWMutex
+	limit   int
+	records []Record
+}
+
+// NewJournal creates an empty journal of at most limit records; zero or
+// less keeps all of them.
+func NewJournal(limit int) *Journal {
+	return &Journal{limit: limit}
+}
+
+// Add records a signal generated for symbol at t.
+func (j *Journal) Add(symbol string, t time.Time, signal Signal) {
+	record := Record{
+		Time:     t,
+		Symbol:   symbol,
+		Method:   signal.Method,
+		Side:     signal.Side,
+		Strength: signal.Strength,
+		Features: make(map[string]float64, len(signal.Components)),
+	}
+	for _, c := range signal.Components {
+		record.Features[c.Name] = c.Normalized
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.records = j.trim(append(j.records, record))
+}
+
+// AddEvent records an event detected outside the ensemble, such as a
+// social mention spike, unless the journal already holds one of the same
+// method for the symbol at the same time. Detectors rerun over
+// overlapping windows find the same events again.
+func (j *Journal) AddEvent(record Record) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := len(j.records) - 1; i >= 0; i-- {
+		r := j.records[i]
+		if r.Symbol == record.Symbol && r.Method == record.Method && r.Time.Equal(record.Time) {
+			return
+		}
+	}
+	j.records = j.trim(append(j.records, record))
+}
+
+// trim drops the records past the limit. Reslicing leaves the dropped ones
+// in the backing array until append outgrows it, so the journal holds at
+// most about twice its limit and adding stays amortised constant time.
+func (j *Journal) trim(records []Record) []Record {
+	if j.limit > 0 && len(records) > j.limit {
+		return records[len(records)-j.limit:]
+	}
+	return records
+}
+
+// Restore replaces the journal with records, which must be in generation
+// order, keeping the latest up to the limit.
+func (j *Journal) Restore(records []Record) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.records = append([]Record(nil), j.trim(records)...)
+}
+
+// Records returns the signals for symbol (all symbols when empty) in
+// [from, to); zero bounds are open.
+func (j *Journal) Records(symbol string, from, to time.Time) []Record {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	records := []Record{}
+	for _, r := range j.records {
+		if symbol != "" && r.Symbol != symbol {
+			continue
+		}
+		if !from.IsZero() && r.Time.Before(from) || !to.IsZero() && !r.Time.Before(to) {
+			continue
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// Latest returns up to n of the latest signals for symbol, newest first.
+func (j *Journal) Latest(symbol string, n int) []Record {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	records := []Record{}
+	for i := len(j.records) - 1; i >= 0 && len(records) < n; i-- {
+		if j.records[i].Symbol == symbol {
+			records = append(records, j.records[i])
+		}
+	}
+	return records
+}
+
+// WriteRecordsCSV writes one row per signal with a column per feature name
+// seen in any record; features a signal did not use are left empty.
+func WriteRecordsCSV(w io.Writer, records []Record) error {
+	seen := make(map[string]bool)
+	var features []string
+	for _, r := range records {
+		for name := range r.Features {
+			if !seen[name] {
+				seen[name] = true
+				features = append(features, name)
+			}
+		}
+	}
+	sort.Strings(features)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append([]string{"time", "symbol", "method", "side", "strength"}, features...)); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.Time.UTC().Format(time.RFC3339Nano),
+			r.Symbol,
+			r.Method,
+			r.Side,
+			strconv.FormatFloat(r.Strength, 'f', -1, 64),
+		}
+		for _, name := range features {
+			if v, ok := r.Features[name]; ok {
+				row = append(row, strconv.FormatFloat(v, 'f', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,279 @@
+package smc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Params configures a full SMC analysis.
+type Params struct {
+	SwingStrength int
+	SupplyDemand  SDParams
+	SFP           SFPParams
+	Sessions      []Session
+
+	// Zones are external zones, such as order blocks and FVGs, that OTE
+	// windows are checked against alongside supply and demand zones.
+	Zones []models.Zone
+
+	// Components selects the detectors to run by name; all of them run
+	// when it is empty.
+	Components []string
+
+	// MinZoneScore drops the supply and demand zones scoring below it from
+	// the result, and ZoneOrder "score" sorts them by descending score
+	// instead of by time. OTEs and the nearest zones still use them all.
+	MinZoneScore float64
+	ZoneOrder    string
+
+	// Explain adds the criteria behind the zones, structure breaks, FVGs
+	// and SFPs, and the supply/demand candidates that were rejected.
+	Explain bool
+
+	// Cache shares derived series with the rest of the request. A new one
+	// is used when nil.
+	Cache *utils.Cache
+}
+
+// Components are the names of the detectors of a full analysis.
+var Components = []string{"swings", "structure", "zones", "sfps", "fvgs", "levels", "power_of_three", "otes", "nearest"}
+
+// Analysis is the combined result of the SMC detectors over one series.
+// Completed names the components that finished; when Partial is set the
+// others ran out of time and are null. Components left out of the
+// analysis are omitted from its JSON. Nearest summarises the unmitigated
+// zones, FVGs and external zones around the last close. Explanations are
+// only set when asked for, once every component has completed.
+type Analysis struct {
+	Swings       []Swing        `json:"swings"`
+	Structure    []Break        `json:"structure"`
+	Zones        []SDZone       `json:"zones"`
+	SFPs         []SFP          `json:"sfps"`
+	FVGs         []models.Zone  `json:"fvgs"`
+	OTEs         []OTE          `json:"otes"`
+	Levels       []Level        `json:"levels"`
+	PowerOfThree []PowerOfThree `json:"power_of_three"`
+	Nearest      []NearestZone  `json:"nearest"`
+	Explanations []Explanation  `json:"explanations,omitempty"`
+	Completed    []string       `json:"completed"`
+	Partial      bool           `json:"partial"`
+
+	enabled map[string]bool
+}
+
+// MarshalJSON encodes the analysis without its disabled components.
+func (a Analysis) MarshalJSON() ([]byte, error) {
+	type fields struct {
+		Swings       *[]Swing        `json:"swings,omitempty"`
+		Structure    *[]Break        `json:"structure,omitempty"`
+		Zones        *[]SDZone       `json:"zones,omitempty"`
+		SFPs         *[]SFP          `json:"sfps,omitempty"`
+		FVGs         *[]models.Zone  `json:"fvgs,omitempty"`
+		OTEs         *[]OTE          `json:"otes,omitempty"`
+		Levels       *[]Level        `json:"levels,omitempty"`
+		PowerOfThree *[]PowerOfThree `json:"power_of_three,omitempty"`
+		Nearest      *[]NearestZone  `json:"nearest,omitempty"`
+		Explanations []Explanation   `json:"explanations,omitempty"`
+		Completed    []string        `json:"completed"`
+		Partial      bool            `json:"partial"`
+	}
+	out := fields{Explanations: a.Explanations, Completed: a.Completed, Partial: a.Partial}
+	include := a.included
+	if include("swings") {
+		out.Swings = &a.Swings
+	}
+	if include("structure") {
+		out.Structure = &a.Structure
+	}
+	if include("zones") {
+		out.Zones = &a.Zones
+	}
+	if include("sfps") {
+		out.SFPs = &a.SFPs
+	}
+	if include("fvgs") {
+		out.FVGs = &a.FVGs
+	}
+	if include("otes") {
+		out.OTEs = &a.OTEs
+	}
+	if include("levels") {
+		out.Levels = &a.Levels
+	}
+	if include("power_of_three") {
+		out.PowerOfThree = &a.PowerOfThree
+	}
+	if include("nearest") {
+		out.Nearest = &a.Nearest
+	}
+	return json.Marshal(out)
+}
+
+// included reports whether the named component is part of the analysis
+// rather than only run for another one.
+func (a Analysis) included(name string) bool {
+	return a.enabled == nil || a.enabled[name]
+}
+
+// rankZones filters and orders the supply and demand zones by score.
+func (a *Analysis) rankZones(params Params) {
+	if a.Zones == nil {
+		return
+	}
+	zones := make([]SDZone, 0, len(a.Zones))
+	for _, z := range a.Zones {
+		if z.Score >= params.MinZoneScore {
+			zones = append(zones, z)
+		}
+	}
+	if params.ZoneOrder == "score" {
+		sort.SliceStable(zones, func(i, j int) bool { return zones[i].Score > zones[j].Score })
+	}
+	a.Zones = zones
+}
+
+// component is the finished result of one detector, applied to the
+// analysis by the collecting goroutine only.
+type component struct {
+	name  string
+	apply func(*Analysis)
+}
+
+// Analyze runs the SMC detectors over candles concurrently: those named
+// in params.Components, or all of them. OTEs need the structure breaks and
+// zones, and the nearest zones need the zones and FVGs; these then run too
+// but are left out of the result. If ctx is done
+// before all of them finish, it returns what has completed so far; the
+// remaining detectors finish in the background and are discarded.
+func Analyze(ctx context.Context, candles []models.OHLC, params Params) Analysis {
+	cache := params.Cache
+	if cache == nil {
+		cache = utils.NewCache(candles)
+	}
+	swingsOf := func() []Swing { return CachedSwings(cache, params.SwingStrength) }
+
+	analysis := Analysis{Completed: []string{}}
+	run := make(map[string]bool, len(Components))
+	if len(params.Components) > 0 {
+		analysis.enabled = make(map[string]bool, len(params.Components))
+		for _, name := range params.Components {
+			analysis.enabled[name], run[name] = true, true
+		}
+		if run["otes"] {
+			run["structure"], run["zones"] = true, true
+		}
+		if run["nearest"] {
+			run["zones"], run["fvgs"] = true, true
+		}
+	} else {
+		for _, name := range Components {
+			run[name] = true
+		}
+	}
+
+	// Buffered for every component so that late detectors never block.
+	results := make(chan component, len(Components))
+	profile := utils.ProfileFrom(ctx)
+	start := func(name string, detect func() func(*Analysis)) {
+		if !run[name] {
+			return
+		}
+		go func() {
+			stop := profile.Time("smc." + name)
+			apply := detect()
+			stop()
+			results <- component{name: name, apply: apply}
+		}()
+	}
+
+	start("swings", func() func(*Analysis) {
+		swings := swingsOf()
+		if swings == nil {
+			swings = []Swing{}
+		}
+		return func(a *Analysis) { a.Swings = swings }
+	})
+	start("structure", func() func(*Analysis) {
+		structure := structureBreaks(candles, swingsOf(), params.SwingStrength)
+		return func(a *Analysis) { a.Structure = structure }
+	})
+	start("zones", func() func(*Analysis) {
+		zones := SupplyDemand(candles, params.SupplyDemand)
+		return func(a *Analysis) { a.Zones = zones }
+	})
+	start("sfps", func() func(*Analysis) {
+		sfps := swingFailures(candles, CachedSwings(cache, params.SFP.SwingStrength), params.SFP)
+		return func(a *Analysis) { a.SFPs = sfps }
+	})
+	start("fvgs", func() func(*Analysis) {
+		fvgs := FVGs(candles)
+		return func(a *Analysis) { a.FVGs = fvgs }
+	})
+	start("levels", func() func(*Analysis) {
+		levels := TimeLevels(candles, params.Sessions)
+		return func(a *Analysis) { a.Levels = levels }
+	})
+	start("power_of_three", func() func(*Analysis) {
+		events := PowerOfThrees(candles, params.Sessions)
+		return func(a *Analysis) { a.PowerOfThree = events }
+	})
+
+	pending := 0
+	for _, name := range Components {
+		if run[name] {
+			pending++
+		}
+	}
+	for ; pending > 0; pending-- {
+		select {
+		case c := <-results:
+			c.apply(&analysis)
+			if analysis.enabled == nil || analysis.enabled[c.name] {
+				analysis.Completed = append(analysis.Completed, c.name)
+			}
+			// OTEs need the structure breaks and zones, so they start once
+			// both are in.
+			if (c.name == "structure" || c.name == "zones") && analysis.Structure != nil && analysis.Zones != nil {
+				structure := analysis.Structure
+				zones := append([]models.Zone(nil), params.Zones...)
+				for _, z := range analysis.Zones {
+					zones = append(zones, z.Zone)
+				}
+				start("otes", func() func(*Analysis) {
+					otes := oteWindows(candles, swingsOf(), structure, zones)
+					return func(a *Analysis) { a.OTEs = otes }
+				})
+			}
+			if (c.name == "zones" || c.name == "fvgs") && analysis.Zones != nil && analysis.FVGs != nil {
+				zones := append(append([]models.Zone(nil), params.Zones...), analysis.FVGs...)
+				for _, z := range analysis.Zones {
+					zones = append(zones, z.Zone)
+				}
+				start("nearest", func() func(*Analysis) {
+					if len(candles) == 0 {
+						return func(a *Analysis) { a.Nearest = []NearestZone{} }
+					}
+					atr := cache.ATR(nearestATRPeriod)
+					last := len(candles) - 1
+					nearest := NearestZones(zones, candles[last].Close, atr[last])
+					return func(a *Analysis) { a.Nearest = nearest }
+				})
+			}
+		case <-ctx.Done():
+			analysis.Partial = true
+			analysis.rankZones(params)
+			sort.Strings(analysis.Completed)
+			return analysis
+		}
+	}
+	analysis.rankZones(params)
+	sort.Strings(analysis.Completed)
+	if params.Explain {
+		analysis.Explanations = explain(candles, analysis, params)
+	}
+	return analysis
+}
@@ -0,0 +1,189 @@
+package smc
+
+import (
+	"math"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Explanation gives the criteria a detector checked at one place in the
+// series: the value measured, the threshold it was held to and whether it
+// passed. Detected explanations refer to the result at Index of their
+// component in the analysis; the others are supply/demand candidates that
+// had a leg-in and a base but failed a later check, which is usually why
+// a zone seen on the chart is missing.
+type Explanation struct {
+	Component string      `json:"component"`
+	Index     int         `json:"index"`
+	Time      time.Time   `json:"time"`
+	Detected  bool        `json:"detected"`
+	Criteria  []Criterion `json:"criteria"`
+}
+
+// Criterion is one check of a detector, with the value compared to the
+// threshold as the detector does it.
+type Criterion struct {
+	Name      string  `json:"name"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Passed    bool    `json:"passed"`
+}
+
+func atLeast(name string, value, threshold float64) Criterion {
+	return Criterion{Name: name, Value: value, Threshold: threshold, Passed: value >= threshold}
+}
+
+func atMost(name string, value, threshold float64) Criterion {
+	return Criterion{Name: name, Value: value, Threshold: threshold, Passed: value <= threshold}
+}
+
+func below(name string, value, threshold float64) Criterion {
+	return Criterion{Name: name, Value: value, Threshold: threshold, Passed: value < threshold}
+}
+
+// beyond is a strict "value > threshold" check, as closes through a level
+// are.
+func beyond(name string, value, threshold float64) Criterion {
+	return Criterion{Name: name, Value: value, Threshold: threshold, Passed: value > threshold}
+}
+
+// explain builds the explanations of the components of analysis that
+// completed and were asked for.
+func explain(candles []models.OHLC, analysis Analysis, params Params) []Explanation {
+	explanations := []Explanation{}
+	if analysis.Zones != nil && analysis.included("zones") {
+		explanations = append(explanations, explainZones(candles, analysis.Zones, params.SupplyDemand)...)
+	}
+	if analysis.Structure != nil && analysis.included("structure") {
+		for i, b := range analysis.Structure {
+			through := candles[b.Index].Close - b.Level
+			if b.Direction == models.Bearish {
+				through = -through
+			}
+			explanations = append(explanations, Explanation{
+				Component: "structure",
+				Index:     i,
+				Time:      b.Time,
+				Detected:  true,
+				Criteria: []Criterion{
+					beyond("close_through_level", through, 0),
+					beyond("bars_since_swing", float64(b.Index-b.SwingIndex), float64(params.SwingStrength)),
+				},
+			})
+		}
+	}
+	if analysis.FVGs != nil && analysis.included("fvgs") {
+		for i, gap := range analysis.FVGs {
+			explanations = append(explanations, Explanation{
+				Component: "fvgs",
+				Index:     i,
+				Time:      gap.StartTime,
+				Detected:  true,
+				Criteria:  []Criterion{beyond("gap_size", gap.Top-gap.Bottom, 0)},
+			})
+		}
+	}
+	if analysis.SFPs != nil && analysis.included("sfps") {
+		for i, sfp := range analysis.SFPs {
+			explanations = append(explanations, explainSFP(candles, i, sfp, params.SFP))
+		}
+	}
+	return explanations
+}
+
+// explainZones explains the detected supply and demand zones along with
+// the candidates rejected after their base, walking candles as
+// SupplyDemand does.
+func explainZones(candles []models.OHLC, zones []SDZone, params SDParams) []Explanation {
+	explanations := []Explanation{}
+	if len(candles) < 3 {
+		return explanations
+	}
+	var ranges float64
+	for _, c := range candles {
+		ranges += c.High - c.Low
+	}
+	legRange := params.LegRange * ranges / float64(len(candles))
+	leg := func(name string, c models.OHLC) []Criterion {
+		return []Criterion{
+			atLeast(name+"_body_ratio", bodyRatio(c), 0.5),
+			atLeast(name+"_range", c.High-c.Low, legRange),
+		}
+	}
+	passed := func(criteria []Criterion) bool {
+		for _, c := range criteria {
+			if !c.Passed {
+				return false
+			}
+		}
+		return true
+	}
+
+	detected := make(map[int]int, len(zones))
+	for i, z := range zones {
+		detected[z.StartIndex] = i
+	}
+	for in := 0; in < len(candles)-2; in++ {
+		criteria := leg("leg_in", candles[in])
+		if !passed(criteria) {
+			continue
+		}
+		out := in + 1
+		var baseRatio float64
+		for out < len(candles) && out-in-1 < params.MaxBase && bodyRatio(candles[out]) < 0.5 {
+			baseRatio = math.Max(baseRatio, bodyRatio(candles[out]))
+			out++
+		}
+		if out == in+1 || out >= len(candles) {
+			continue
+		}
+		criteria = append(criteria,
+			below("base_body_ratio", baseRatio, 0.5),
+			atMost("base_candles", float64(out-in-1), float64(params.MaxBase)),
+		)
+		criteria = append(criteria, leg("leg_out", candles[out])...)
+
+		base := candles[in+1 : out]
+		baseHigh, baseLow := base[0].High, base[0].Low
+		for _, c := range base {
+			baseHigh = math.Max(baseHigh, c.High)
+			baseLow = math.Min(baseLow, c.Low)
+		}
+		if direction(candles[out]) == models.Bullish {
+			criteria = append(criteria, beyond("leg_out_close_above_base", candles[out].Close, baseHigh))
+		} else {
+			criteria = append(criteria, below("leg_out_close_below_base", candles[out].Close, baseLow))
+		}
+
+		explanation := Explanation{Component: "zones", Index: -1, Time: candles[in+1].Time, Criteria: criteria}
+		if passed(criteria) {
+			i, ok := detected[in+1]
+			in = out - 1
+			// Zones below the minimum score are left out of the analysis.
+			if !ok {
+				continue
+			}
+			explanation.Index, explanation.Detected = i, true
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+func explainSFP(candles []models.OHLC, i int, sfp SFP, params SFPParams) Explanation {
+	c := candles[sfp.Index]
+	sweep, inside := sfp.Extreme-sfp.SwingPrice, sfp.SwingPrice-c.Close
+	if sfp.Direction == models.Bullish {
+		sweep, inside = -sweep, -inside
+	}
+	criteria := []Criterion{
+		beyond("sweep_depth", sweep, 0),
+		beyond("close_back_inside", inside, 0),
+		atLeast("confirming_closes", float64(sfp.ConfirmIndex-sfp.Index+1), float64(max(params.ConfirmCloses, 1))),
+	}
+	if params.VolumeFactor > 0 {
+		criteria = append(criteria, atLeast("volume_ratio", sfp.VolumeRatio, params.VolumeFactor))
+	}
+	return Explanation{Component: "sfps", Index: i, Time: sfp.Time, Detected: true, Criteria: criteria}
+}
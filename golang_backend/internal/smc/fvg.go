@@ -0,0 +1,137 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Zone types of fair value gaps.
+const (
+	FVG         = "fvg"
+	InvertedFVG = "ifvg"
+)
+
+// Timeframes an FVG inversion can be confirmed on.
+const (
+	SameTimeframe  = "same"
+	LowerTimeframe = "lower"
+)
+
+// FVGs finds three-candle fair value gaps: a bullish gap where the low of
+// the third candle is above the high of the first, a bearish one where its
+// high is below the first's low. The zone is the gap, starting at the
+// middle candle, and is mitigated once a candle closes through it.
+func FVGs(candles []models.OHLC) []models.Zone {
+	gaps := []models.Zone{}
+	for i := 1; i < len(candles)-1; i++ {
+		prev, next := candles[i-1], candles[i+1]
+		gap := models.Zone{Type: FVG, StartIndex: i, StartTime: candles[i].Time}
+		switch {
+		case next.Low > prev.High:
+			gap.Direction, gap.Top, gap.Bottom = models.Bullish, next.Low, prev.High
+		case next.High < prev.Low:
+			gap.Direction, gap.Top, gap.Bottom = models.Bearish, prev.Low, next.High
+		default:
+			continue
+		}
+		trackTouches(candles, i+2, &gap)
+		gaps = append(gaps, gap)
+	}
+	return gaps
+}
+
+// FVGInversion links a fair value gap to its inversion: price closed
+// through the gap at BrokenAt and, at ConfirmedAt, came back into it and
+// was rejected, so that a bullish gap now acts as resistance and a bearish
+// one as support. Inverted is the gap as an opposite-direction zone from
+// the confirmation on. Timeframe tells whether the break and confirmation
+// were found on the gap's candles or on the lower timeframe ones; the
+// start index of Inverted refers to that series.
+type FVGInversion struct {
+	Gap         models.Zone `json:"gap"`
+	Inverted    models.Zone `json:"inverted"`
+	Timeframe   string      `json:"timeframe"`
+	BrokenAt    time.Time   `json:"broken_at"`
+	ConfirmedAt time.Time   `json:"confirmed_at"`
+}
+
+// FVGInversions finds the inverted fair value gaps of candles. When lower
+// holds candles of a lower timeframe, each gap is also checked on them
+// from the close of the gap's third candle on, which catches inversions
+// that a higher timeframe close would confirm only later, if at all. A gap
+// can then have an inversion on both timeframes.
+func FVGInversions(candles, lower []models.OHLC) []FVGInversion {
+	inversions := []FVGInversion{}
+	for _, gap := range FVGs(candles) {
+		if inversion, ok := invert(gap, candles, gap.StartIndex+2, SameTimeframe); ok {
+			inversions = append(inversions, inversion)
+		}
+		if len(lower) == 0 {
+			continue
+		}
+		formed := gapFormed(candles, gap.StartIndex)
+		from := 0
+		for from < len(lower) && lower[from].Time.Before(formed) {
+			from++
+		}
+		if inversion, ok := invert(gap, lower, from, LowerTimeframe); ok {
+			inversions = append(inversions, inversion)
+		}
+	}
+	return inversions
+}
+
+// gapFormed returns the time the third candle of the gap centred on i
+// closed: the open of the candle after it or, for the last candle, its
+// open plus the spacing of the gap's candles.
+func gapFormed(candles []models.OHLC, i int) time.Time {
+	if i+2 < len(candles) {
+		return candles[i+2].Time
+	}
+	return candles[i+1].Time.Add(candles[i+1].Time.Sub(candles[i].Time))
+}
+
+// invert looks for the inversion of gap in candles from index from on: a
+// close through the gap, then a candle that trades back into it and closes
+// on the broken side. A close back beyond the far side of the gap before
+// the confirmation restores it and ends the search.
+func invert(gap models.Zone, candles []models.OHLC, from int, timeframe string) (FVGInversion, bool) {
+	bullish := gap.Direction == models.Bullish
+	broken := -1
+	for i := from; i < len(candles); i++ {
+		c := candles[i]
+		if broken < 0 {
+			if bullish && c.Close < gap.Bottom || !bullish && c.Close > gap.Top {
+				broken = i
+			}
+			continue
+		}
+		if bullish && c.Close > gap.Top || !bullish && c.Close < gap.Bottom {
+			return FVGInversion{}, false
+		}
+		if bullish && c.High >= gap.Bottom && c.Close < gap.Bottom ||
+			!bullish && c.Low <= gap.Top && c.Close > gap.Top {
+			inverted := models.Zone{
+				Type:       InvertedFVG,
+				Direction:  models.Bearish,
+				Top:        gap.Top,
+				Bottom:     gap.Bottom,
+				StartIndex: i,
+				StartTime:  c.Time,
+			}
+			if !bullish {
+				inverted.Direction = models.Bullish
+			}
+			trackTouches(candles, i+1, &inverted)
+			return FVGInversion{
+				Gap:         gap,
+				Inverted:    inverted,
+				Timeframe:   timeframe,
+				BrokenAt:    candles[broken].Time,
+				ConfirmedAt: c.Time,
+			}, true
+		}
+	}
+	return FVGInversion{}, false
+}
@@ -0,0 +1,146 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/internal/calendar"
+	"github.com/abs/go_billing/models"
+)
+
+// Session is a trading session as a wall clock window of the day in
+// Location, UTC when nil, so that it follows the local DST transitions. A
+// session whose End is before its Start wraps past midnight.
+type Session struct {
+	Name     string
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// DefaultSessions are the Asia, London and New York sessions, 09:00 to
+// 18:00 Tokyo and 08:00 to 17:00 in London and New York local time.
+var DefaultSessions = []Session{
+	{Name: "asia", Start: 9 * time.Hour, End: 18 * time.Hour, Location: calendar.MustLocation("Asia/Tokyo")},
+	{Name: "london", Start: 8 * time.Hour, End: 17 * time.Hour, Location: calendar.MustLocation("Europe/London")},
+	{Name: "new_york", Start: 8 * time.Hour, End: 17 * time.Hour, Location: calendar.MustLocation("America/New_York")},
+}
+
+// newYork is the time zone of the midnight and weekly opens.
+var newYork = calendar.MustLocation("America/New_York")
+
+// Level is a named time-based liquidity level. Index is the candle that
+// set it. A high or low is swept once a later candle trades beyond it; an
+// open once a later candle trades through it.
+type Level struct {
+	Name       string     `json:"name"`
+	Price      float64    `json:"price"`
+	Index      int        `json:"index"`
+	Time       time.Time  `json:"time"`
+	Swept      bool       `json:"swept"`
+	SweptIndex int        `json:"swept_index,omitempty"`
+	SweptTime  *time.Time `json:"swept_time,omitempty"`
+}
+
+// Contains reports whether t falls in the session.
+func (s Session) Contains(t time.Time) bool {
+	location := s.Location
+	if location == nil {
+		location = time.UTC
+	}
+	tod := calendar.TimeOfDay(t.In(location))
+	if s.Start <= s.End {
+		return tod >= s.Start && tod < s.End
+	}
+	return tod >= s.Start || tod < s.End
+}
+
+// TimeLevels returns the high and low of the last completed instance of
+// each session plus the current midnight and weekly opens, each with its
+// swept status. As in ICT usage, the midnight open is at 00:00 New York
+// time and the weekly open at the Sunday 18:00 New York open of the
+// trading week.
+func TimeLevels(candles []models.OHLC, sessions []Session) []Level {
+	levels := []Level{}
+	if len(candles) == 0 {
+		return levels
+	}
+
+	for _, session := range sessions {
+		// Walk back to the last bar of the last session that has ended.
+		end := len(candles) - 1
+		for end >= 0 && session.Contains(candles[end].Time) {
+			end--
+		}
+		for end >= 0 && !session.Contains(candles[end].Time) {
+			end--
+		}
+		if end < 0 {
+			continue
+		}
+		// Sessions last under a day, which keeps consecutive days apart
+		// when the data has no bars between them.
+		start := end
+		for start > 0 && session.Contains(candles[start-1].Time) &&
+			candles[end].Time.Sub(candles[start-1].Time) < 24*time.Hour {
+			start--
+		}
+
+		high, low := start, start
+		for i := start; i <= end; i++ {
+			if candles[i].High > candles[high].High {
+				high = i
+			}
+			if candles[i].Low < candles[low].Low {
+				low = i
+			}
+		}
+		highLevel := newLevel(session.Name+"_high", candles[high].High, candles, high)
+		lowLevel := newLevel(session.Name+"_low", candles[low].Low, candles, low)
+		markSwept(&highLevel, candles, end+1, func(c models.OHLC) bool { return c.High > highLevel.Price })
+		markSwept(&lowLevel, candles, end+1, func(c models.OHLC) bool { return c.Low < lowLevel.Price })
+		levels = append(levels, highLevel, lowLevel)
+	}
+
+	last := candles[len(candles)-1].Time.In(newYork)
+	midnight := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, newYork)
+	weekStart := time.Date(last.Year(), last.Month(), last.Day()-int(last.Weekday()), 18, 0, 0, 0, newYork)
+	if last.Before(weekStart) {
+		weekStart = time.Date(last.Year(), last.Month(), last.Day()-int(last.Weekday())-7, 18, 0, 0, 0, newYork)
+	}
+	for _, open := range []struct {
+		name string
+		from time.Time
+	}{{"midnight_open", midnight}, {"weekly_open", weekStart}} {
+		i := firstAtOrAfter(candles, open.from)
+		if i < 0 {
+			continue
+		}
+		level := newLevel(open.name, candles[i].Open, candles, i)
+		markSwept(&level, candles, i+1, func(c models.OHLC) bool { return c.Low <= level.Price && c.High >= level.Price })
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+func newLevel(name string, price float64, candles []models.OHLC, i int) Level {
+	return Level{Name: name, Price: price, Index: i, Time: candles[i].Time}
+}
+
+func markSwept(level *Level, candles []models.OHLC, from int, swept func(models.OHLC) bool) {
+	for i := from; i < len(candles); i++ {
+		if swept(candles[i]) {
+			t := candles[i].Time
+			level.Swept, level.SweptIndex, level.SweptTime = true, i, &t
+			return
+		}
+	}
+}
+
+func firstAtOrAfter(candles []models.OHLC, t time.Time) int {
+	for i, c := range candles {
+		if !c.Time.Before(t) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,197 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Market maker model types and the phases and stages of their cycle. The
+// smart money reversal is only known once a structure break against the
+// initial run confirms it, at which point distribution begins, so it is a
+// phase but never the current stage.
+const (
+	BuyModel  = "buy"
+	SellModel = "sell"
+
+	PhaseConsolidation = "consolidation"
+	PhaseInitialRun    = "initial_run"
+	PhaseReversal      = "smart_money_reversal"
+	PhaseDistribution  = "distribution"
+	StageComplete      = "complete"
+)
+
+// MMParams configures market maker model detection. A consolidation is at
+// least ConsolidationBars candles spanning no more than RangeFactor times
+// the average candle range; structure breaks use SwingStrength.
+type MMParams struct {
+	SwingStrength     int
+	ConsolidationBars int
+	RangeFactor       float64
+}
+
+// MMPhase is one phase of a market maker model, between two candles.
+type MMPhase struct {
+	Name       string    `json:"name"`
+	StartIndex int       `json:"start_index"`
+	StartTime  time.Time `json:"start_time"`
+	EndIndex   int       `json:"end_index"`
+	EndTime    time.Time `json:"end_time"`
+}
+
+// MarketMakerModel is an ICT market maker buy or sell model: a
+// consolidation, an initial run away from it that engineers liquidity, a
+// smart money reversal at Extreme confirmed by a change of character, and
+// distribution back toward the liquidity resting beyond the other side of
+// the consolidation, Target. A buy model runs down first and distributes
+// up. Stage is the phase price is in, or complete once Target traded.
+type MarketMakerModel struct {
+	Model     string    `json:"model"`
+	Direction string    `json:"direction"`
+	Stage     string    `json:"stage"`
+	Top       float64   `json:"top"`
+	Bottom    float64   `json:"bottom"`
+	Extreme   float64   `json:"extreme,omitempty"`
+	Target    float64   `json:"target"`
+	Phases    []MMPhase `json:"phases"`
+}
+
+// MarketMaker holds the market maker models of a series. Current is the
+// last model not yet complete, the cycle price is likely in, or nil.
+type MarketMaker struct {
+	Models  []MarketMakerModel `json:"models"`
+	Current *MarketMakerModel  `json:"current"`
+}
+
+// MarketMakerModels finds market maker models in candles. Consolidations
+// are scanned left to right without overlap. A run that closes back
+// beyond the far side of its consolidation before reversing, or a
+// distribution that closes beyond the reversal extreme, fails the model
+// and it is dropped; a break against the run only counts as the reversal
+// once the run has travelled at least the consolidation's height.
+func MarketMakerModels(candles []models.OHLC, params MMParams) MarketMaker {
+	result := MarketMaker{Models: []MarketMakerModel{}}
+	bars := params.ConsolidationBars
+	if bars < 2 || len(candles) < bars {
+		return result
+	}
+
+	var ranges float64
+	for _, c := range candles {
+		ranges += c.High - c.Low
+	}
+	maxRange := params.RangeFactor * ranges / float64(len(candles))
+	breaks := StructureBreaks(candles, params.SwingStrength)
+
+	for start := 0; start+bars <= len(candles); {
+		top, bottom := candles[start].High, candles[start].Low
+		for _, c := range candles[start+1 : start+bars] {
+			top, bottom = max(top, c.High), min(bottom, c.Low)
+		}
+		if top-bottom > maxRange {
+			start++
+			continue
+		}
+		end := start + bars - 1
+		for end+1 < len(candles) && candles[end+1].Close <= top && candles[end+1].Close >= bottom {
+			end++
+		}
+
+		model, next, ok := marketMaker(candles, breaks, start, end, top, bottom)
+		if ok {
+			result.Models = append(result.Models, model)
+		}
+		start = next
+	}
+
+	for i := len(result.Models) - 1; i >= 0; i-- {
+		if result.Models[i].Stage != StageComplete {
+			result.Current = &result.Models[i]
+			break
+		}
+	}
+	return result
+}
+
+// marketMaker follows the consolidation between start and end through the
+// model's phases. It returns the index to resume scanning from.
+func marketMaker(candles []models.OHLC, breaks []Break, start, end int, top, bottom float64) (MarketMakerModel, int, bool) {
+	last := len(candles) - 1
+	phase := func(name string, from, to int) MMPhase {
+		return MMPhase{Name: name, StartIndex: from, StartTime: candles[from].Time, EndIndex: to, EndTime: candles[to].Time}
+	}
+	model := MarketMakerModel{
+		Stage:  PhaseConsolidation,
+		Top:    top,
+		Bottom: bottom,
+		Phases: []MMPhase{phase(PhaseConsolidation, start, end)},
+	}
+	run := end + 1
+	if run > last {
+		model.Target = top
+		return model, run, true
+	}
+
+	// sign turns prices into distances in the run's direction, so that
+	// both models share one code path.
+	sign := 1.0
+	model.Model, model.Direction, model.Target = SellModel, models.Bearish, bottom
+	if candles[run].Close < bottom {
+		sign = -1
+		model.Model, model.Direction, model.Target = BuyModel, models.Bullish, top
+	}
+	runEdge, farEdge := top, bottom
+	if sign < 0 {
+		runEdge, farEdge = bottom, top
+	}
+	extremeOf := func(c models.OHLC) float64 {
+		if sign > 0 {
+			return c.High
+		}
+		return c.Low
+	}
+
+	extreme := run
+	next := 0
+	for next < len(breaks) && breaks[next].Index <= run {
+		next++
+	}
+	reversal := -1
+	for i := run; i <= last && reversal < 0; i++ {
+		if sign*(extremeOf(candles[i])-extremeOf(candles[extreme])) > 0 {
+			extreme = i
+		}
+		if sign*(candles[i].Close-farEdge) < 0 {
+			return MarketMakerModel{}, end + 1, false
+		}
+		for ; next < len(breaks) && breaks[next].Index == i; next++ {
+			travelled := sign * (extremeOf(candles[extreme]) - runEdge)
+			if breaks[next].Direction == model.Direction && travelled >= top-bottom {
+				reversal = i
+			}
+		}
+	}
+	if reversal < 0 {
+		model.Stage = PhaseInitialRun
+		model.Phases = append(model.Phases, phase(PhaseInitialRun, run, extreme))
+		return model, last + 1, true
+	}
+
+	model.Extreme = extremeOf(candles[extreme])
+	model.Stage = PhaseDistribution
+	model.Phases = append(model.Phases,
+		phase(PhaseInitialRun, run, extreme),
+		phase(PhaseReversal, extreme, reversal))
+	for i := reversal + 1; i <= last; i++ {
+		if sign*(candles[i].Close-model.Extreme) > 0 {
+			return MarketMakerModel{}, reversal + 1, false
+		}
+		if sign > 0 && candles[i].Low <= model.Target || sign < 0 && candles[i].High >= model.Target {
+			model.Stage = StageComplete
+			model.Phases = append(model.Phases, phase(PhaseDistribution, reversal, i))
+			return model, i + 1, true
+		}
+	}
+	model.Phases = append(model.Phases, phase(PhaseDistribution, reversal, last))
+	return model, last + 1, true
+}
@@ -0,0 +1,75 @@
+package smc
+
+import (
+	"sort"
+
+	"github.com/abs/go_billing/models"
+)
+
+// nearestATRPeriod is the ATR period distances are measured in.
+const nearestATRPeriod = 14
+
+// Sides of a zone relative to the price.
+const (
+	Above  = "above"
+	Below  = "below"
+	Inside = "inside"
+)
+
+// NearestZone is the unmitigated zone of its type and direction closest
+// to the price. Side is where the zone lies relative to the price, and
+// the distance, to its nearest edge and 0 inside it, is given in price,
+// percent of the price and multiples of the ATR.
+type NearestZone struct {
+	models.Zone
+	Side        string  `json:"side"`
+	Distance    float64 `json:"distance"`
+	DistancePct float64 `json:"distance_pct"`
+	DistanceATR float64 `json:"distance_atr"`
+}
+
+// NearestZones returns, for every type and direction among zones, the
+// unmitigated zone closest to price, nearest first. A zero atr leaves
+// DistanceATR 0.
+func NearestZones(zones []models.Zone, price, atr float64) []NearestZone {
+	closest := make(map[[2]string]NearestZone)
+	for _, z := range zones {
+		if z.Mitigated {
+			continue
+		}
+		nearest := NearestZone{Zone: z, Side: Inside}
+		switch {
+		case z.Bottom > price:
+			nearest.Side, nearest.Distance = Above, z.Bottom-price
+		case z.Top < price:
+			nearest.Side, nearest.Distance = Below, price-z.Top
+		}
+		key := [2]string{z.Type, z.Direction}
+		if current, ok := closest[key]; ok && current.Distance <= nearest.Distance {
+			continue
+		}
+		if price != 0 {
+			nearest.DistancePct = 100 * nearest.Distance / price
+		}
+		if atr > 0 {
+			nearest.DistanceATR = nearest.Distance / atr
+		}
+		closest[key] = nearest
+	}
+
+	result := make([]NearestZone, 0, len(closest))
+	for _, nearest := range closest {
+		result = append(result, nearest)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Direction < b.Direction
+	})
+	return result
+}
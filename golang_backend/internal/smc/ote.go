@@ -0,0 +1,101 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// OTE retracement bounds of a displacement leg.
+const (
+	oteShallow   = 0.62
+	oteSweetSpot = 0.705
+	oteDeep      = 0.79
+)
+
+// OTE is the optimal trade entry window of the displacement leg that
+// caused a CHoCH. The leg runs from the extreme before the break to the
+// first swing confirmed after it; Top and Bottom are its 62% and 79%
+// retracements. Overlaps lists the zones of the same direction that
+// intersect the window, and Reached whether price has traded into it.
+type OTE struct {
+	Direction  string        `json:"direction"`
+	BreakIndex int           `json:"break_index"`
+	LegStart   int           `json:"leg_start"`
+	LegEnd     int           `json:"leg_end"`
+	LegEndTime time.Time     `json:"leg_end_time"`
+	Top        float64       `json:"top"`
+	Bottom     float64       `json:"bottom"`
+	SweetSpot  float64       `json:"sweet_spot"`
+	Overlaps   []models.Zone `json:"overlaps"`
+	Reached    bool          `json:"reached"`
+}
+
+// OTEs computes the OTE window of every CHoCH whose displacement leg has
+// completed, checking it against zones such as order blocks and FVGs.
+func OTEs(candles []models.OHLC, strength int, breaks []Break, zones []models.Zone) []OTE {
+	return oteWindows(candles, Swings(candles, strength), breaks, zones)
+}
+
+func oteWindows(candles []models.OHLC, swings []Swing, breaks []Break, zones []models.Zone) []OTE {
+	otes := []OTE{}
+	for _, b := range breaks {
+		if b.Type != CHoCH {
+			continue
+		}
+
+		// The leg ends at the first swing of the break's side after it.
+		end := -1
+		want := SwingHigh
+		if b.Direction == models.Bearish {
+			want = SwingLow
+		}
+		for _, s := range swings {
+			if s.Index >= b.Index && s.Type == want {
+				end = s.Index
+				break
+			}
+		}
+		if end < 0 {
+			continue
+		}
+
+		start := b.SwingIndex
+		for i := b.SwingIndex; i <= b.Index; i++ {
+			if b.Direction == models.Bullish && candles[i].Low < candles[start].Low ||
+				b.Direction == models.Bearish && candles[i].High > candles[start].High {
+				start = i
+			}
+		}
+
+		o := OTE{
+			Direction:  b.Direction,
+			BreakIndex: b.Index,
+			LegStart:   start,
+			LegEnd:     end,
+			LegEndTime: candles[end].Time,
+			Overlaps:   []models.Zone{},
+		}
+		if b.Direction == models.Bullish {
+			high, size := candles[end].High, candles[end].High-candles[start].Low
+			o.Top, o.Bottom, o.SweetSpot = high-oteShallow*size, high-oteDeep*size, high-oteSweetSpot*size
+		} else {
+			low, size := candles[end].Low, candles[start].High-candles[end].Low
+			o.Top, o.Bottom, o.SweetSpot = low+oteDeep*size, low+oteShallow*size, low+oteSweetSpot*size
+		}
+
+		for _, z := range zones {
+			if z.Direction == o.Direction && z.Bottom <= o.Top && z.Top >= o.Bottom {
+				o.Overlaps = append(o.Overlaps, z)
+			}
+		}
+		for _, c := range candles[end+1:] {
+			if c.Low <= o.Top && c.High >= o.Bottom {
+				o.Reached = true
+				break
+			}
+		}
+		otes = append(otes, o)
+	}
+	return otes
+}
@@ -0,0 +1,91 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// PowerOfThree is an accumulation-manipulation-distribution sequence within
+// one session instance. The manipulation (judas) leg runs from the session
+// open to the extreme set first; Direction is the expected distribution,
+// opposite to it, and holds while price trades back across the open.
+// Complete is false for the session still in progress.
+type PowerOfThree struct {
+	Session             string    `json:"session"`
+	Start               time.Time `json:"start"`
+	Open                float64   `json:"open"`
+	ManipulationIndex   int       `json:"manipulation_index"`
+	ManipulationTime    time.Time `json:"manipulation_time"`
+	ManipulationExtreme float64   `json:"manipulation_extreme"`
+	ManipulationSize    float64   `json:"manipulation_size"`
+	Direction           string    `json:"direction"`
+	Close               float64   `json:"close"`
+	Complete            bool      `json:"complete"`
+}
+
+// PowerOfThrees tags every session instance whose first excursion from the
+// open, of at least a tenth of the session range, was reversed through the
+// open. Sessions that trended from the open are left out.
+func PowerOfThrees(candles []models.OHLC, sessions []Session) []PowerOfThree {
+	events := []PowerOfThree{}
+	for _, session := range sessions {
+		for start := 0; start < len(candles); start++ {
+			if !session.Contains(candles[start].Time) {
+				continue
+			}
+			end := start
+			for end+1 < len(candles) && session.Contains(candles[end+1].Time) &&
+				candles[end+1].Time.Sub(candles[start].Time) < 24*time.Hour {
+				end++
+			}
+
+			if event, ok := powerOfThree(candles, session.Name, start, end); ok {
+				event.Complete = end < len(candles)-1
+				events = append(events, event)
+			}
+			start = end
+		}
+	}
+	return events
+}
+
+func powerOfThree(candles []models.OHLC, name string, start, end int) (PowerOfThree, bool) {
+	open := candles[start].Open
+	high, low := start, start
+	for i := start; i <= end; i++ {
+		if candles[i].High > candles[high].High {
+			high = i
+		}
+		if candles[i].Low < candles[low].Low {
+			low = i
+		}
+	}
+
+	event := PowerOfThree{
+		Session: name,
+		Start:   candles[start].Time,
+		Open:    open,
+		Close:   candles[end].Close,
+	}
+	switch {
+	case low < high && event.Close > open && candles[low].Low < open:
+		event.Direction = models.Bullish
+		event.ManipulationIndex = low
+		event.ManipulationExtreme = candles[low].Low
+		event.ManipulationSize = open - candles[low].Low
+	case high < low && event.Close < open && candles[high].High > open:
+		event.Direction = models.Bearish
+		event.ManipulationIndex = high
+		event.ManipulationExtreme = candles[high].High
+		event.ManipulationSize = candles[high].High - open
+	default:
+		return PowerOfThree{}, false
+	}
+	// A wick of a few ticks beyond the open is noise, not a judas swing.
+	if event.ManipulationSize < 0.1*(candles[high].High-candles[low].Low) {
+		return PowerOfThree{}, false
+	}
+	event.ManipulationTime = candles[event.ManipulationIndex].Time
+	return event, true
+}
@@ -0,0 +1,191 @@
+package smc
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// MaxReplay is the most bars RepaintAudit replays: each one runs every
+// detector over the history up to it.
+const MaxReplay = 500
+
+// Kinds of repainted detections.
+const (
+	Disappeared = "disappeared"
+	Changed     = "changed"
+)
+
+// RepaintReport compares, for one detector, what a live engine would have
+// shown bar by bar with what the full history shows. Live counts the
+// detections ever shown, Final those of the full history over the same
+// bars. Disappeared detections were shown and later withdrawn; Changed
+// ones kept their place but not their levels or classification. Delays
+// are the bars between the candle a detection belongs to and the bar it
+// first showed, which is confirmation lag rather than repainting.
+type RepaintReport struct {
+	Detector    string         `json:"detector"`
+	Live        int            `json:"live"`
+	Final       int            `json:"final"`
+	Disappeared int            `json:"disappeared"`
+	Changed     int            `json:"changed"`
+	AvgDelay    float64        `json:"avg_delay"`
+	MaxDelay    int            `json:"max_delay"`
+	Events      []RepaintEvent `json:"events"`
+}
+
+// RepaintEvent is one repainted detection. FirstSeen and LastSeen are the
+// indices of the bars at which the live engine first and last showed it
+// as First; Final is how the full history shows it, empty when it
+// disappeared.
+type RepaintEvent struct {
+	Kind      string    `json:"kind"`
+	Key       string    `json:"key"`
+	Index     int       `json:"index"`
+	Time      time.Time `json:"time"`
+	FirstSeen int       `json:"first_seen"`
+	LastSeen  int       `json:"last_seen"`
+	First     string    `json:"first"`
+	Final     string    `json:"final,omitempty"`
+}
+
+// detection identifies a detector output by key, the candle it belongs to
+// and value, the attributes that should never change once shown.
+type detection struct {
+	key   string
+	index int
+	value string
+}
+
+type repaintDetector struct {
+	name   string
+	detect func(candles []models.OHLC) []detection
+}
+
+func repaintDetectors(params Params) []repaintDetector {
+	return []repaintDetector{
+		{"swings", func(c []models.OHLC) []detection {
+			var out []detection
+			for _, s := range Swings(c, params.SwingStrength) {
+				out = append(out, detection{fmt.Sprintf("%s@%d", s.Type, s.Index), s.Index, fmt.Sprint(s.Price)})
+			}
+			return out
+		}},
+		{"structure", func(c []models.OHLC) []detection {
+			var out []detection
+			for _, b := range StructureBreaks(c, params.SwingStrength) {
+				out = append(out, detection{fmt.Sprintf("%s@%d", b.Direction, b.Index), b.Index, fmt.Sprintf("%s %v", b.Type, b.Level)})
+			}
+			return out
+		}},
+		{"zones", func(c []models.OHLC) []detection {
+			var out []detection
+			for _, z := range SupplyDemand(c, params.SupplyDemand) {
+				out = append(out, detection{fmt.Sprintf("%s@%d", z.Type, z.StartIndex), z.StartIndex, fmt.Sprintf("%v-%v %s", z.Bottom, z.Top, z.Formation)})
+			}
+			return out
+		}},
+		{"sfps", func(c []models.OHLC) []detection {
+			var out []detection
+			for _, s := range SwingFailures(c, params.SFP) {
+				out = append(out, detection{fmt.Sprintf("%s@%d", s.Direction, s.Index), s.Index, fmt.Sprintf("swing %d %v", s.SwingIndex, s.SwingPrice)})
+			}
+			return out
+		}},
+		{"fvgs", func(c []models.OHLC) []detection {
+			var out []detection
+			for _, z := range FVGs(c) {
+				out = append(out, detection{fmt.Sprintf("%s@%d", z.Direction, z.StartIndex), z.StartIndex, fmt.Sprintf("%v-%v", z.Bottom, z.Top)})
+			}
+			return out
+		}},
+	}
+}
+
+// RepaintAudit replays up to MaxReplay candles from index from onwards as
+// a live engine would see them, running every SMC detector on the history
+// up to each bar, and reports per detector what was shown live but differs
+// from the full history. Detections already shown before from, or
+// belonging to candles after the replay, are not audited.
+func RepaintAudit(candles []models.OHLC, from int, params Params) []RepaintReport {
+	from = min(max(from, 0), len(candles))
+	stop := min(from+MaxReplay, len(candles))
+	reports := []RepaintReport{}
+	for _, detector := range repaintDetectors(params) {
+		type sighting struct {
+			detection
+			firstSeen, lastSeen int
+		}
+		before := make(map[string]bool)
+		for _, d := range detector.detect(candles[:from]) {
+			before[d.key] = true
+		}
+		seen := make(map[string]*sighting)
+		changed := make(map[string]bool)
+		for end := from + 1; end <= stop; end++ {
+			for _, d := range detector.detect(candles[:end]) {
+				if before[d.key] {
+					continue
+				}
+				s, ok := seen[d.key]
+				if !ok {
+					seen[d.key] = &sighting{detection: d, firstSeen: end - 1, lastSeen: end - 1}
+					continue
+				}
+				s.lastSeen = end - 1
+				if d.value != s.value {
+					changed[d.key] = true
+				}
+			}
+		}
+
+		final := make(map[string]detection)
+		for _, d := range detector.detect(candles) {
+			if !before[d.key] && d.index < stop {
+				final[d.key] = d
+			}
+		}
+		report := RepaintReport{Detector: detector.name, Live: len(seen), Final: len(final), Events: []RepaintEvent{}}
+		var delays int
+		for key, s := range seen {
+			delay := s.firstSeen - s.index
+			delays += delay
+			report.MaxDelay = max(report.MaxDelay, delay)
+
+			event := RepaintEvent{
+				Key:       key,
+				Index:     s.index,
+				Time:      candles[s.index].Time,
+				FirstSeen: s.firstSeen,
+				LastSeen:  s.lastSeen,
+				First:     s.value,
+			}
+			f, ok := final[key]
+			switch {
+			case !ok:
+				event.Kind = Disappeared
+				report.Disappeared++
+			case changed[key]:
+				event.Kind, event.Final = Changed, f.value
+				report.Changed++
+			default:
+				continue
+			}
+			report.Events = append(report.Events, event)
+		}
+		if len(seen) > 0 {
+			report.AvgDelay = float64(delays) / float64(len(seen))
+		}
+		sort.Slice(report.Events, func(i, j int) bool {
+			a, b := report.Events[i], report.Events[j]
+			if a.Index != b.Index {
+				return a.Index < b.Index
+			}
+			return a.Key < b.Key
+		})
+		reports = append(reports, report)
+	}
+	return reports
+}
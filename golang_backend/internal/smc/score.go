@@ -0,0 +1,94 @@
+package smc
+
+import (
+	"math"
+	"sort"
+
+	"github.com/abs/go_billing/models"
+)
+
+// ScoreParams configures zone relevance scoring. Relevance halves every
+// HalfLife bars of age and with every touch (TouchDecay is the factor kept
+// per touch), and falls off exponentially with the distance from the last
+// close in units of DistanceScale average bar ranges.
+type ScoreParams struct {
+	HalfLife      float64
+	TouchDecay    float64
+	DistanceScale float64
+}
+
+// DefaultScoreParams use a half-life of 100 bars, a touch decay of 0.5 and
+// a distance scale of 10 average bar ranges.
+var DefaultScoreParams = ScoreParams{HalfLife: 100, TouchDecay: 0.5, DistanceScale: 10}
+
+// ScoredZone is a zone with its relevance. Age is in bars and Distance in
+// average bar ranges from the last close, 0 when price is inside the zone.
+type ScoredZone struct {
+	models.Zone
+	Age      int     `json:"age"`
+	Distance float64 `json:"distance"`
+	Score    float64 `json:"score"`
+}
+
+// ScoreZones rates zones from 0 to 100 against the latest candle and
+// returns them sorted by score, dropping those below minScore. Mitigated
+// zones score 0.
+func ScoreZones(candles []models.OHLC, zones []models.Zone, params ScoreParams, minScore float64) []ScoredZone {
+	scored := []ScoredZone{}
+	if len(candles) == 0 {
+		return scored
+	}
+
+	scorer := newZoneScorer(candles, params)
+	for _, zone := range zones {
+		if s := scorer.score(zone); s.Score >= minScore {
+			scored = append(scored, s)
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	return scored
+}
+
+// zoneScorer scores zones against the latest candle of a series.
+type zoneScorer struct {
+	params   ScoreParams
+	last     int
+	price    float64
+	avgRange float64
+}
+
+// newZoneScorer creates a scorer for candles, which must not be empty.
+func newZoneScorer(candles []models.OHLC, params ScoreParams) zoneScorer {
+	var ranges float64
+	for _, c := range candles {
+		ranges += c.High - c.Low
+	}
+	last := len(candles) - 1
+	return zoneScorer{
+		params:   params,
+		last:     last,
+		price:    candles[last].Close,
+		avgRange: ranges / float64(len(candles)),
+	}
+}
+
+func (z zoneScorer) score(zone models.Zone) ScoredZone {
+	s := ScoredZone{Zone: zone, Age: max(z.last-zone.StartIndex, 0)}
+	switch {
+	case z.price > zone.Top:
+		s.Distance = z.price - zone.Top
+	case z.price < zone.Bottom:
+		s.Distance = zone.Bottom - z.price
+	}
+	if z.avgRange > 0 {
+		s.Distance /= z.avgRange
+	}
+
+	if !zone.Mitigated {
+		s.Score = 100 *
+			math.Pow(0.5, float64(s.Age)/z.params.HalfLife) *
+			math.Pow(z.params.TouchDecay, float64(zone.Touches)) *
+			math.Exp(-s.Distance/z.params.DistanceScale)
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// volumeLookback is the number of bars averaged for volume confirmation.
+const volumeLookback = 20
+
+// SFPParams configures swing failure detection. ConfirmCloses is the number
+// of consecutive closes back inside the swing, starting with the sweeping
+// bar, needed to confirm. With VolumeFactor above zero the sweeping bar's
+// volume must be at least that multiple of the previous bars' average.
+type SFPParams struct {
+	SwingStrength int
+	ConfirmCloses int
+	VolumeFactor  float64
+}
+
+// SFP is a swing failure: a wick beyond a swing that closed back inside.
+// Bearish SFPs sweep swing highs, bullish ones swing lows. Extreme is the
+// wick's furthest price and ConfirmIndex the bar of the last confirming
+// close.
+type SFP struct {
+	Direction    string    `json:"direction"`
+	Index        int       `json:"index"`
+	Time         time.Time `json:"time"`
+	SwingIndex   int       `json:"swing_index"`
+	SwingPrice   float64   `json:"swing_price"`
+	Extreme      float64   `json:"extreme"`
+	VolumeRatio  float64   `json:"volume_ratio,omitempty"`
+	ConfirmIndex int       `json:"confirm_index"`
+}
+
+// SwingFailures finds SFPs on a closing basis. A swing can be used once: it
+// is consumed by the first bar that trades beyond it, whether that bar
+// fails back inside or closes through. When one wick takes several swings
+// the most extreme one is reported.
+func SwingFailures(candles []models.OHLC, params SFPParams) []SFP {
+	return swingFailures(candles, Swings(candles, params.SwingStrength), params)
+}
+
+func swingFailures(candles []models.OHLC, swings []Swing, params SFPParams) []SFP {
+	sfps := []SFP{}
+
+	var highs, lows []Swing
+	next := 0
+	for i, c := range candles {
+		// Swings become usable once their right side has printed.
+		for next < len(swings) && swings[next].Index+params.SwingStrength < i {
+			if swings[next].Type == SwingHigh {
+				highs = append(highs, swings[next])
+			} else {
+				lows = append(lows, swings[next])
+			}
+			next++
+		}
+
+		var swept Swing
+		var ok bool
+		highs, swept, ok = sweep(highs, func(s Swing) bool { return c.High > s.Price }, func(a, b Swing) bool { return a.Price > b.Price })
+		if ok && c.Close < swept.Price {
+			if sfp, ok := confirm(candles, i, swept, models.Bearish, c.High, params); ok {
+				sfps = append(sfps, sfp)
+			}
+		}
+		lows, swept, ok = sweep(lows, func(s Swing) bool { return c.Low < s.Price }, func(a, b Swing) bool { return a.Price < b.Price })
+		if ok && c.Close > swept.Price {
+			if sfp, ok := confirm(candles, i, swept, models.Bullish, c.Low, params); ok {
+				sfps = append(sfps, sfp)
+			}
+		}
+	}
+	return sfps
+}
+
+// sweep removes the swings taken by a bar and returns the most extreme,
+// if any. The swing is returned by value: a pointer to the loop variable
+// would escape and allocate on every bar.
+func sweep(active []Swing, taken func(Swing) bool, further func(a, b Swing) bool) ([]Swing, Swing, bool) {
+	var swept Swing
+	var found bool
+	kept := active[:0]
+	for _, s := range active {
+		if !taken(s) {
+			kept = append(kept, s)
+			continue
+		}
+		if !found || further(s, swept) {
+			swept, found = s, true
+		}
+	}
+	return kept, swept, found
+}
+
+func confirm(candles []models.OHLC, i int, swing Swing, direction string, extreme float64, params SFPParams) (SFP, bool) {
+	end := i + max(params.ConfirmCloses, 1) - 1
+	if end >= len(candles) {
+		return SFP{}, false
+	}
+	for j := i + 1; j <= end; j++ {
+		if direction == models.Bearish && candles[j].Close >= swing.Price ||
+			direction == models.Bullish && candles[j].Close <= swing.Price {
+			return SFP{}, false
+		}
+	}
+
+	sfp := SFP{
+		Direction:    direction,
+		Index:        i,
+		Time:         candles[i].Time,
+		SwingIndex:   swing.Index,
+		SwingPrice:   swing.Price,
+		Extreme:      extreme,
+		ConfirmIndex: end,
+	}
+	if params.VolumeFactor > 0 {
+		start := max(i-volumeLookback, 0)
+		if start == i {
+			return SFP{}, false
+		}
+		var sum float64
+		for _, c := range candles[start:i] {
+			sum += c.Volume
+		}
+		if avg := sum / float64(i-start); avg > 0 {
+			sfp.VolumeRatio = candles[i].Volume / avg
+		}
+		if sfp.VolumeRatio < params.VolumeFactor {
+			return SFP{}, false
+		}
+	}
+	return sfp, true
+}
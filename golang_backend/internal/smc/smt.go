@@ -0,0 +1,109 @@
+package smc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// SMTLeg is the move of one symbol between two consecutive swings.
+type SMTLeg struct {
+	Symbol    string    `json:"symbol"`
+	PrevTime  time.Time `json:"prev_time"`
+	PrevPrice float64   `json:"prev_price"`
+	Time      time.Time `json:"time"`
+	Price     float64   `json:"price"`
+}
+
+// SMT is a divergence between two correlated symbols: Leader made a new
+// high (bearish SMT) or low (bullish SMT) that Lagger failed to confirm.
+type SMT struct {
+	Direction string    `json:"direction"`
+	Time      time.Time `json:"time"`
+	Leader    string    `json:"leader"`
+	Lagger    string    `json:"lagger"`
+	Legs      [2]SMTLeg `json:"legs"`
+}
+
+// SMTDivergences compares consecutive swings of a and b over the candles
+// they share by time. The other symbol's extreme is taken within strength
+// bars of the swing, so that the two need not peak on the same bar.
+func SMTDivergences(symbolA string, a []models.OHLC, symbolB string, b []models.OHLC, strength int) ([]SMT, error) {
+	byTime := make(map[int64]int, len(b))
+	for i, c := range b {
+		byTime[c.Time.UnixNano()] = i
+	}
+	var alignedA, alignedB []models.OHLC
+	for _, c := range a {
+		if j, ok := byTime[c.Time.UnixNano()]; ok {
+			alignedA = append(alignedA, c)
+			alignedB = append(alignedB, b[j])
+		}
+	}
+	if len(alignedA) == 0 {
+		return nil, fmt.Errorf("%s and %s share no candle times", symbolA, symbolB)
+	}
+
+	events := []SMT{}
+	series := []struct {
+		symbol  string
+		candles []models.OHLC
+	}{{symbolA, alignedA}, {symbolB, alignedB}}
+	for k, leader := range series {
+		lagger := series[1-k]
+		prev := map[string]*Swing{}
+		for _, s := range Swings(leader.candles, strength) {
+			p := prev[s.Type]
+			prev[s.Type] = &s
+			if p == nil {
+				continue
+			}
+
+			lagPrev := extreme(lagger.candles, p.Index, strength, s.Type)
+			lagNow := extreme(lagger.candles, s.Index, strength, s.Type)
+			var direction string
+			switch {
+			case s.Type == SwingHigh && s.Price > p.Price && lagNow.price <= lagPrev.price:
+				direction = models.Bearish
+			case s.Type == SwingLow && s.Price < p.Price && lagNow.price >= lagPrev.price:
+				direction = models.Bullish
+			default:
+				continue
+			}
+			events = append(events, SMT{
+				Direction: direction,
+				Time:      s.Time,
+				Leader:    leader.symbol,
+				Lagger:    lagger.symbol,
+				Legs: [2]SMTLeg{
+					{Symbol: leader.symbol, PrevTime: p.Time, PrevPrice: p.Price, Time: s.Time, Price: s.Price},
+					{Symbol: lagger.symbol, PrevTime: lagPrev.time, PrevPrice: lagPrev.price, Time: lagNow.time, Price: lagNow.price},
+				},
+			})
+		}
+	}
+	return events, nil
+}
+
+type point struct {
+	time  time.Time
+	price float64
+}
+
+// extreme returns the highest high or lowest low within strength bars of i.
+func extreme(candles []models.OHLC, i, strength int, kind string) point {
+	best := point{time: candles[i].Time, price: candles[i].High}
+	if kind == SwingLow {
+		best.price = candles[i].Low
+	}
+	for j := max(i-strength, 0); j <= min(i+strength, len(candles)-1); j++ {
+		if kind == SwingHigh && candles[j].High > best.price {
+			best = point{time: candles[j].Time, price: candles[j].High}
+		}
+		if kind == SwingLow && candles[j].Low < best.price {
+			best = point{time: candles[j].Time, price: candles[j].Low}
+		}
+	}
+	return best
+}
@@ -0,0 +1,76 @@
+package smc
+
+import (
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Structure break types.
+const (
+	BOS   = "bos"
+	CHoCH = "choch"
+)
+
+// Break is a close beyond the latest confirmed swing. It is a change of
+// character when it goes against the prevailing structure and a break of
+// structure when it continues it.
+type Break struct {
+	Type       string    `json:"type"`
+	Direction  string    `json:"direction"`
+	Index      int       `json:"index"`
+	Time       time.Time `json:"time"`
+	Level      float64   `json:"level"`
+	SwingIndex int       `json:"swing_index"`
+}
+
+// StructureBreaks walks candles and reports every close through the latest
+// swing high or low once that swing is confirmed. Each swing is broken at
+// most once. The first break sets the structure and counts as a BOS.
+func StructureBreaks(candles []models.OHLC, strength int) []Break {
+	return structureBreaks(candles, Swings(candles, strength), strength)
+}
+
+func structureBreaks(candles []models.OHLC, swings []Swing, strength int) []Break {
+	breaks := []Break{}
+
+	var high, low *Swing
+	var trend string
+	next := 0
+	for i, c := range candles {
+		for next < len(swings) && swings[next].Index+strength < i {
+			s := swings[next]
+			if s.Type == SwingHigh {
+				high = &s
+			} else {
+				low = &s
+			}
+			next++
+		}
+
+		if high != nil && c.Close > high.Price {
+			breaks = append(breaks, newBreak(trend, models.Bullish, i, c.Time, *high))
+			trend, high = models.Bullish, nil
+		}
+		if low != nil && c.Close < low.Price {
+			breaks = append(breaks, newBreak(trend, models.Bearish, i, c.Time, *low))
+			trend, low = models.Bearish, nil
+		}
+	}
+	return breaks
+}
+
+func newBreak(trend, direction string, i int, t time.Time, swing Swing) Break {
+	kind := BOS
+	if trend != "" && trend != direction {
+		kind = CHoCH
+	}
+	return Break{
+		Type:       kind,
+		Direction:  direction,
+		Index:      i,
+		Time:       t,
+		Level:      swing.Price,
+		SwingIndex: swing.Index,
+	}
+}
@@ -0,0 +1,143 @@
+package smc
+
+import (
+	"math"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Zone types produced by SupplyDemand.
+const (
+	Supply = "supply"
+	Demand = "demand"
+)
+
+// SDParams configures supply/demand detection. A base is 1 to MaxBase
+// candles whose body is less than half their range, between two explosive
+// candles: a body of at least half the range and a range of at least
+// LegRange times the average. Zones are scored with Score, or
+// DefaultScoreParams when it is zero.
+type SDParams struct {
+	MaxBase  int
+	LegRange float64
+	Score    ScoreParams
+}
+
+// SDZone is a supply or demand zone. Formation is the leg-in, base and
+// leg-out sequence (DBR, RBR, RBD or DBD). Freshness is 100 for an
+// untouched zone, halves with every touch and is 0 once price closes
+// through the zone. Score is its relevance from 0 to 100 as ScoreZones
+// rates it against the last candle.
+type SDZone struct {
+	models.Zone
+	Formation string  `json:"formation"`
+	Freshness float64 `json:"freshness"`
+	Score     float64 `json:"score"`
+}
+
+// SupplyDemand finds base-explosion supply and demand zones. Demand zones
+// span the base from its highest body to its lowest low, supply zones from
+// its lowest body to its highest high.
+func SupplyDemand(candles []models.OHLC, params SDParams) []SDZone {
+	zones := []SDZone{}
+	if len(candles) < 3 {
+		return zones
+	}
+
+	var ranges float64
+	for _, c := range candles {
+		ranges += c.High - c.Low
+	}
+	avgRange := ranges / float64(len(candles))
+	explosive := func(c models.OHLC) bool {
+		return bodyRatio(c) >= 0.5 && c.High-c.Low >= params.LegRange*avgRange
+	}
+
+	for in := 0; in < len(candles)-2; in++ {
+		if !explosive(candles[in]) {
+			continue
+		}
+		out := in + 1
+		for out < len(candles) && out-in-1 < params.MaxBase && bodyRatio(candles[out]) < 0.5 {
+			out++
+		}
+		if out == in+1 || out >= len(candles) || !explosive(candles[out]) {
+			continue
+		}
+
+		base := candles[in+1 : out]
+		baseHigh, baseLow := base[0].High, base[0].Low
+		bodyHigh, bodyLow := math.Max(base[0].Open, base[0].Close), math.Min(base[0].Open, base[0].Close)
+		for _, c := range base {
+			baseHigh = math.Max(baseHigh, c.High)
+			baseLow = math.Min(baseLow, c.Low)
+			bodyHigh = math.Max(bodyHigh, math.Max(c.Open, c.Close))
+			bodyLow = math.Min(bodyLow, math.Min(c.Open, c.Close))
+		}
+
+		legIn, legOut := direction(candles[in]), direction(candles[out])
+		zone := SDZone{Zone: models.Zone{StartIndex: in + 1, StartTime: candles[in+1].Time}}
+		switch {
+		case legOut == models.Bullish && candles[out].Close > baseHigh:
+			zone.Type, zone.Direction = Demand, models.Bullish
+			zone.Top, zone.Bottom = bodyHigh, baseLow
+			zone.Formation = map[string]string{models.Bearish: "DBR", models.Bullish: "RBR"}[legIn]
+		case legOut == models.Bearish && candles[out].Close < baseLow:
+			zone.Type, zone.Direction = Supply, models.Bearish
+			zone.Top, zone.Bottom = baseHigh, bodyLow
+			zone.Formation = map[string]string{models.Bullish: "RBD", models.Bearish: "DBD"}[legIn]
+		default:
+			continue
+		}
+
+		trackTouches(candles, out+1, &zone.Zone)
+		zone.Freshness = 100 / math.Pow(2, float64(zone.Touches))
+		if zone.Mitigated {
+			zone.Freshness = 0
+		}
+		zones = append(zones, zone)
+		in = out - 1
+	}
+
+	scoreParams := params.Score
+	if scoreParams == (ScoreParams{}) {
+		scoreParams = DefaultScoreParams
+	}
+	scorer := newZoneScorer(candles, scoreParams)
+	for i := range zones {
+		zones[i].Score = scorer.score(zones[i].Zone).Score
+	}
+	return zones
+}
+
+// trackTouches counts the separate visits of price into zone from candle
+// from on, and marks it mitigated at the first close through it.
+func trackTouches(candles []models.OHLC, from int, zone *models.Zone) {
+	inside := false
+	for _, c := range candles[from:] {
+		if zone.Direction == models.Bullish && c.Close < zone.Bottom ||
+			zone.Direction == models.Bearish && c.Close > zone.Top {
+			zone.Mitigated = true
+			return
+		}
+		touching := c.Low <= zone.Top && c.High >= zone.Bottom
+		if touching && !inside {
+			zone.Touches++
+		}
+		inside = touching
+	}
+}
+
+func direction(c models.OHLC) string {
+	if c.Close >= c.Open {
+		return models.Bullish
+	}
+	return models.Bearish
+}
+
+func bodyRatio(c models.OHLC) float64 {
+	if c.High == c.Low {
+		return 0
+	}
+	return math.Abs(c.Close-c.Open) / (c.High - c.Low)
+}
@@ -0,0 +1,60 @@
+package smc
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Swing types.
+const (
+	SwingHigh = "high"
+	SwingLow  = "low"
+)
+
+// Swing is a fractal swing point: a high (low) strictly above (below) the
+// highs (lows) of strength bars on each side.
+type Swing struct {
+	Type  string    `json:"type"`
+	Index int       `json:"index"`
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+// Swings returns the swing highs and lows of candles in index order. A
+// swing is only known strength bars after it forms, so the last strength
+// bars never hold one.
+func Swings(candles []models.OHLC, strength int) []Swing {
+	var swings []Swing
+	for i := strength; i+strength < len(candles); i++ {
+		isHigh, isLow := true, true
+		for j := i - strength; j <= i+strength; j++ {
+			if j == i {
+				continue
+			}
+			if candles[j].High >= candles[i].High {
+				isHigh = false
+			}
+			if candles[j].Low <= candles[i].Low {
+				isLow = false
+			}
+		}
+		if isHigh {
+			swings = append(swings, Swing{Type: SwingHigh, Index: i, Time: candles[i].Time, Price: candles[i].High})
+		}
+		if isLow {
+			swings = append(swings, Swing{Type: SwingLow, Index: i, Time: candles[i].Time, Price: candles[i].Low})
+		}
+	}
+	return swings
+}
+
+// CachedSwings returns the swings of the cache's candles at strength,
+// computing them once per cache.
+func CachedSwings(cache *utils.Cache, strength int) []Swing {
+	return cache.Memo(fmt.Sprintf("smc.swings/%d", strength), func() any {
+		return Swings(cache.Candles(), strength)
+	}).([]Swing)
+}
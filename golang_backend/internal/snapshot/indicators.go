@@ -0,0 +1,84 @@
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Indicator is an indicator computed for every snapshot, named after its
+// kind and period, as "ema_20".
+type Indicator struct {
+	Kind   string
+	Period int
+}
+
+// DefaultIndicators are computed when no others are configured.
+var DefaultIndicators = []Indicator{{"ema", 20}, {"ema", 50}, {"ema", 200}, {"rsi", 14}}
+
+// indicatorKinds are the kinds an indicator may be of, with the number of
+// candles each needs for a value.
+var indicatorKinds = map[string]func(period int) int{
+	"sma": func(period int) int { return period },
+	"ema": func(period int) int { return period },
+	"wma": func(period int) int { return period },
+	"rsi": func(period int) int { return period + 1 },
+	"atr": func(period int) int { return period + 1 },
+}
+
+// ParseIndicators parses indicator names such as "ema_20" or "rsi_14".
+// The kinds are sma, ema, wma, rsi and atr.
+func ParseIndicators(names []string) ([]Indicator, error) {
+	indicators := make([]Indicator, 0, len(names))
+	for _, name := range names {
+		kind, period, ok := strings.Cut(strings.TrimSpace(name), "_")
+		if _, known := indicatorKinds[kind]; !ok || !known {
+			return nil, fmt.Errorf("invalid indicator %q: want <kind>_<period> with kind sma, ema, wma, rsi or atr", name)
+		}
+		n, err := strconv.Atoi(period)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid indicator %q: period must be a positive integer", name)
+		}
+		indicators = append(indicators, Indicator{Kind: kind, Period: n})
+	}
+	return indicators, nil
+}
+
+// Name returns the key of the indicator in a snapshot.
+func (ind Indicator) Name() string {
+	return fmt.Sprintf("%s_%d", ind.Kind, ind.Period)
+}
+
+// last returns the value of the indicator at the last candle, or false
+// when there are too few candles.
+func (ind Indicator) last(candles []models.OHLC, closes []float64) (float64, bool) {
+	need, ok := indicatorKinds[ind.Kind]
+	if !ok || len(candles) < need(ind.Period) {
+		return 0, false
+	}
+	var series []float64
+	switch ind.Kind {
+	case "rsi":
+		// Only the last value is kept, so the series is pooled.
+		buf := utils.GetFloats(len(candles))
+		defer utils.PutFloats(buf)
+		series = utils.CalculateRSIInto(*buf, closes, ind.Period)
+	case "atr":
+		buf, highBuf, lowBuf := utils.GetFloats(len(candles)), utils.GetFloats(len(candles)), utils.GetFloats(len(candles))
+		defer utils.PutFloats(buf)
+		defer utils.PutFloats(highBuf)
+		defer utils.PutFloats(lowBuf)
+		highs, lows := utils.HighsInto(*highBuf, candles), utils.LowsInto(*lowBuf, candles)
+		series = utils.CalculateATRInto(*buf, highs, lows, closes, ind.Period)
+	case "sma":
+		series = utils.CalculateSMA(closes, ind.Period)
+	case "ema":
+		series = utils.CalculateEMA(closes, ind.Period)
+	case "wma":
+		series = utils.CalculateWMA(closes, ind.Period)
+	}
+	return series[len(series)-1], true
+}
@@ -0,0 +1,182 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/internal/smc"
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// swingStrength is the swing strength used for structure and SFPs.
+const swingStrength = 3
+
+// Snapshot is the latest state of one symbol and timeframe. Indicators
+// holds the last value of each indicator with enough history, Zones the
+// unmitigated supply and demand zones and Bias the direction of the last
+// structure break. Signals are the latest signals generated for the
+// symbol, newest first, attached when the snapshot is served. Candle is the state of the latest candle received, when
+// the timeframe has a fixed duration; with ClosedOnly set, a candle still
+// forming is left out of the analysis, so that nothing in the snapshot
+// changes until the candle closes.
+type Snapshot struct {
+	Symbol     string             `json:"symbol"`
+	Timeframe  string             `json:"timeframe"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+	Candle     *CandleStatus      `json:"candle,omitempty"`
+	ClosedOnly bool               `json:"closed_only"`
+	Time       time.Time          `json:"time"`
+	Close      float64            `json:"close"`
+	Indicators map[string]float64 `json:"indicators"`
+	Zones      []models.Zone      `json:"zones"`
+	Bias       string             `json:"bias,omitempty"`
+	LastBreak  *smc.Break         `json:"last_break,omitempty"`
+	LastSFP    *smc.SFP           `json:"last_sfp,omitempty"`
+	Signals    []signals.Record   `json:"signals,omitempty"`
+}
+
+// CandleStatus tells whether a candle has closed and, while it has not,
+// how long it has left.
+type CandleStatus struct {
+	Open      time.Time `json:"open"`
+	ClosesAt  time.Time `json:"closes_at"`
+	Closed    bool      `json:"closed"`
+	Remaining float64   `json:"remaining_seconds"`
+}
+
+// NewCandleStatus returns the status at now of a candle of duration opened
+// at open.
+func NewCandleStatus(open time.Time, duration time.Duration, now time.Time) CandleStatus {
+	status := CandleStatus{Open: open, ClosesAt: open.Add(duration)}
+	return status.At(now)
+}
+
+// At returns the status at now.
+func (s CandleStatus) At(now time.Time) CandleStatus {
+	s.Closed = !now.Before(s.ClosesAt)
+	s.Remaining = 0
+	if !s.Closed {
+		s.Remaining = s.ClosesAt.Sub(now).Seconds()
+	}
+	return s
+}
+
+// Build computes the snapshot of candles as of now with the given
+// indicators. The latest candle's
+// status is only known for timeframes with a fixed duration, such as "15m"
+// or "4h"; with closedOnly, a latest candle still forming is dropped
+// before the analysis unless it is the only one.
+func Build(symbol, timeframe string, candles []models.OHLC, now time.Time, closedOnly bool, indicators []Indicator) Snapshot {
+	var status *CandleStatus
+	if duration, err := utils.ParseTimeframe(timeframe); err == nil {
+		s := NewCandleStatus(candles[len(candles)-1].Time, duration, now)
+		status = &s
+		if closedOnly && !s.Closed && len(candles) > 1 {
+			candles = candles[:len(candles)-1]
+		}
+	}
+
+	last := len(candles) - 1
+	snap := Snapshot{
+		Symbol:     symbol,
+		Timeframe:  timeframe,
+		UpdatedAt:  now,
+		Candle:     status,
+		ClosedOnly: closedOnly,
+		Time:       candles[last].Time,
+		Close:      candles[last].Close,
+		Indicators: make(map[string]float64),
+		Zones:      []models.Zone{},
+	}
+
+	buf := utils.GetFloats(len(candles))
+	defer utils.PutFloats(buf)
+	closes := utils.ClosesInto(*buf, candles)
+	for _, ind := range indicators {
+		if value, ok := ind.last(candles, closes); ok {
+			snap.Indicators[ind.Name()] = value
+		}
+	}
+
+	for _, z := range smc.SupplyDemand(candles, smc.SDParams{MaxBase: 6, LegRange: 1.5}) {
+		if !z.Mitigated {
+			snap.Zones = append(snap.Zones, z.Zone)
+		}
+	}
+	if breaks := smc.StructureBreaks(candles, swingStrength); len(breaks) > 0 {
+		b := breaks[len(breaks)-1]
+		snap.Bias, snap.LastBreak = b.Direction, &b
+	}
+	if sfps := smc.SwingFailures(candles, smc.SFPParams{SwingStrength: swingStrength, ConfirmCloses: 1}); len(sfps) > 0 {
+		snap.LastSFP = &sfps[len(sfps)-1]
+	}
+	return snap
+}
+
+// Store keeps the latest snapshot per symbol and timeframe in memory so
+// that reads never recompute anything.
+type Store struct {
+	mu        sync.RWMutex
+	snapshots map[string]Snapshot
+}
+
+// NewStore creates an empty store.
+func NewStore() *Store {
+	return &Store{snapshots: make(map[string]Snapshot)}
+}
+
+// Put replaces the snapshot of its symbol and timeframe.
+func (s *Store) Put(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[key(snap.Symbol, snap.Timeframe)] = snap
+}
+
+// Get returns the snapshot of a symbol and timeframe.
+func (s *Store) Get(symbol, timeframe string) (Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap, ok := s.snapshots[key(symbol, timeframe)]
+	if !ok {
+		return Snapshot{}, fmt.Errorf("no snapshot for %s %s", symbol, timeframe)
+	}
+	return snap, nil
+}
+
+// All returns every snapshot ordered by symbol and timeframe.
+func (s *Store) All() []Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snaps := make([]Snapshot, 0, len(s.snapshots))
+	for _, snap := range s.snapshots {
+		snaps = append(snaps, snap)
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return key(snaps[i].Symbol, snaps[i].Timeframe) < key(snaps[j].Symbol, snaps[j].Timeframe)
+	})
+	return snaps
+}
+
+// Restore replaces every snapshot.
+func (s *Store) Restore(snaps []Snapshot) {
+	snapshots := make(map[string]Snapshot, len(snaps))
+	for _, snap := range snaps {
+		snapshots[key(snap.Symbol, snap.Timeframe)] = snap
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = snapshots
+}
+
+func key(symbol, timeframe string) string {
+	return symbol + "/" + timeframe
+}
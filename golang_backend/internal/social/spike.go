@@ -0,0 +1,126 @@
+package social
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/abs/go_billing/internal/signals"
+	"github.com/abs/go_billing/internal/upstream"
+	"github.com/abs/go_billing/models"
+)
+
+// Spike is an interval whose mention count is unusually high compared with
+// the preceding baseline window.
+type Spike struct {
+	Symbol string    `json:"symbol"`
+	Time   time.Time `json:"time"`
+	Count  float64   `json:"count"`
+	Mean   float64   `json:"mean"`
+	StdDev float64   `json:"std_dev"`
+	ZScore float64   `json:"z_score"`
+}
+
+// SpikeMethod is the method of spikes in the signals journal.
+const SpikeMethod = "social_spike"
+
+// Record returns the spike as a journal event. Spikes have no side; the
+// strength is the z-score.
+func (s Spike) Record() signals.Record {
+	return signals.Record{
+		Time:     s.Time,
+		Symbol:   s.Symbol,
+		Method:   SpikeMethod,
+		Strength: s.ZScore,
+		Features: map[string]float64{"count": s.Count, "mean": s.Mean, "std_dev": s.StdDev},
+	}
+}
+
+// Provider fetches mention counts per interval for a symbol.
+type Provider interface {
+	Mentions(ctx context.Context, symbol string, from, to time.Time, interval time.Duration) ([]models.MentionCount, error)
+}
+
+// HTTPProvider reads counts from an API answering
+// GET {base}/mentions?symbol=&from=&to=&interval= with [{"time", "count"}].
+type HTTPProvider struct {
+	baseURL string
+	apiKey  string
+	client  *upstream.Client
+}
+
+// NewHTTPProvider creates a provider for the API at baseURL that sends its
+// requests through client.
+func NewHTTPProvider(baseURL, apiKey string, client *upstream.Client) *HTTPProvider {
+	return &HTTPProvider{
+		baseURL: baseURL,
+		apiKey:  apiKey,
+		client:  client,
+	}
+}
+
+// Mentions implements Provider.
+func (p *HTTPProvider) Mentions(ctx context.Context, symbol string, from, to time.Time, interval time.Duration) ([]models.MentionCount, error) {
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("from", from.Format(time.RFC3339))
+	query.Set("to", to.Format(time.RFC3339))
+	query.Set("interval", interval.String())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/mentions?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("social provider returned %s for %s", resp.Status, symbol)
+	}
+
+	var counts []models.MentionCount
+	if err := json.NewDecoder(resp.Body).Decode(&counts); err != nil {
+		return nil, fmt.Errorf("cannot decode mentions of %s: %w", symbol, err)
+	}
+	return counts, nil
+}
+
+// DetectSpikes flags counts whose z-score against the previous window
+// counts is at least threshold. The first window counts only build the
+// baseline. The standard deviation is floored at one mention so that a flat
+// baseline does not divide by zero.
+func DetectSpikes(symbol string, counts []models.MentionCount, window int, threshold float64) []Spike {
+	var spikes []Spike
+	for i := window; i < len(counts); i++ {
+		var sum, sumSq float64
+		for _, c := range counts[i-window : i] {
+			sum += c.Count
+			sumSq += c.Count * c.Count
+		}
+		mean := sum / float64(window)
+		stdDev := math.Max(math.Sqrt(math.Max(sumSq/float64(window)-mean*mean, 0)), 1)
+
+		z := (counts[i].Count - mean) / stdDev
+		if z < threshold {
+			continue
+		}
+		spikes = append(spikes, Spike{
+			Symbol: symbol,
+			Time:   counts[i].Time,
+			Count:  counts[i].Count,
+			Mean:   mean,
+			StdDev: stdDev,
+			ZScore: z,
+		})
+	}
+	return spikes
+}
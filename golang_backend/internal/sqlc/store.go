@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Store provides the generated queries over a connection pool.
+type Store interface {
+	Querier
+	// Ping checks that the database can be reached.
+	Ping(ctx context.Context) error
+}
+
+// SQLStore is a Store backed by PostgreSQL.
+type SQLStore struct {
+	connPool *pgxpool.Pool
+	*Queries
+}
+
+// NewStore creates a Store on connPool.
+func NewStore(connPool *pgxpool.Pool) Store {
+	return &SQLStore{
+		connPool: connPool,
+		Queries:  New(connPool),
+	}
+}
+
+// Ping implements Store.
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.connPool.Ping(ctx)
+}
@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// BreadthParams configures the breadth computation.
+type BreadthParams struct {
+	EMAPeriod     int
+	RSIPeriod     int
+	HighLowPeriod int
+}
+
+// BreadthPoint is the state of the universe at one timestamp. Composite is
+// a 0-100 fear/greed style score averaging the percent of symbols above
+// their EMA, the average RSI and the new-high share of new highs and lows.
+type BreadthPoint struct {
+	Time        time.Time `json:"time"`
+	Symbols     int       `json:"symbols"`
+	PctAboveEMA float64   `json:"pct_above_ema"`
+	NewHighs    int       `json:"new_highs"`
+	NewLows     int       `json:"new_lows"`
+	AvgRSI      float64   `json:"avg_rsi"`
+	Composite   float64   `json:"composite"`
+}
+
+type symbolSeries struct {
+	candles []models.OHLC
+	ema     []float64
+	rsi     []float64
+	index   map[int64]int
+}
+
+// Breadth computes breadth metrics across a universe of symbols for every
+// timestamp present in any symbol. A symbol only counts towards a metric
+// once it has enough history for it.
+func Breadth(universe map[string][]models.OHLC, params BreadthParams) []BreadthPoint {
+	series := make([]symbolSeries, 0, len(universe))
+	times := make(map[int64]time.Time)
+	buf := utils.GetFloats(0)
+	defer utils.PutFloats(buf)
+	for _, candles := range universe {
+		if cap(*buf) < len(candles) {
+			*buf = make([]float64, len(candles))
+		}
+		closes := utils.ClosesInto(*buf, candles)
+		s := symbolSeries{
+			candles: candles,
+			ema:     utils.CalculateEMA(closes, params.EMAPeriod),
+			rsi:     utils.CalculateRSI(closes, params.RSIPeriod),
+			index:   make(map[int64]int, len(candles)),
+		}
+		for i, candle := range candles {
+			s.index[candle.Time.UnixNano()] = i
+			times[candle.Time.UnixNano()] = candle.Time
+		}
+		series = append(series, s)
+	}
+
+	keys := make([]int64, 0, len(times))
+	for k := range times {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	points := make([]BreadthPoint, 0, len(keys))
+	for _, key := range keys {
+		point := BreadthPoint{Time: times[key]}
+		var aboveEMA, withEMA, withRSI int
+		var rsiSum float64
+		for _, s := range series {
+			i, ok := s.index[key]
+			if !ok {
+				continue
+			}
+			point.Symbols++
+			price := s.candles[i].Close
+
+			if i >= params.EMAPeriod-1 {
+				withEMA++
+				if price > s.ema[i] {
+					aboveEMA++
+				}
+			}
+			if i >= params.RSIPeriod {
+				withRSI++
+				rsiSum += s.rsi[i]
+			}
+			if i >= params.HighLowPeriod {
+				high, low := s.candles[i-1].High, s.candles[i-1].Low
+				for _, prev := range s.candles[i-params.HighLowPeriod : i] {
+					high = max(high, prev.High)
+					low = min(low, prev.Low)
+				}
+				if s.candles[i].High > high {
+					point.NewHighs++
+				}
+				if s.candles[i].Low < low {
+					point.NewLows++
+				}
+			}
+		}
+
+		var components []float64
+		if withEMA > 0 {
+			point.PctAboveEMA = 100 * float64(aboveEMA) / float64(withEMA)
+			components = append(components, point.PctAboveEMA)
+		}
+		if withRSI > 0 {
+			point.AvgRSI = rsiSum / float64(withRSI)
+			components = append(components, point.AvgRSI)
+		}
+		if extremes := point.NewHighs + point.NewLows; extremes > 0 {
+			components = append(components, 100*float64(point.NewHighs)/float64(extremes))
+		}
+		if len(components) > 0 {
+			var sum float64
+			for _, c := range components {
+				sum += c
+			}
+			point.Composite = sum / float64(len(components))
+		}
+		points = append(points, point)
+	}
+	return points
+}
@@ -0,0 +1,207 @@
+package stats
+
+import (
+	"math"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// EquityParams configures equity curve analytics. Window is the length of
+// the rolling Sharpe and Sortino windows, in points, and PeriodsPerYear
+// annualises them.
+type EquityParams struct {
+	Window         int
+	PeriodsPerYear float64
+}
+
+// UnderwaterPoint is the drawdown from the running peak, as a fraction of
+// the peak, at one point of the curve.
+type UnderwaterPoint struct {
+	Time     time.Time `json:"time"`
+	Drawdown float64   `json:"drawdown"`
+}
+
+// DrawdownPeriod runs from a peak to the point where equity first regains
+// it. Recovered is false, and End and RecoveryBars are empty, while the
+// curve is still below the peak. Bars count points from the start.
+type DrawdownPeriod struct {
+	Start        time.Time  `json:"start"`
+	Trough       time.Time  `json:"trough"`
+	End          *time.Time `json:"end,omitempty"`
+	Depth        float64    `json:"depth"`
+	TroughBars   int        `json:"trough_bars"`
+	RecoveryBars int        `json:"recovery_bars,omitempty"`
+	Recovered    bool       `json:"recovered"`
+}
+
+// RollingPoint is an annualised ratio over the window ending at Time.
+type RollingPoint struct {
+	Time    time.Time `json:"time"`
+	Sharpe  float64   `json:"sharpe"`
+	Sortino float64   `json:"sortino"`
+}
+
+// Streaks counts runs of consecutive winning and losing periods by length.
+// Flat periods end a run without starting one.
+type Streaks struct {
+	Wins    map[int]int `json:"wins"`
+	Losses  map[int]int `json:"losses"`
+	MaxWin  int         `json:"max_win"`
+	MaxLoss int         `json:"max_loss"`
+}
+
+// EquityStats is the analysis of an equity curve.
+type EquityStats struct {
+	Underwater      []UnderwaterPoint `json:"underwater"`
+	Drawdowns       []DrawdownPeriod  `json:"drawdowns"`
+	MaxDrawdown     float64           `json:"max_drawdown"`
+	AvgRecoveryBars float64           `json:"avg_recovery_bars"`
+	Rolling         []RollingPoint    `json:"rolling"`
+	Streaks         Streaks           `json:"streaks"`
+}
+
+// EquityFromReturns compounds period returns into an equity curve starting
+// at 1 one period before the first return.
+func EquityFromReturns(returns []models.ReturnPoint) []models.EquityPoint {
+	equity := make([]models.EquityPoint, 0, len(returns)+1)
+	value := 1.0
+	if len(returns) > 0 {
+		start := returns[0].Time
+		if len(returns) > 1 {
+			start = start.Add(-returns[1].Time.Sub(returns[0].Time))
+		}
+		equity = append(equity, models.EquityPoint{Time: start, Equity: value})
+	}
+	for _, r := range returns {
+		value *= 1 + r.Return
+		equity = append(equity, models.EquityPoint{Time: r.Time, Equity: value})
+	}
+	return equity
+}
+
+// Equity computes the underwater curve, drawdown periods, rolling Sharpe
+// and Sortino ratios and win/loss streaks of an equity curve.
+func Equity(equity []models.EquityPoint, params EquityParams) EquityStats {
+	result := EquityStats{
+		Underwater: make([]UnderwaterPoint, 0, len(equity)),
+		Drawdowns:  []DrawdownPeriod{},
+		Rolling:    []RollingPoint{},
+		Streaks:    Streaks{Wins: map[int]int{}, Losses: map[int]int{}},
+	}
+	if len(equity) == 0 {
+		return result
+	}
+
+	var current *DrawdownPeriod
+	peak, peakIndex, troughIndex := equity[0].Equity, 0, 0
+	var recoveries, recovered int
+	for i, point := range equity {
+		if point.Equity >= peak {
+			if current != nil {
+				end := point.Time
+				current.End, current.Recovered = &end, true
+				current.RecoveryBars = i - troughIndex
+				recoveries += current.RecoveryBars
+				recovered++
+				result.Drawdowns = append(result.Drawdowns, *current)
+				current = nil
+			}
+			peak, peakIndex = point.Equity, i
+		}
+
+		var dd float64
+		if peak > 0 {
+			dd = (peak - point.Equity) / peak
+		}
+		result.Underwater = append(result.Underwater, UnderwaterPoint{Time: point.Time, Drawdown: dd})
+		result.MaxDrawdown = math.Max(result.MaxDrawdown, dd)
+		if dd == 0 {
+			continue
+		}
+		if current == nil {
+			current = &DrawdownPeriod{Start: equity[peakIndex].Time}
+		}
+		if dd > current.Depth {
+			current.Depth, current.Trough, current.TroughBars = dd, point.Time, i-peakIndex
+			troughIndex = i
+		}
+	}
+	if current != nil {
+		result.Drawdowns = append(result.Drawdowns, *current)
+	}
+	if recovered > 0 {
+		result.AvgRecoveryBars = float64(recoveries) / float64(recovered)
+	}
+
+	returns := make([]float64, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity != 0 {
+			returns[i-1] = equity[i].Equity/equity[i-1].Equity - 1
+		}
+	}
+	if params.Window > 1 {
+		for end := params.Window; end <= len(returns); end++ {
+			sharpe, sortino := ratios(returns[end-params.Window:end], params.PeriodsPerYear)
+			result.Rolling = append(result.Rolling, RollingPoint{Time: equity[end].Time, Sharpe: sharpe, Sortino: sortino})
+		}
+	}
+
+	var run int
+	flush := func() {
+		switch {
+		case run > 0:
+			result.Streaks.Wins[run]++
+			result.Streaks.MaxWin = max(result.Streaks.MaxWin, run)
+		case run < 0:
+			result.Streaks.Losses[-run]++
+			result.Streaks.MaxLoss = max(result.Streaks.MaxLoss, -run)
+		}
+	}
+	for _, r := range returns {
+		switch {
+		case r > 0 && run >= 0:
+			run++
+		case r < 0 && run <= 0:
+			run--
+		default:
+			flush()
+			run = 0
+			if r > 0 {
+				run = 1
+			} else if r < 0 {
+				run = -1
+			}
+		}
+	}
+	flush()
+	return result
+}
+
+// ratios returns the annualised Sharpe and Sortino ratios of returns with a
+// zero risk-free rate. A ratio with no dispersion is zero.
+func ratios(returns []float64, periodsPerYear float64) (float64, float64) {
+	n := float64(len(returns))
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= n
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	scale := math.Sqrt(periodsPerYear)
+	var sharpe, sortino float64
+	if sd := math.Sqrt(variance / (n - 1)); sd > 0 {
+		sharpe = mean / sd * scale
+	}
+	if dd := math.Sqrt(downside / n); dd > 0 {
+		sortino = mean / dd * scale
+	}
+	return sharpe, sortino
+}
@@ -0,0 +1,143 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Regime labels.
+const (
+	Trending = "trending"
+	Ranging  = "ranging"
+	HighVol  = "high_vol"
+	LowVol   = "low_vol"
+)
+
+// RegimeParams configures regime classification. A bar is trending when
+// the efficiency ratio of the last Window closes (net move over the sum of
+// absolute moves) is at least TrendThreshold, and high volatility when its
+// average range over the window, relative to the close, is above the
+// series median.
+type RegimeParams struct {
+	Window         int
+	TrendThreshold float64
+}
+
+// Regime is the classification of one bar.
+type Regime struct {
+	Time  time.Time `json:"time"`
+	Trend string    `json:"trend"`
+	Vol   string    `json:"vol"`
+}
+
+// Trade is a closed trade to attribute to the regime at its entry.
+type Trade struct {
+	Symbol   string
+	OpenTime time.Time
+	Realized float64
+}
+
+// RegimePerformance summarises the trades entered in one regime.
+// ProfitFactor is zero when the regime has no losing trade.
+type RegimePerformance struct {
+	Trend        string  `json:"trend"`
+	Vol          string  `json:"vol"`
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	WinRate      float64 `json:"win_rate"`
+	TotalPnL     float64 `json:"total_pnl"`
+	AvgPnL       float64 `json:"avg_pnl"`
+	ProfitFactor float64 `json:"profit_factor"`
+}
+
+// Regimes classifies every bar with a full window; earlier bars are
+// skipped.
+func Regimes(candles []models.OHLC, params RegimeParams) []Regime {
+	w := params.Window
+	if w <= 0 || len(candles) <= w {
+		return []Regime{}
+	}
+
+	vols := make([]float64, 0, len(candles)-w)
+	for i := w; i < len(candles); i++ {
+		var ranges float64
+		for _, c := range candles[i-w+1 : i+1] {
+			ranges += c.High - c.Low
+		}
+		var vol float64
+		if candles[i].Close != 0 {
+			vol = ranges / float64(w) / candles[i].Close
+		}
+		vols = append(vols, vol)
+	}
+	sorted := append([]float64(nil), vols...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	regimes := make([]Regime, 0, len(vols))
+	for i := w; i < len(candles); i++ {
+		var path float64
+		for j := i - w + 1; j <= i; j++ {
+			path += math.Abs(candles[j].Close - candles[j-1].Close)
+		}
+		regime := Regime{Time: candles[i].Time, Trend: Ranging, Vol: LowVol}
+		if path > 0 && math.Abs(candles[i].Close-candles[i-w].Close)/path >= params.TrendThreshold {
+			regime.Trend = Trending
+		}
+		if vols[i-w] > median {
+			regime.Vol = HighVol
+		}
+		regimes = append(regimes, regime)
+	}
+	return regimes
+}
+
+// PerformanceByRegime attributes each trade to the regime of the last bar
+// of its symbol at or before its entry and summarises each regime. Trades
+// without a classified bar are left out.
+func PerformanceByRegime(trades []Trade, regimes map[string][]Regime) []RegimePerformance {
+	type key struct{ trend, vol string }
+	byRegime := make(map[key]*RegimePerformance)
+	profits, losses := make(map[key]float64), make(map[key]float64)
+	for _, trade := range trades {
+		series := regimes[trade.Symbol]
+		i := sort.Search(len(series), func(i int) bool { return series[i].Time.After(trade.OpenTime) }) - 1
+		if i < 0 {
+			continue
+		}
+		k := key{series[i].Trend, series[i].Vol}
+		perf, ok := byRegime[k]
+		if !ok {
+			perf = &RegimePerformance{Trend: k.trend, Vol: k.vol}
+			byRegime[k] = perf
+		}
+		perf.Trades++
+		perf.TotalPnL += trade.Realized
+		if trade.Realized > 0 {
+			perf.Wins++
+			profits[k] += trade.Realized
+		} else {
+			losses[k] -= trade.Realized
+		}
+	}
+
+	result := make([]RegimePerformance, 0, len(byRegime))
+	for k, perf := range byRegime {
+		perf.WinRate = float64(perf.Wins) / float64(perf.Trades)
+		perf.AvgPnL = perf.TotalPnL / float64(perf.Trades)
+		if losses[k] > 0 {
+			perf.ProfitFactor = profits[k] / losses[k]
+		}
+		result = append(result, *perf)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Trend != result[j].Trend {
+			return result[i].Trend < result[j].Trend
+		}
+		return result[i].Vol < result[j].Vol
+	})
+	return result
+}
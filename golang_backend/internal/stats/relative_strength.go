@@ -0,0 +1,158 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// RSParams configures relative strength ranking. Lookbacks are in bars of
+// the benchmark.
+type RSParams struct {
+	Lookbacks []int
+}
+
+// LookbackStrength is a symbol's performance against the benchmark over
+// one lookback. Ratio is the change of the symbol's price ratio to the
+// benchmark, (1 + Return) / (1 + BenchmarkReturn): above 1 the symbol
+// outperformed. Rank is 1 for the strongest symbol with enough history and
+// Percentile 100 for the strongest, 0 for the weakest.
+type LookbackStrength struct {
+	Bars            int     `json:"bars"`
+	Return          float64 `json:"return"`
+	BenchmarkReturn float64 `json:"benchmark_return"`
+	Ratio           float64 `json:"ratio"`
+	Rank            int     `json:"rank"`
+	Percentile      float64 `json:"percentile"`
+}
+
+// SymbolStrength is a symbol's relative strength. PriceRatio is its
+// latest close over the benchmark's. Score averages the percentiles of its
+// lookbacks and Rank orders symbols by Score; a symbol too short for every
+// lookback has neither.
+type SymbolStrength struct {
+	Symbol     string             `json:"symbol"`
+	Time       time.Time          `json:"time"`
+	PriceRatio float64            `json:"price_ratio"`
+	Lookbacks  []LookbackStrength `json:"lookbacks"`
+	Score      float64            `json:"score"`
+	Rank       int                `json:"rank,omitempty"`
+}
+
+// RelativeStrengthReport ranks a universe against its benchmark, strongest
+// first.
+type RelativeStrengthReport struct {
+	Benchmark string           `json:"benchmark"`
+	Symbols   []SymbolStrength `json:"symbols"`
+}
+
+// RelativeStrength ranks every symbol of universe but the benchmark by its
+// momentum relative to the benchmark, per lookback and overall. Each
+// symbol is compared on the bars it shares with the benchmark, up to its
+// latest one, so a symbol with gaps looks back over the benchmark's bars.
+// The benchmark must be in universe.
+func RelativeStrength(universe map[string][]models.OHLC, benchmark string, params RSParams) RelativeStrengthReport {
+	bench := universe[benchmark]
+	report := RelativeStrengthReport{Benchmark: benchmark, Symbols: []SymbolStrength{}}
+	for symbol, candles := range universe {
+		if symbol == benchmark {
+			continue
+		}
+		closes := make(map[int64]float64, len(candles))
+		for _, candle := range candles {
+			closes[candle.Time.UnixNano()] = candle.Close
+		}
+		// The symbol's and the benchmark's closes on the shared bars,
+		// oldest first.
+		var symbolCloses, benchCloses []float64
+		var latest time.Time
+		for _, candle := range bench {
+			price, ok := closes[candle.Time.UnixNano()]
+			if !ok || price == 0 || candle.Close == 0 {
+				continue
+			}
+			symbolCloses = append(symbolCloses, price)
+			benchCloses = append(benchCloses, candle.Close)
+			latest = candle.Time
+		}
+		if len(symbolCloses) == 0 {
+			continue
+		}
+
+		last := len(symbolCloses) - 1
+		strength := SymbolStrength{
+			Symbol:     symbol,
+			Time:       latest,
+			PriceRatio: symbolCloses[last] / benchCloses[last],
+			Lookbacks:  []LookbackStrength{},
+		}
+		seen := make(map[int]bool, len(params.Lookbacks))
+		for _, bars := range params.Lookbacks {
+			if bars > last || seen[bars] {
+				continue
+			}
+			seen[bars] = true
+			symbolReturn := symbolCloses[last]/symbolCloses[last-bars] - 1
+			benchReturn := benchCloses[last]/benchCloses[last-bars] - 1
+			strength.Lookbacks = append(strength.Lookbacks, LookbackStrength{
+				Bars:            bars,
+				Return:          symbolReturn,
+				BenchmarkReturn: benchReturn,
+				Ratio:           (1 + symbolReturn) / (1 + benchReturn),
+			})
+		}
+		report.Symbols = append(report.Symbols, strength)
+	}
+
+	// Rank each lookback among the symbols long enough for it.
+	type entry struct {
+		symbol   string
+		lookback *LookbackStrength
+	}
+	byBars := make(map[int][]entry)
+	for i := range report.Symbols {
+		s := &report.Symbols[i]
+		for j := range s.Lookbacks {
+			byBars[s.Lookbacks[j].Bars] = append(byBars[s.Lookbacks[j].Bars], entry{s.Symbol, &s.Lookbacks[j]})
+		}
+	}
+	for _, entries := range byBars {
+		sort.Slice(entries, func(a, b int) bool {
+			if entries[a].lookback.Ratio != entries[b].lookback.Ratio {
+				return entries[a].lookback.Ratio > entries[b].lookback.Ratio
+			}
+			return entries[a].symbol < entries[b].symbol
+		})
+		for rank, e := range entries {
+			e.lookback.Rank = rank + 1
+			e.lookback.Percentile = 100
+			if len(entries) > 1 {
+				e.lookback.Percentile = 100 * float64(len(entries)-1-rank) / float64(len(entries)-1)
+			}
+		}
+	}
+
+	for i := range report.Symbols {
+		s := &report.Symbols[i]
+		for _, l := range s.Lookbacks {
+			s.Score += l.Percentile / float64(len(s.Lookbacks))
+		}
+	}
+	sort.Slice(report.Symbols, func(a, b int) bool {
+		sa, sb := report.Symbols[a], report.Symbols[b]
+		if (len(sa.Lookbacks) > 0) != (len(sb.Lookbacks) > 0) {
+			return len(sa.Lookbacks) > 0
+		}
+		if sa.Score != sb.Score {
+			return sa.Score > sb.Score
+		}
+		return sa.Symbol < sb.Symbol
+	})
+	for i := range report.Symbols {
+		if len(report.Symbols[i].Lookbacks) > 0 {
+			report.Symbols[i].Rank = i + 1
+		}
+	}
+	return report
+}
@@ -0,0 +1,111 @@
+package tuning
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/models"
+	"github.com/abs/go_billing/utils"
+)
+
+// Settings are the runtime knobs that can be changed without a restart.
+// Detection defaults apply to requests that leave the parameter zero;
+// exposure limits feed the pre-trade checks.
+type Settings struct {
+	OutputPrecision   int     `json:"output_precision"`
+	SwingStrength     int     `json:"swing_strength"`
+	MaxBase           int     `json:"max_base"`
+	LegRange          float64 `json:"leg_range"`
+	ConfirmCloses     int     `json:"confirm_closes"`
+	MaxSymbolExposure float64 `json:"max_symbol_exposure"`
+	MaxTotalExposure  float64 `json:"max_total_exposure"`
+	MaxGroupExposure  float64 `json:"max_group_exposure"`
+}
+
+// Change is an audit log entry for one setting.
+type Change struct {
+	Time    time.Time `json:"time"`
+	Actor   string    `json:"actor"`
+	Setting string    `json:"setting"`
+	Old     any       `json:"old"`
+	New     any       `json:"new"`
+}
+
+// Store holds the current settings and the audit log of every change.
+type Store struct {
+	mu       sync.RWMutex
+	settings Settings
+	audit    []Change
+	clock    utils.Clock
+}
+
+// NewStore creates a store with the initial settings. Changes are stamped
+// with clock.
+func NewStore(settings Settings, clock utils.Clock) *Store {
+	return &Store{settings: settings, audit: []Change{}, clock: clock}
+}
+
+// Restore replaces the settings and the audit log without recording a
+// change.
+func (s *Store) Restore(settings Settings, audit []Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings = settings
+	s.audit = append([]Change{}, audit...)
+}
+
+// Get returns the current settings.
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings
+}
+
+// Update applies the set fields of a patch on behalf of actor, records and
+// logs each setting whose value changed, and returns the new settings.
+func (s *Store) Update(actor string, patch models.SettingsPatch) Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now().UTC()
+	record := func(name string, old, value any) {
+		if old == value {
+			return
+		}
+		s.audit = append(s.audit, Change{Time: now, Actor: actor, Setting: name, Old: old, New: value})
+		log.Printf("admin: %s changed %s from %v to %v", actor, name, old, value)
+	}
+	setInt := func(name string, field *int, value *int) {
+		if value != nil {
+			record(name, *field, *value)
+			*field = *value
+		}
+	}
+	setFloat := func(name string, field *float64, value *float64) {
+		if value != nil {
+			record(name, *field, *value)
+			*field = *value
+		}
+	}
+
+	setInt("output_precision", &s.settings.OutputPrecision, patch.OutputPrecision)
+	setInt("swing_strength", &s.settings.SwingStrength, patch.SwingStrength)
+	setInt("max_base", &s.settings.MaxBase, patch.MaxBase)
+	setFloat("leg_range", &s.settings.LegRange, patch.LegRange)
+	setInt("confirm_closes", &s.settings.ConfirmCloses, patch.ConfirmCloses)
+	setFloat("max_symbol_exposure", &s.settings.MaxSymbolExposure, patch.MaxSymbolExposure)
+	setFloat("max_total_exposure", &s.settings.MaxTotalExposure, patch.MaxTotalExposure)
+	setFloat("max_group_exposure", &s.settings.MaxGroupExposure, patch.MaxGroupExposure)
+	return s.settings
+}
+
+// Audit returns every recorded change, oldest first.
+func (s *Store) Audit() []Change {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Change{}, s.audit...)
+}
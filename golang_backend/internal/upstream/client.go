@@ -0,0 +1,251 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abs/go_billing/utils"
+)
+
+// Breaker states.
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned without calling the upstream while its
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// Policy configures retries and the circuit breaker. Retries back off
+// exponentially from BaseDelay with full jitter, capped at MaxDelay, unless
+// the upstream asks for longer with Retry-After (up to MaxRetryAfter).
+// After FailureThreshold consecutive failed calls the breaker opens for
+// Cooldown, then lets a single trial call through.
+type Policy struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	MaxRetryAfter    time.Duration
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// DefaultPolicy suits REST data providers.
+var DefaultPolicy = Policy{
+	Timeout:          10 * time.Second,
+	MaxRetries:       3,
+	BaseDelay:        200 * time.Millisecond,
+	MaxDelay:         5 * time.Second,
+	MaxRetryAfter:    30 * time.Second,
+	FailureThreshold: 5,
+	Cooldown:         30 * time.Second,
+}
+
+// Health is the state of an upstream as seen by its client.
+type Health struct {
+	Name                string     `json:"name"`
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// Client is an HTTP client for one upstream with retries, rate-limit
+// awareness and a circuit breaker. It is safe for concurrent use.
+type Client struct {
+	name   string
+	policy Policy
+	http   *http.Client
+	clock  utils.Clock
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	lastError   string
+	lastSuccess time.Time
+	openUntil   time.Time
+	trial       bool
+}
+
+// NewClient creates a client for the named upstream. Backoff waits and the
+// breaker cooldown run on clock.
+func NewClient(name string, policy Policy, clock utils.Clock) *Client {
+	return &Client{
+		name:   name,
+		policy: policy,
+		http:   &http.Client{Timeout: policy.Timeout},
+		clock:  clock,
+		state:  Closed,
+	}
+}
+
+// CheckRedirect sets the redirect policy of the underlying http.Client. It
+// must be called before the client is used.
+func (c *Client) CheckRedirect(check func(req *http.Request, via []*http.Request) error) {
+	c.http.CheckRedirect = check
+}
+
+// Do sends req, retrying network errors, 429 and 5xx responses. Requests
+// with a body must set GetBody so they can be replayed. A response is
+// returned for every other status, including other errors, and counts as a
+// success for the breaker.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := c.acquire(); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					c.record(err)
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := c.http.Do(attemptReq)
+		var wait time.Duration
+		switch {
+		case err != nil && req.Context().Err() != nil:
+			// The caller gave up; that says nothing about the upstream.
+			c.abandon()
+			return nil, err
+		case err != nil:
+			lastErr = err
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("%s returned %s", c.name, resp.Status)
+			wait = retryAfter(resp.Header.Get("Retry-After"), c.clock.Now())
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			c.record(nil)
+			return resp, nil
+		}
+
+		if attempt >= c.policy.MaxRetries || req.Body != nil && req.GetBody == nil {
+			break
+		}
+		if wait <= 0 {
+			wait = c.backoff(attempt)
+		}
+		if wait > c.policy.MaxRetryAfter {
+			break
+		}
+		select {
+		case <-req.Context().Done():
+			c.abandon()
+			return nil, req.Context().Err()
+		case <-c.clock.After(wait):
+		}
+	}
+	c.record(lastErr)
+	return nil, lastErr
+}
+
+// Health returns the current state of the upstream.
+func (c *Client) Health() Health {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	health := Health{
+		Name:                c.name,
+		State:               c.state,
+		ConsecutiveFailures: c.failures,
+		LastError:           c.lastError,
+	}
+	if !c.lastSuccess.IsZero() {
+		t := c.lastSuccess
+		health.LastSuccess = &t
+	}
+	if c.state == Open {
+		t := c.openUntil
+		health.OpenUntil = &t
+	}
+	return health
+}
+
+// acquire lets a call through unless the breaker is open. Once the
+// cooldown has passed, one trial call is let through while the others are
+// still rejected.
+func (c *Client) acquire() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case Open:
+		if c.clock.Now().Before(c.openUntil) {
+			return fmt.Errorf("%s: %w until %s", c.name, ErrCircuitOpen, c.openUntil.Format(time.RFC3339))
+		}
+		c.state, c.trial = HalfOpen, true
+	case HalfOpen:
+		if c.trial {
+			return fmt.Errorf("%s: %w, trial call in flight", c.name, ErrCircuitOpen)
+		}
+		c.trial = true
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a call.
+func (c *Client) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trial = false
+	if err == nil {
+		c.state, c.failures, c.lastSuccess = Closed, 0, c.clock.Now()
+		return
+	}
+	c.failures++
+	c.lastError = err.Error()
+	if c.state == HalfOpen || c.failures >= c.policy.FailureThreshold {
+		c.state, c.openUntil = Open, c.clock.Now().Add(c.policy.Cooldown)
+	}
+}
+
+// abandon releases a trial call without counting its outcome.
+func (c *Client) abandon() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.trial = false
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := min(c.policy.BaseDelay<<attempt, c.policy.MaxDelay)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP
+// date relative to now, returning 0 when it is absent or invalid.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
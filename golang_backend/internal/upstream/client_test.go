@@ -0,0 +1,105 @@
+package upstream
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abs/go_billing/utils"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// waitForTimer blocks until the client waits on the clock.
+func waitForTimer(t *testing.T, clock *utils.SimulatedClock) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); clock.Timers() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the client never waited on the clock")
+		}
+	}
+}
+
+func TestRetryWaitsForRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	clock := utils.NewSimulatedClock(start)
+	client := NewClient("test", Policy{Timeout: 5 * time.Second, MaxRetries: 1, MaxRetryAfter: time.Minute, FailureThreshold: 1, Cooldown: time.Minute}, clock)
+	done := make(chan error, 1)
+	go func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+
+	waitForTimer(t, clock)
+	clock.Advance(time.Second)
+	if clock.Timers() != 1 || hits.Load() != 1 {
+		t.Fatalf("retried after 1s of a 2s Retry-After: %d calls", hits.Load())
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if hits.Load() != 2 {
+		t.Fatalf("got %d calls, want 2", hits.Load())
+	}
+	if health := client.Health(); health.State != Closed || !health.LastSuccess.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("got %+v, want closed with a success at 2s", health)
+	}
+}
+
+func TestBreakerCooldown(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	clock := utils.NewSimulatedClock(start)
+	client := NewClient("test", Policy{Timeout: 5 * time.Second, FailureThreshold: 2, Cooldown: 30 * time.Second}, clock)
+	call := func() error {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		_, err := client.Do(req)
+		return err
+	}
+
+	call()
+	if state := client.Health().State; state != Closed {
+		t.Fatalf("open after one failure: %s", state)
+	}
+	call()
+	health := client.Health()
+	if health.State != Open || !health.OpenUntil.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("got %+v, want open for 30s", health)
+	}
+
+	clock.Advance(29 * time.Second)
+	if err := call(); !errors.Is(err, ErrCircuitOpen) || hits.Load() != 2 {
+		t.Fatalf("called through the open breaker: %v, %d calls", err, hits.Load())
+	}
+
+	// The trial call after the cooldown fails and reopens the breaker.
+	clock.Advance(time.Second)
+	if err := call(); errors.Is(err, ErrCircuitOpen) || hits.Load() != 3 {
+		t.Fatalf("no trial call after the cooldown: %v, %d calls", err, hits.Load())
+	}
+	health = client.Health()
+	if health.State != Open || !health.OpenUntil.Equal(start.Add(60*time.Second)) {
+		t.Fatalf("got %+v, want reopened until 60s", health)
+	}
+}
@@ -1 +1,29 @@
-package main
\ No newline at end of file
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	api "github.com/abs/go_billing/cmd"
+	"github.com/abs/go_billing/utils"
+)
+
+func main() {
+	config := utils.LoadConfig()
+
+	server, err := api.NewServer(config, utils.SystemClock)
+	if err != nil {
+		log.Fatal("cannot create server: ", err)
+	}
+
+	// SIGTERM drains the server and saves its state before exiting.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err = server.Start(ctx, config.HTTPServerAddress)
+	if err != nil {
+		log.Fatal("cannot start server: ", err)
+	}
+}
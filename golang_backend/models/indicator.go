@@ -0,0 +1,166 @@
+package models
+
+import "time"
+
+// IndicatorResponse holds indicator series aligned with the request's
+// candles: index i of every series is candle i, and values inside an
+// indicator's warm-up period are 0.
+type IndicatorResponse struct {
+	EMA50      []float64      `json:"ema_50"`
+	EMA200     []float64      `json:"ema_200"`
+	RSI        []float64      `json:"rsi"`
+	Bollinger  BollingerBands `json:"bollinger"`
+	Keltner    Keltner        `json:"keltner"`
+	Donchian   Donchian       `json:"donchian"`
+	Stochastic Stochastic     `json:"stochastic"`
+	WilliamsR  []float64      `json:"williams_r"`
+	ElderRay   ElderRay       `json:"elder_ray"`
+	Aroon      Aroon          `json:"aroon"`
+	Fisher     Fisher         `json:"fisher"`
+	// InverseFisherRSI is in [-1, 1], so it can feed an ensemble signal as
+	// a score component as is.
+	InverseFisherRSI []float64  `json:"inverse_fisher_rsi"`
+	ATR              []float64  `json:"atr"`
+	SuperTrend       SuperTrend `json:"supertrend"`
+	ADX              ADX        `json:"adx"`
+	Ichimoku         Ichimoku   `json:"ichimoku"`
+	Volume           Volume     `json:"volume"`
+	MFI              []float64  `json:"mfi"`
+	CCI              []float64  `json:"cci"`
+	TRIX             []float64  `json:"trix"`
+	Coppock          []float64  `json:"coppock"`
+	KST              KST        `json:"kst"`
+	STC              []float64  `json:"stc"`
+	QQE              QQE        `json:"qqe"`
+	// MovingAverages holds the requested moving averages keyed by type and
+	// period, as in "hma_21".
+	MovingAverages map[string][]float64 `json:"moving_averages"`
+	// WMA and VWMA hold a series per requested period.
+	WMA  map[int][]float64 `json:"wma"`
+	VWMA map[int][]float64 `json:"vwma"`
+	VWAP VWAP              `json:"vwap"`
+}
+
+// BollingerBands are the bands around a simple moving average. Bandwidth
+// is (upper - lower) / middle; its lows mark squeezes.
+type BollingerBands struct {
+	Upper     []float64 `json:"upper"`
+	Middle    []float64 `json:"middle"`
+	Lower     []float64 `json:"lower"`
+	Bandwidth []float64 `json:"bandwidth"`
+}
+
+// Keltner is Keltner Channels, ATR bands around an EMA. Squeeze marks the
+// candles whose Bollinger Bands lie inside the channels: volatility has
+// contracted and a breakout often follows when it ends.
+type Keltner struct {
+	Upper   []float64 `json:"upper"`
+	Middle  []float64 `json:"middle"`
+	Lower   []float64 `json:"lower"`
+	Squeeze []bool    `json:"squeeze"`
+}
+
+// Donchian is Donchian Channels: the highest high and lowest low of the
+// lookback and the line halfway between them.
+type Donchian struct {
+	Upper  []float64 `json:"upper"`
+	Middle []float64 `json:"middle"`
+	Lower  []float64 `json:"lower"`
+}
+
+// Stochastic holds the fast stochastic, unsmoothed %K, and the slow one,
+// whose %K is the fast %K smoothed.
+type Stochastic struct {
+	Fast StochasticLines `json:"fast"`
+	Slow StochasticLines `json:"slow"`
+}
+
+// StochasticLines are the %K and %D lines of a stochastic, from 0 to 100.
+type StochasticLines struct {
+	K []float64 `json:"k"`
+	D []float64 `json:"d"`
+}
+
+// ElderRay is Elder's bull and bear power around an EMA. Bear power
+// rising while below zero in an uptrend of the EMA is a buy signal, and
+// bull power falling while above zero in a downtrend a sell signal.
+type ElderRay struct {
+	BullPower []float64 `json:"bull_power"`
+	BearPower []float64 `json:"bear_power"`
+}
+
+// Aroon holds Aroon Up and Down, from 0 to 100, and the Aroon
+// Oscillator, their difference from -100 to 100.
+type Aroon struct {
+	Up         []float64 `json:"up"`
+	Down       []float64 `json:"down"`
+	Oscillator []float64 `json:"oscillator"`
+}
+
+// Fisher is the Fisher transform and its trigger line. The transform
+// crossing its trigger after an extreme reading marks a turn.
+type Fisher struct {
+	Fisher  []float64 `json:"fisher"`
+	Trigger []float64 `json:"trigger"`
+}
+
+// SuperTrend is the SuperTrend trailing line and its direction, 1 up and
+// -1 down; a change of direction is a flip of the trailing stop.
+type SuperTrend struct {
+	Line      []float64 `json:"line"`
+	Direction []int     `json:"direction"`
+}
+
+// ADX holds the average directional index, the strength of the trend
+// whichever its direction, and the +DI and -DI lines whose spread it
+// smooths. An ADX under about 20 marks a ranging market.
+type ADX struct {
+	ADX     []float64 `json:"adx"`
+	PlusDI  []float64 `json:"plus_di"`
+	MinusDI []float64 `json:"minus_di"`
+}
+
+// Ichimoku is the Ichimoku cloud. Unlike the other series, the Senkou
+// spans are plotted ahead of price and run past the last candle: index i
+// is candle i up to the last candle, and the following entries are the
+// bars whose times are in Projection. Chikou is plotted behind price, so
+// its last entries, for which no close exists yet, are 0.
+type Ichimoku struct {
+	Tenkan     []float64   `json:"tenkan"`
+	Kijun      []float64   `json:"kijun"`
+	SenkouA    []float64   `json:"senkou_a"`
+	SenkouB    []float64   `json:"senkou_b"`
+	Chikou     []float64   `json:"chikou"`
+	Projection []time.Time `json:"projection"`
+}
+
+// Volume holds the on-balance volume and the simple and exponential
+// averages of volume. A breakout on volume above its average is
+// confirmed; OBV making new highs with price confirms an uptrend.
+type Volume struct {
+	OBV []float64 `json:"obv"`
+	SMA []float64 `json:"sma"`
+	EMA []float64 `json:"ema"`
+}
+
+// KST is the Know Sure Thing and its signal line. Crossing the signal
+// line, or zero, signals a change of the long-term momentum.
+type KST struct {
+	KST    []float64 `json:"kst"`
+	Signal []float64 `json:"signal"`
+}
+
+// QQE holds the smoothed RSI of the QQE, its trailing line and the trend,
+// 1 up and -1 down, which flips when the RSI crosses the trailing line.
+type QQE struct {
+	Line     []float64 `json:"line"`
+	Trailing []float64 `json:"trailing"`
+	Trend    []int     `json:"trend"`
+}
+
+// VWAP holds the session-anchored VWAP, which restarts every session, and
+// the rolling VWAP over a fixed number of candles.
+type VWAP struct {
+	Session []float64 `json:"session"`
+	Rolling []float64 `json:"rolling"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "math"
+
+// Contract types.
+const (
+	Linear  = "linear"
+	Inverse = "inverse"
+	Quanto  = "quanto"
+)
+
+// Instrument describes how a symbol's PnL is computed and settled.
+//
+// Linear contracts pay (exit - entry) * quantity * multiplier in the quote
+// currency. Inverse contracts (BitMEX-style XBTUSD) have a fixed quote value
+// of Multiplier per contract and pay multiplier * quantity * (1/entry -
+// 1/exit) in the base currency. Quanto contracts pay like linear ones but
+// with Multiplier expressed in SettlementCurrency per price point.
+//
+// TickSize is the minimum price increment; when set, responses for the
+// symbol are rounded to its decimals.
+type Instrument struct {
+	Symbol             string  `json:"symbol" binding:"required"`
+	Type               string  `json:"type" binding:"required,oneof=linear inverse quanto"`
+	Multiplier         float64 `json:"multiplier" binding:"gte=0"`
+	SettlementCurrency string  `json:"settlement_currency"`
+	TickSize           float64 `json:"tick_size" binding:"gte=0"`
+}
+
+// LinearInstrument returns the default instrument for unknown symbols.
+func LinearInstrument(symbol string) Instrument {
+	return Instrument{Symbol: symbol, Type: Linear, Multiplier: 1}
+}
+
+func (instrument Instrument) multiplier() float64 {
+	if instrument.Multiplier == 0 {
+		return 1
+	}
+	return instrument.Multiplier
+}
+
+// PnL returns the profit of holding quantity contracts (negative for
+// shorts) from entry to exit, in the settlement currency.
+func (instrument Instrument) PnL(entry, exit, quantity float64) float64 {
+	if instrument.Type == Inverse {
+		return instrument.multiplier() * quantity * (1/entry - 1/exit)
+	}
+	return (exit - entry) * quantity * instrument.multiplier()
+}
+
+// AveragePrice returns the entry price of two lots merged into one. Inverse
+// contracts average harmonically so that PnL is preserved.
+func (instrument Instrument) AveragePrice(price1, quantity1, price2, quantity2 float64) float64 {
+	if instrument.Type == Inverse {
+		return (quantity1 + quantity2) / (quantity1/price1 + quantity2/price2)
+	}
+	return (price1*quantity1 + price2*quantity2) / (quantity1 + quantity2)
+}
+
+// Value returns the value of quantity contracts at price in the settlement
+// currency: the notional of linear and quanto contracts, and the base
+// currency amount their fixed quote value buys for inverse ones.
+func (instrument Instrument) Value(price, quantity float64) float64 {
+	if instrument.Type == Inverse {
+		return instrument.multiplier() * math.Abs(quantity) / price
+	}
+	return price * math.Abs(quantity) * instrument.multiplier()
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Order sides.
+const (
+	Buy  = "buy"
+	Sell = "sell"
+)
+
+// Order is an order about to be routed to a paper or live venue.
+// Group names the correlation bucket of the symbol (e.g. "usd-majors") and
+// Currency the currency the price is quoted in.
+type Order struct {
+	Strategy string  `json:"strategy"`
+	Symbol   string  `json:"symbol" binding:"required"`
+	Side     string  `json:"side" binding:"required,oneof=buy sell"`
+	Quantity float64 `json:"quantity" binding:"gt=0"`
+	Price    float64 `json:"price" binding:"gt=0"`
+	Group    string  `json:"group"`
+	Leverage float64 `json:"leverage" binding:"gte=0"`
+	Currency string  `json:"currency"`
+}
+
+// SignedQuantity returns the quantity, negative for sells.
+func (order Order) SignedQuantity() float64 {
+	if order.Side == Sell {
+		return -order.Quantity
+	}
+	return order.Quantity
+}
+
+// Position is an open position. Quantity is negative for shorts.
+type Position struct {
+	Symbol   string  `json:"symbol" binding:"required"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Group    string  `json:"group"`
+	Currency string  `json:"currency"`
+}
+
+// Fill is an execution reported by the paper or live venue. Fee is the total
+// fee paid for the fill in Currency, the currency the price is quoted in.
+type Fill struct {
+	ID       string    `json:"id"`
+	Symbol   string    `json:"symbol" binding:"required"`
+	Side     string    `json:"side" binding:"required,oneof=buy sell"`
+	Quantity float64   `json:"quantity" binding:"gt=0"`
+	Price    float64   `json:"price" binding:"gt=0"`
+	Fee      float64   `json:"fee" binding:"gte=0"`
+	Currency string    `json:"currency"`
+	Time     time.Time `json:"time"`
+}
+
+// SignedQuantity returns the quantity, negative for sells.
+func (fill Fill) SignedQuantity() float64 {
+	if fill.Side == Sell {
+		return -fill.Quantity
+	}
+	return fill.Quantity
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Pivots are the pivot levels of the period from Start to End, computed
+// from Prior, the OHLC of the period before it stamped with its start.
+// Zones holds every level as a zero-height zone, to be merged with other
+// support and resistance zones.
+type Pivots struct {
+	Period string     `json:"period"`
+	Start  time.Time  `json:"start"`
+	End    time.Time  `json:"end"`
+	Prior  OHLC       `json:"prior"`
+	Sets   []PivotSet `json:"sets"`
+	Zones  []Zone     `json:"zones"`
+}
+
+// PivotSet is the levels of one pivot method: the pivot and its
+// resistances r1, r2... above and supports s1, s2... below.
+type PivotSet struct {
+	Method string       `json:"method"`
+	Levels []PivotLevel `json:"levels"`
+}
+
+// PivotLevel is a named pivot level.
+type PivotLevel struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
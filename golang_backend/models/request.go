@@ -0,0 +1,720 @@
+package models
+
+import "time"
+
+// OHLC is a single candle as sent by clients.
+type OHLC struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+// ChartLabel is a text marker placed at a candle, e.g. a swing label.
+type ChartLabel struct {
+	Index int     `json:"index"`
+	Price float64 `json:"price"`
+	Text  string  `json:"text"`
+}
+
+// ChartRequest is the body of POST /render/chart and POST
+// /render/annotations, which ignores Width and Height. Analyses are run
+// on the candles by /render/annotations, with the current detector
+// settings, and drawn along with the zones and labels: "smc", "patterns"
+// for candlestick patterns, "chart_patterns" and "trendlines".
+type ChartRequest struct {
+	Candles  []OHLC       `json:"candles" binding:"required,min=1"`
+	Zones    []Zone       `json:"zones"`
+	Labels   []ChartLabel `json:"labels"`
+	Analyses []string     `json:"analyses" binding:"dive,oneof=smc patterns chart_patterns trendlines"`
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+}
+
+// EquityUpdateRequest is the body of POST /risk/equity.
+type EquityUpdateRequest struct {
+	Strategy string  `json:"strategy" binding:"required"`
+	Equity   float64 `json:"equity" binding:"gte=0"`
+}
+
+// EquityPoint is the account equity at a point in time.
+type EquityPoint struct {
+	Time   time.Time `json:"time" binding:"required"`
+	Equity float64   `json:"equity"`
+}
+
+// ReturnPoint is the return of the period ending at Time (0.01 = 1%).
+type ReturnPoint struct {
+	Time   time.Time `json:"time" binding:"required"`
+	Return float64   `json:"return"`
+}
+
+// EquityStatsRequest is the body of POST /stats/equity: either an equity
+// curve or a returns series. Window defaults to 30 points and
+// PeriodsPerYear to 252.
+type EquityStatsRequest struct {
+	Equity         []EquityPoint `json:"equity" binding:"required_without=Returns,dive"`
+	Returns        []ReturnPoint `json:"returns" binding:"dive"`
+	Window         int           `json:"window" binding:"gte=0"`
+	PeriodsPerYear float64       `json:"periods_per_year" binding:"gte=0"`
+}
+
+// RegimeStatsRequest is the body of POST /stats/regimes: candles per
+// symbol to classify the regimes of the ledger's closed trades. Window
+// defaults to 20 bars and TrendThreshold to 0.3.
+type RegimeStatsRequest struct {
+	Candles        map[string][]OHLC `json:"candles" binding:"required,min=1"`
+	Window         int               `json:"window" binding:"gte=0"`
+	TrendThreshold float64           `json:"trend_threshold" binding:"gte=0,lte=1"`
+}
+
+// PropFirmRequest is the body of POST /risk/propfirm: an equity curve from
+// a backtest or paper run and the challenge rules. Limits and the target
+// are fractions of InitialBalance (0.05 = 5%); zero disables a rule.
+// Trading days, and the daily loss reference balance, use Timezone (UTC
+// when empty).
+type PropFirmRequest struct {
+	Equity         []EquityPoint `json:"equity" binding:"required,min=1,dive"`
+	InitialBalance float64       `json:"initial_balance" binding:"gt=0"`
+	DailyLossLimit float64       `json:"daily_loss_limit" binding:"gte=0,lt=1"`
+	MaxDrawdown    float64       `json:"max_drawdown" binding:"gte=0,lt=1"`
+	Trailing       bool          `json:"trailing"`
+	ProfitTarget   float64       `json:"profit_target" binding:"gte=0"`
+	MinTradingDays int           `json:"min_trading_days" binding:"gte=0"`
+	Timezone       string        `json:"timezone"`
+}
+
+// PositionSizing holds the settings of POST /risk/position-size and
+// /risk/position-size/batch. Positions are sized so that each one's
+// typical move over a bar is Risk times Equity: ATRMultiple ATRs over
+// ATRPeriod bars in atr mode, or in beta mode its beta to the Benchmark
+// candles times their standard deviation of returns, over BetaPeriod bars.
+// Zero values use ATR 14, one ATR and 63 bars.
+type PositionSizing struct {
+	Mode        string  `json:"mode" binding:"required,oneof=atr beta"`
+	Equity      float64 `json:"equity" binding:"gt=0"`
+	Risk        float64 `json:"risk" binding:"gt=0,lt=1"`
+	ATRPeriod   int     `json:"atr_period" binding:"gte=0"`
+	ATRMultiple float64 `json:"atr_multiple" binding:"gte=0"`
+	BetaPeriod  int     `json:"beta_period" binding:"gte=0"`
+	Benchmark   []OHLC  `json:"benchmark" binding:"required_if=Mode beta"`
+}
+
+// SizingInstrument is a symbol to size. Price, ATR and Beta, when zero,
+// are computed from Candles.
+type SizingInstrument struct {
+	Symbol  string  `json:"symbol" binding:"required"`
+	Price   float64 `json:"price" binding:"gte=0"`
+	ATR     float64 `json:"atr" binding:"gte=0"`
+	Beta    float64 `json:"beta"`
+	Candles []OHLC  `json:"candles"`
+}
+
+// PositionSizeRequest is the body of POST /risk/position-size.
+type PositionSizeRequest struct {
+	PositionSizing
+	Instrument SizingInstrument `json:"instrument" binding:"required"`
+}
+
+// PositionSizeBatchRequest is the body of POST /risk/position-size/batch,
+// sizing a whole watchlist with the same settings.
+type PositionSizeBatchRequest struct {
+	PositionSizing
+	Instruments []SizingInstrument `json:"instruments" binding:"required,min=1,max=500,dive"`
+}
+
+// KillSwitchRequest is the body of POST /risk/killswitch. An empty strategy
+// applies the action to the whole account.
+type KillSwitchRequest struct {
+	Action   string `json:"action" binding:"required,oneof=trip rearm"`
+	Strategy string `json:"strategy"`
+	Reason   string `json:"reason"`
+}
+
+// PreTradeRequest is the body of POST /risk/pretrade. Commit is set when
+// the order is sent on acceptance, so that an entry counts towards the
+// strategy's max_trades_per_day; other checks leave the count alone.
+type PreTradeRequest struct {
+	Order           Order      `json:"order" binding:"required"`
+	Positions       []Position `json:"positions" binding:"dive"`
+	AvailableMargin float64    `json:"available_margin"`
+	Commit          bool       `json:"commit"`
+}
+
+// TradingConstraints are the intraday rules of a strategy, the body of
+// PUT /risk/constraints/:strategy. Times are "15:04" in Timezone (UTC when
+// empty). Entries are only allowed in [EntryStart, EntryEnd); a window
+// whose end is before its start wraps past midnight. From FlatBy until the
+// end of the day orders may only reduce positions. Zero values disable a
+// rule.
+type TradingConstraints struct {
+	MaxTradesPerDay int    `json:"max_trades_per_day" binding:"gte=0"`
+	EntryStart      string `json:"entry_start"`
+	EntryEnd        string `json:"entry_end"`
+	FlatBy          string `json:"flat_by"`
+	Timezone        string `json:"timezone"`
+}
+
+// ConstraintReplayRequest is the body of POST /risk/constraints/replay: a
+// backtest's fills and the constraints to run them through.
+type ConstraintReplayRequest struct {
+	Constraints TradingConstraints `json:"constraints"`
+	Fills       []Fill             `json:"fills" binding:"required,min=1,dive"`
+}
+
+// AllocationStrategyRequest is the body of POST /allocations/strategies.
+type AllocationStrategyRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Weight      float64 `json:"weight" binding:"gte=0"`
+	Volatility  float64 `json:"volatility" binding:"gte=0"`
+	Performance float64 `json:"performance"`
+}
+
+// RebalanceRequest is the body of POST /allocations/rebalance. A zero equity
+// uses the account equity tracked by the risk supervisor.
+type RebalanceRequest struct {
+	Equity float64 `json:"equity" binding:"gte=0"`
+	Method string  `json:"method" binding:"omitempty,oneof=fixed volatility performance"`
+}
+
+// FillsRequest is the body of POST /pnl/fills.
+type FillsRequest struct {
+	Fills []Fill `json:"fills" binding:"required,min=1,dive"`
+}
+
+// MarksRequest is the body of POST /pnl/marks: mark price per symbol.
+// Marks must be positive, as inverse instruments divide by them.
+type MarksRequest struct {
+	Marks map[string]float64 `json:"marks" binding:"required,dive,keys,required,endkeys,gt=0"`
+}
+
+// PnLExportRequest holds the query of GET /pnl/export.
+type PnLExportRequest struct {
+	Type string `form:"type" binding:"required,oneof=fills lots"`
+}
+
+// SignalExportRequest holds the query of GET /signals/export. From and To
+// are RFC 3339 times bounding the export; Format is csv (the default) or
+// json. Parquet is not offered: it would add a columnar encoding
+// dependency for files that pandas and Spark read as CSV just as well at
+// the journal's size.
+type SignalExportRequest struct {
+	Symbol string    `form:"symbol"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	Format string    `form:"format" binding:"omitempty,oneof=csv json"`
+}
+
+// BenchRequest holds the query of GET /admin/bench. Sizes are up to three
+// candle counts and default to 1k, 10k and 100k; Cases are benchmark names
+// and default to all of them.
+type BenchRequest struct {
+	Sizes []int    `form:"size" binding:"max=3,dive,min=1,max=1000000"`
+	Cases []string `form:"case"`
+}
+
+// SettingsPatch is the body of PATCH /admin/settings. Only the fields that
+// are set change.
+type SettingsPatch struct {
+	OutputPrecision   *int     `json:"output_precision" binding:"omitempty,gte=-1,lte=12"`
+	SwingStrength     *int     `json:"swing_strength" binding:"omitempty,gte=1"`
+	MaxBase           *int     `json:"max_base" binding:"omitempty,gte=1"`
+	LegRange          *float64 `json:"leg_range" binding:"omitempty,gt=0"`
+	ConfirmCloses     *int     `json:"confirm_closes" binding:"omitempty,gte=1"`
+	MaxSymbolExposure *float64 `json:"max_symbol_exposure" binding:"omitempty,gte=0"`
+	MaxTotalExposure  *float64 `json:"max_total_exposure" binding:"omitempty,gte=0"`
+	MaxGroupExposure  *float64 `json:"max_group_exposure" binding:"omitempty,gte=0"`
+}
+
+// RetentionPolicyRequest is the body of PUT /admin/retention/:timeframe.
+// Ages are Go durations that may start with months and days, such as
+// "90d", "12mo" or "1d12h"; an empty MaxAge keeps candles forever, and candles are deleted
+// without a roll-up when DownsampleTimeframe is empty.
+type RetentionPolicyRequest struct {
+	MaxAge              string `json:"max_age" binding:"required_with=DownsampleTimeframe"`
+	DownsampleTimeframe string `json:"downsample_timeframe" binding:"required_with=DownsampleInterval"`
+	DownsampleInterval  string `json:"downsample_interval" binding:"required_with=DownsampleTimeframe"`
+}
+
+// RatesRequest is the body of POST /fx/rates, keyed by "BASE/QUOTE".
+type RatesRequest struct {
+	Rates map[string]float64 `json:"rates" binding:"required"`
+}
+
+// ConvertRequest holds the query of GET /fx/convert.
+type ConvertRequest struct {
+	Amount float64 `form:"amount"`
+	From   string  `form:"from" binding:"required"`
+	To     string  `form:"to" binding:"required"`
+}
+
+// FuturesContract is one contract of a ContinuousRequest.
+type FuturesContract struct {
+	Symbol  string    `json:"symbol" binding:"required"`
+	Expiry  time.Time `json:"expiry" binding:"required"`
+	Candles []OHLC    `json:"candles" binding:"required,min=1"`
+}
+
+// ContinuousRequest is the body of POST /futures/continuous.
+type ContinuousRequest struct {
+	Contracts            []FuturesContract `json:"contracts" binding:"required,min=1,dive"`
+	RollDaysBeforeExpiry int               `json:"roll_days_before_expiry" binding:"gte=0"`
+	Adjustment           string            `json:"adjustment" binding:"omitempty,oneof=none backward forward ratio"`
+}
+
+// CorporateAction is a split or cash dividend effective at its ex-date.
+// Ratio is the number of new shares per old share (4 for a 4:1 split, 0.1
+// for a 1:10 reverse split); Amount is the dividend per share.
+type CorporateAction struct {
+	Type   string    `json:"type" binding:"required,oneof=split dividend"`
+	ExDate time.Time `json:"ex_date" binding:"required"`
+	Ratio  float64   `json:"ratio" binding:"gte=0"`
+	Amount float64   `json:"amount" binding:"gte=0"`
+}
+
+// AdjustRequest is the body of POST /equities/adjust.
+type AdjustRequest struct {
+	Candles    []OHLC            `json:"candles" binding:"required,min=1"`
+	Actions    []CorporateAction `json:"actions" binding:"dive"`
+	SplitsOnly bool              `json:"splits_only"`
+}
+
+// OnChainRequest is the body of POST /onchain/series. Metrics are aligned to
+// the candle times.
+type OnChainRequest struct {
+	Asset   string   `json:"asset" binding:"required"`
+	Metrics []string `json:"metrics" binding:"required,min=1,dive,oneof=exchange_net_flow active_addresses mvrv"`
+	Candles []OHLC   `json:"candles" binding:"required,min=1"`
+}
+
+// Headline is a news headline and its publication time.
+type Headline struct {
+	Title  string    `json:"title" binding:"required"`
+	Time   time.Time `json:"time" binding:"required"`
+	Source string    `json:"source"`
+}
+
+// SentimentRequest is the body of POST /sentiment/series. Headlines are
+// read from the given headlines and RSS feeds, which must be on a host in
+// SENTIMENT_FEED_HOSTS; Symbols maps each symbol to the keywords that
+// identify it in a headline.
+type SentimentRequest struct {
+	Headlines []Headline          `json:"headlines" binding:"dive"`
+	Feeds     []string            `json:"feeds" binding:"dive,url"`
+	Symbols   map[string][]string `json:"symbols" binding:"required,min=1"`
+	Interval  string              `json:"interval"`
+	Window    int                 `json:"window" binding:"gte=0"`
+}
+
+// MentionCount is the number of social mentions of a symbol in one interval.
+type MentionCount struct {
+	Time  time.Time `json:"time" binding:"required"`
+	Count float64   `json:"count" binding:"gte=0"`
+}
+
+// SocialSpikeRequest is the body of POST /social/spikes. Counts are taken
+// from the body, or fetched from the configured provider for symbols
+// without counts over the last Lookback (default 7 days). Spikes found are
+// also published to the signals journal, once each, with the method
+// social_spike.
+type SocialSpikeRequest struct {
+	Counts    map[string][]MentionCount `json:"counts"`
+	Symbols   []string                  `json:"symbols"`
+	Interval  string                    `json:"interval"`
+	Lookback  string                    `json:"lookback"`
+	Window    int                       `json:"window" binding:"gte=0"`
+	Threshold float64                   `json:"threshold" binding:"gte=0"`
+}
+
+// BreadthRequest is the body of POST /stats/breadth: candles per symbol of
+// the universe. Zero periods use the defaults (EMA 200, RSI 14, 252 bars
+// for new highs/lows).
+type BreadthRequest struct {
+	Universe      map[string][]OHLC `json:"universe" binding:"required,min=1"`
+	EMAPeriod     int               `json:"ema_period" binding:"gte=0"`
+	RSIPeriod     int               `json:"rsi_period" binding:"gte=0"`
+	HighLowPeriod int               `json:"high_low_period" binding:"gte=0"`
+}
+
+// RelativeStrengthRequest is the body of POST /stats/relative-strength:
+// candles per symbol of the universe, including the Benchmark symbol the
+// others are ranked against. Lookbacks are in bars and default to 21, 63,
+// 126 and 252, about one, three, six and twelve months of daily bars.
+type RelativeStrengthRequest struct {
+	Universe  map[string][]OHLC `json:"universe" binding:"required,min=2"`
+	Benchmark string            `json:"benchmark" binding:"required"`
+	Lookbacks []int             `json:"lookbacks" binding:"max=10,dive,min=1"`
+}
+
+// AnomalyRequest is the body of POST /ml/anomalies. Zero values use the
+// defaults: 100 trees, 256 samples per tree and a 0.6 score threshold.
+// Trees and samples are bounded, as scoring costs grow with both.
+type AnomalyRequest struct {
+	Candles    []OHLC  `json:"candles" binding:"required,min=3"`
+	Trees      int     `json:"trees" binding:"gte=0,lte=1000"`
+	SampleSize int     `json:"sample_size" binding:"gte=0,lte=4096"`
+	Threshold  float64 `json:"threshold" binding:"gte=0,lte=1"`
+	Seed       int64   `json:"seed"`
+}
+
+// Outcome is a historical signal score and whether the trade it triggered
+// was a winner.
+type Outcome struct {
+	Score float64 `json:"score"`
+	Win   bool    `json:"win"`
+}
+
+// CalibrationRequest is the body of POST /ml/calibrate. Method is platt or
+// isotonic and defaults to platt.
+type CalibrationRequest struct {
+	Method   string    `json:"method" binding:"omitempty,oneof=platt isotonic"`
+	Outcomes []Outcome `json:"outcomes" binding:"required,min=2,dive"`
+	Scores   []float64 `json:"scores" binding:"required"`
+}
+
+// PurgedCVRequest is the body of POST /ml/cv/purged. Each sample starts at
+// Times[i] and its label is known at LabelEnds[i]; when LabelEnds is empty
+// it is the time of the sample Horizon bars later. Embargo is the fraction
+// of samples dropped after each test fold. Folds defaults to 5.
+type PurgedCVRequest struct {
+	Times     []time.Time `json:"times" binding:"required,min=2"`
+	LabelEnds []time.Time `json:"label_ends"`
+	Horizon   int         `json:"horizon" binding:"gte=0"`
+	Folds     int         `json:"folds" binding:"omitempty,gte=2"`
+	Embargo   float64     `json:"embargo" binding:"gte=0,lt=1"`
+}
+
+// FeatureSample is the training and live values of one model input.
+type FeatureSample struct {
+	Reference []float64 `json:"reference" binding:"required,min=1"`
+	Live      []float64 `json:"live" binding:"required,min=1"`
+}
+
+// MonitorRequest is the body of POST /ml/monitor. Predictions, when set,
+// compares the model's training and live outputs. Zero thresholds use PSI
+// 0.2, a KS p-value of 0.05 and 10 bins.
+type MonitorRequest struct {
+	Features     map[string]FeatureSample `json:"features" binding:"required,dive"`
+	Predictions  *FeatureSample           `json:"predictions"`
+	PSIThreshold float64                  `json:"psi_threshold" binding:"gte=0"`
+	KSPValue     float64                  `json:"ks_p_value" binding:"gte=0,lte=1"`
+	Bins         int                      `json:"bins" binding:"omitempty,gte=2,lte=1000"`
+}
+
+// ModelVersionRequest is the body of POST /ml/models.
+type ModelVersionRequest struct {
+	Name     string             `json:"name" binding:"required"`
+	Version  string             `json:"version" binding:"required"`
+	Metadata map[string]string  `json:"metadata"`
+	Metrics  map[string]float64 `json:"metrics"`
+}
+
+// DeployRequest is the body of POST /ml/models/:name/deploy and maps
+// versions to the fraction of traffic they receive.
+type DeployRequest struct {
+	Traffic map[string]float64 `json:"traffic" binding:"required,min=1"`
+}
+
+// ModelOutcomeRequest is the body of POST /ml/models/:name/outcomes.
+type ModelOutcomeRequest struct {
+	Version string  `json:"version" binding:"required"`
+	Outcome float64 `json:"outcome"`
+}
+
+// SignalComponent is one input to an ensemble signal. Type is score (Value
+// in [-Scale, Scale]), probability (Value is the probability of an up move)
+// or pattern (Value is the confidence, 1 when zero, and Direction bullish
+// or bearish).
+type SignalComponent struct {
+	Name      string  `json:"name" binding:"required"`
+	Type      string  `json:"type" binding:"required,oneof=score probability pattern"`
+	Value     float64 `json:"value"`
+	Scale     float64 `json:"scale" binding:"gte=0"`
+	Direction string  `json:"direction" binding:"omitempty,oneof=bullish bearish"`
+}
+
+// EnsembleRequest is the body of POST /signals/ensemble. Weights are keyed
+// by component name. Method is vote or weighted and defaults to weighted;
+// Threshold defaults to 0.2. Signals with a Symbol are journaled for
+// GET /signals/export.
+type EnsembleRequest struct {
+	Symbol     string             `json:"symbol"`
+	Components []SignalComponent  `json:"components" binding:"required,min=1,dive"`
+	Weights    map[string]float64 `json:"weights"`
+	Method     string             `json:"method" binding:"omitempty,oneof=vote weighted"`
+	Threshold  float64            `json:"threshold" binding:"gte=0,lte=1"`
+}
+
+// PatternRequest is the body of POST /patterns/detect. Patterns selects the
+// patterns to run by name; all of them run when it is empty. Format is
+// series (a boolean per candle, the default) or indices (only the indices
+// of the candles where a pattern completed).
+type PatternRequest struct {
+	Candles  []OHLC   `json:"candles" binding:"required,min=1"`
+	Patterns []string `json:"patterns" binding:"dive,required"`
+	Format   string   `json:"format" binding:"omitempty,oneof=series indices"`
+}
+
+// UniverseStatsRequest is the body of POST /patterns/universe-stats:
+// candles per symbol of the universe. Patterns selects the patterns by
+// name, all of them when empty. Zero values measure returns 5 bars ahead
+// and keep combinations seen at least 5 times.
+type UniverseStatsRequest struct {
+	Universe       map[string][]OHLC `json:"universe" binding:"required,min=1"`
+	Patterns       []string          `json:"patterns" binding:"dive,required"`
+	Horizon        int               `json:"horizon" binding:"gte=0"`
+	MinOccurrences int               `json:"min_occurrences" binding:"gte=0"`
+}
+
+// CandlesRequest is the body of endpoints that only need candles, such as
+// POST /patterns/compression, POST /patterns/chart and PUT /snapshot.
+type CandlesRequest struct {
+	Candles []OHLC `json:"candles" binding:"required,min=1"`
+}
+
+// TrendlineRequest is the body of POST /patterns/trendlines. Zero values
+// use swings of strength 3, at least 3 touches and a tolerance of a quarter
+// of the average bar range.
+type TrendlineRequest struct {
+	Candles       []OHLC  `json:"candles" binding:"required,min=1"`
+	SwingStrength int     `json:"swing_strength" binding:"gte=0"`
+	MinTouches    int     `json:"min_touches" binding:"gte=0"`
+	Tolerance     float64 `json:"tolerance" binding:"gte=0"`
+}
+
+// SupplyDemandRequest is the body of POST /smc/supply-demand. Zero values
+// allow bases of up to 6 candles and legs of at least 1.5 average ranges.
+type SupplyDemandRequest struct {
+	Candles  []OHLC  `json:"candles" binding:"required,min=1"`
+	MaxBase  int     `json:"max_base" binding:"gte=0"`
+	LegRange float64 `json:"leg_range" binding:"gte=0"`
+}
+
+// ZoneScoreRequest is the body of POST /smc/zones/score. Zero values use a
+// half-life of 100 bars, a touch decay of 0.5 and a distance scale of 10
+// average bar ranges.
+type ZoneScoreRequest struct {
+	Candles       []OHLC  `json:"candles" binding:"required,min=1"`
+	Zones         []Zone  `json:"zones" binding:"required"`
+	HalfLife      float64 `json:"half_life" binding:"gte=0"`
+	TouchDecay    float64 `json:"touch_decay" binding:"gte=0,lte=1"`
+	DistanceScale float64 `json:"distance_scale" binding:"gte=0"`
+	MinScore      float64 `json:"min_score" binding:"gte=0,lte=100"`
+}
+
+// SFPRequest is the body of POST /smc/sfp. Zero values use swings of
+// strength 3, one confirming close and no volume confirmation.
+type SFPRequest struct {
+	Candles       []OHLC  `json:"candles" binding:"required,min=1"`
+	SwingStrength int     `json:"swing_strength" binding:"gte=0"`
+	ConfirmCloses int     `json:"confirm_closes" binding:"gte=0"`
+	VolumeFactor  float64 `json:"volume_factor" binding:"gte=0"`
+}
+
+// SMCRequest is the body of POST /analyze/smc. Detector settings default as
+// in their own endpoints; SwingStrength also applies to SFPs. Zones are
+// extra zones, e.g. order blocks and FVGs, checked for OTE overlaps.
+// Components selects the detectors to run, all of them when empty; the
+// others are omitted from the response. With DeadlineMS set, components
+// not done in time are left out of a partial response. Explain adds the
+// criteria values behind each zone, break, FVG and SFP, and the rejected
+// supply/demand candidates. Supply/demand zones carry a 0-100 relevance
+// score as in POST /smc/zones/score with its default settings; those
+// below MinZoneScore are left out, and ZoneSort "score" orders them by
+// descending score rather than by time.
+type SMCRequest struct {
+	Candles       []OHLC   `json:"candles" binding:"required,min=1"`
+	Zones         []Zone   `json:"zones"`
+	Components    []string `json:"components" binding:"dive,oneof=swings structure zones sfps fvgs levels power_of_three otes nearest"`
+	DeadlineMS    int      `json:"deadline_ms" binding:"gte=0"`
+	SwingStrength int      `json:"swing_strength" binding:"gte=0"`
+	MaxBase       int      `json:"max_base" binding:"gte=0"`
+	LegRange      float64  `json:"leg_range" binding:"gte=0"`
+	ConfirmCloses int      `json:"confirm_closes" binding:"gte=0"`
+	VolumeFactor  float64  `json:"volume_factor" binding:"gte=0"`
+	MinZoneScore  float64  `json:"min_zone_score" binding:"gte=0,lte=100"`
+	ZoneSort      string   `json:"zone_sort" binding:"omitempty,oneof=time score"`
+	Explain       bool     `json:"explain"`
+}
+
+// RepaintAuditRequest is the body of POST /smc/repaint-audit. Up to 500
+// candles from From on, the last 500 when it is zero, are replayed one by
+// one; the earlier ones are history. Replaying runs every detector once
+// per candle, hence the limit on replayed candles. Detector settings
+// default as in POST /analyze/smc.
+type RepaintAuditRequest struct {
+	Candles       []OHLC    `json:"candles" binding:"required,min=1,max=5000"`
+	From          time.Time `json:"from"`
+	SwingStrength int       `json:"swing_strength" binding:"gte=0"`
+	MaxBase       int       `json:"max_base" binding:"gte=0"`
+	LegRange      float64   `json:"leg_range" binding:"gte=0"`
+	ConfirmCloses int       `json:"confirm_closes" binding:"gte=0"`
+	VolumeFactor  float64   `json:"volume_factor" binding:"gte=0"`
+}
+
+// FVGInversionRequest is the body of POST /smc/fvg/inversions. Gaps are
+// found on Candles; LowerCandles, optional, are the same market on a lower
+// timeframe, where inversions are also looked for.
+type FVGInversionRequest struct {
+	Candles      []OHLC `json:"candles" binding:"required,min=3"`
+	LowerCandles []OHLC `json:"lower_candles"`
+}
+
+// MarketMakerRequest is the body of POST /smc/market-maker. Zero values use
+// the tuned swing strength and 8-bar consolidations spanning at most 3
+// average candle ranges.
+type MarketMakerRequest struct {
+	Candles           []OHLC  `json:"candles" binding:"required,min=1"`
+	SwingStrength     int     `json:"swing_strength" binding:"gte=0"`
+	ConsolidationBars int     `json:"consolidation_bars" binding:"gte=0"`
+	RangeFactor       float64 `json:"range_factor" binding:"gte=0"`
+}
+
+// SMTRequest is the body of POST /smc/smt. Each pair names two symbols of
+// Series to compare. SwingStrength defaults to 3.
+type SMTRequest struct {
+	Series        map[string][]OHLC `json:"series" binding:"required,min=2"`
+	Pairs         [][2]string       `json:"pairs" binding:"required,min=1"`
+	SwingStrength int               `json:"swing_strength" binding:"gte=0"`
+}
+
+// IndicatorRequest is the body of POST /calculate/indicators. Zero values
+// use RSI 14, 20-period Bollinger Bands at 2 standard deviations, Keltner
+// Channels 2 ATR 10s around EMA 20, Donchian Channels 20, a 14, 3, 3
+// stochastic, Williams %R 14, Elder Ray 13, Aroon 25, a Fisher transform
+// over 10 bars, an inverse Fisher transform of RSI 5 smoothed by WMA 9,
+// ATR 14, a 10-period SuperTrend at 3 ATRs, ADX 14, a 9, 26, 52 Ichimoku
+// displaced 26 bars, 20-period volume averages, MFI 14, CCI 20, TRIX 15, a
+// Coppock curve of ROC 14 and 11 smoothed by WMA 10, a 23, 50, 10 Schaff
+// trend cycle, a QQE of RSI 14 smoothed over 5 bars with bands 4.236 wide,
+// and a 20-candle rolling VWAP with UTC sessions; the KST has Pring's
+// settings. WMAPeriods and VWMAPeriods list the periods of the weighted
+// and volume weighted moving averages to compute, and MovingAverages
+// further moving averages of closes by type and period, none by default.
+type IndicatorRequest struct {
+	Candles          []OHLC                 `json:"candles" binding:"required,min=1"`
+	RSIPeriod        int                    `json:"rsi_period" binding:"gte=0"`
+	Bollinger        BollingerParams        `json:"bollinger"`
+	Keltner          KeltnerParams          `json:"keltner"`
+	DonchianPeriod   int                    `json:"donchian_period" binding:"gte=0"`
+	Stochastic       StochasticParams       `json:"stochastic"`
+	WilliamsRPeriod  int                    `json:"williams_r_period" binding:"gte=0"`
+	ElderRayPeriod   int                    `json:"elder_ray_period" binding:"gte=0"`
+	AroonPeriod      int                    `json:"aroon_period" binding:"gte=0"`
+	FisherPeriod     int                    `json:"fisher_period" binding:"gte=0"`
+	InverseFisherRSI InverseFisherRSIParams `json:"inverse_fisher_rsi"`
+	ATRPeriod        int                    `json:"atr_period" binding:"gte=0"`
+	SuperTrend       SuperTrendParams       `json:"supertrend"`
+	ADXPeriod        int                    `json:"adx_period" binding:"gte=0"`
+	Ichimoku         IchimokuParams         `json:"ichimoku"`
+	VolumePeriod     int                    `json:"volume_period" binding:"gte=0"`
+	MFIPeriod        int                    `json:"mfi_period" binding:"gte=0"`
+	CCIPeriod        int                    `json:"cci_period" binding:"gte=0"`
+	TRIXPeriod       int                    `json:"trix_period" binding:"gte=0"`
+	Coppock          CoppockParams          `json:"coppock"`
+	STC              STCParams              `json:"stc"`
+	QQE              QQEParams              `json:"qqe"`
+	WMAPeriods       []int                  `json:"wma_periods" binding:"max=10,dive,min=1"`
+	VWMAPeriods      []int                  `json:"vwma_periods" binding:"max=10,dive,min=1"`
+	MovingAverages   []MovingAverageSpec    `json:"moving_averages" binding:"max=20,dive"`
+	VWAP             VWAPParams             `json:"vwap"`
+}
+
+// MovingAverageSpec selects a moving average of closes: sma, ema, wma,
+// hma (Hull), tema (triple EMA) or zlema (zero lag EMA), over Period.
+type MovingAverageSpec struct {
+	Type   string `json:"type" binding:"required,oneof=sma ema wma hma tema zlema"`
+	Period int    `json:"period" binding:"required,min=1"`
+}
+
+// PivotRequest is the body of POST /calculate/pivots. The pivots apply to
+// the period of the last candle and come from the OHLC of the period
+// before it. Period is daily or weekly, daily by default, with days
+// starting at midnight in Timezone, an IANA name, UTC when empty, and
+// weeks on Monday. Methods default to all four.
+type PivotRequest struct {
+	Candles  []OHLC   `json:"candles" binding:"required,min=2"`
+	Period   string   `json:"period" binding:"omitempty,oneof=daily weekly"`
+	Methods  []string `json:"methods" binding:"dive,oneof=classic fibonacci camarilla woodie"`
+	Timezone string   `json:"timezone"`
+}
+
+// BollingerParams configures Bollinger Bands.
+type BollingerParams struct {
+	Period int     `json:"period" binding:"gte=0"`
+	StdDev float64 `json:"std_dev" binding:"gte=0"`
+}
+
+// InverseFisherRSIParams configures the inverse Fisher transform of the
+// RSI: the RSI period and that of the WMA smoothing it.
+type InverseFisherRSIParams struct {
+	RSIPeriod int `json:"rsi_period" binding:"gte=0"`
+	WMAPeriod int `json:"wma_period" binding:"gte=0"`
+}
+
+// KeltnerParams configures Keltner Channels: the EMA period of the
+// midline, and the ATR period and multiplier of the bands' distance.
+type KeltnerParams struct {
+	Period     int     `json:"period" binding:"gte=0"`
+	ATRPeriod  int     `json:"atr_period" binding:"gte=0"`
+	Multiplier float64 `json:"multiplier" binding:"gte=0"`
+}
+
+// SuperTrendParams configures the SuperTrend: its ATR period and the
+// distance of the bands from the bar midpoint, in ATRs.
+type SuperTrendParams struct {
+	Period     int     `json:"period" binding:"gte=0"`
+	Multiplier float64 `json:"multiplier" binding:"gte=0"`
+}
+
+// IchimokuParams configures the Ichimoku cloud: the Tenkan-sen, Kijun-sen
+// and Senkou Span B periods and the displacement of the spans, which
+// lengthens the span series and is bounded.
+type IchimokuParams struct {
+	Tenkan       int `json:"tenkan" binding:"gte=0"`
+	Kijun        int `json:"kijun" binding:"gte=0"`
+	SenkouB      int `json:"senkou_b" binding:"gte=0"`
+	Displacement int `json:"displacement" binding:"gte=0,lte=500"`
+}
+
+// CoppockParams configures the Coppock curve: the periods of its two rates
+// of change and of the WMA that smooths their sum.
+type CoppockParams struct {
+	LongROC   int `json:"long_roc" binding:"gte=0"`
+	ShortROC  int `json:"short_roc" binding:"gte=0"`
+	WMAPeriod int `json:"wma_period" binding:"gte=0"`
+}
+
+// STCParams configures the Schaff trend cycle: the EMA periods of its MACD
+// and the stochastic cycle.
+type STCParams struct {
+	Fast  int `json:"fast" binding:"gte=0"`
+	Slow  int `json:"slow" binding:"gte=0"`
+	Cycle int `json:"cycle" binding:"gte=0"`
+}
+
+// QQEParams configures the QQE: the RSI period, the EMA smoothing it and
+// the width of the trailing bands in average RSI moves.
+type QQEParams struct {
+	RSIPeriod int     `json:"rsi_period" binding:"gte=0"`
+	Smoothing int     `json:"smoothing" binding:"gte=0"`
+	Factor    float64 `json:"factor" binding:"gte=0"`
+}
+
+// VWAPParams configures the VWAP: sessions start at midnight in Timezone,
+// an IANA name, and the rolling VWAP spans Period candles.
+type VWAPParams struct {
+	Timezone string `json:"timezone"`
+	Period   int    `json:"period" binding:"gte=0"`
+}
+
+// StochasticParams configures the stochastic oscillator: %K over KPeriod
+// bars, smoothed over Smooth bars for the slow variant, and %D, the SMA of
+// %K over DPeriod bars.
+type StochasticParams struct {
+	KPeriod int `json:"k_period" binding:"gte=0"`
+	Smooth  int `json:"smooth" binding:"gte=0"`
+	DPeriod int `json:"d_period" binding:"gte=0"`
+}
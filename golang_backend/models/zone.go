@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Zone directions.
+const (
+	Bullish = "bullish"
+	Bearish = "bearish"
+)
+
+// Zone is a price area between Bottom and Top starting at a candle.
+// Type names the detector that produced it (e.g. "fvg", "supply").
+// Touches counts the times price came back into the zone after it formed.
+type Zone struct {
+	Type       string    `json:"type"`
+	Direction  string    `json:"direction"`
+	Top        float64   `json:"top"`
+	Bottom     float64   `json:"bottom"`
+	StartIndex int       `json:"start_index"`
+	StartTime  time.Time `json:"start_time"`
+	Mitigated  bool      `json:"mitigated"`
+	Touches    int       `json:"touches"`
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Cache memoises series derived from one request's candles so that inputs
+// shared by several computations, such as closes, moving averages, the RSI,
+// the ATR or swing points, are computed once. CalculateIndicators shares
+// them between indicators and smc.Analyze between detectors; a caller
+// running both over the same candles can pass them one cache. It is safe
+// for concurrent use; a value being computed is waited for rather than
+// computed again. Cached slices are shared and must not be modified.
+type Cache struct {
+	candles []models.OHLC
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	once  sync.Once
+	value any
+}
+
+// NewCache creates an empty cache over candles.
+func NewCache(candles []models.OHLC) *Cache {
+	return &Cache{candles: candles, entries: make(map[string]*cacheEntry)}
+}
+
+// Candles returns the candles the cache was created over.
+func (c *Cache) Candles() []models.OHLC {
+	return c.candles
+}
+
+// Memo returns the value stored under key, computing it on first use.
+// Keys are namespaced by the package that owns them, e.g. "smc.swings/3".
+func (c *Cache) Memo(key string, compute func() any) any {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() { entry.value = compute() })
+	return entry.value
+}
+
+// Closes returns the close prices of the candles.
+func (c *Cache) Closes() []float64 {
+	return c.Memo("closes", func() any { return Closes(c.candles) }).([]float64)
+}
+
+// Highs returns the high prices of the candles.
+func (c *Cache) Highs() []float64 {
+	return c.Memo("highs", func() any { return Highs(c.candles) }).([]float64)
+}
+
+// Lows returns the low prices of the candles.
+func (c *Cache) Lows() []float64 {
+	return c.Memo("lows", func() any { return Lows(c.candles) }).([]float64)
+}
+
+// SMA returns the simple moving average of closes over period.
+func (c *Cache) SMA(period int) []float64 {
+	return c.Memo(fmt.Sprintf("sma/%d", period), func() any { return CalculateSMA(c.Closes(), period) }).([]float64)
+}
+
+// EMA returns the exponential moving average of closes over period.
+func (c *Cache) EMA(period int) []float64 {
+	return c.Memo(fmt.Sprintf("ema/%d", period), func() any { return CalculateEMA(c.Closes(), period) }).([]float64)
+}
+
+// RSI returns the relative strength index of closes over period.
+func (c *Cache) RSI(period int) []float64 {
+	return c.Memo(fmt.Sprintf("rsi/%d", period), func() any { return CalculateRSI(c.Closes(), period) }).([]float64)
+}
+
+// ATR returns Wilder's average true range over period.
+func (c *Cache) ATR(period int) []float64 {
+	return c.Memo(fmt.Sprintf("atr/%d", period), func() any {
+		return CalculateATR(c.Highs(), c.Lows(), c.Closes(), period)
+	}).([]float64)
+}
+
+// MovingAverage returns the moving average of closes named name over
+// period. Its keys are those of SMA and EMA, so the series are shared.
+func (c *Cache) MovingAverage(name string, period int) ([]float64, error) {
+	average, ok := movingAverages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown moving average %q", name)
+	}
+	return c.Memo(fmt.Sprintf("%s/%d", name, period), func() any { return average(c.Closes(), period) }).([]float64), nil
+}
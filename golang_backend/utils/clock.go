@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time and of timers. Components take a
+// Clock instead of calling time.Now so that replays and tests can run on
+// simulated time.
+type Clock interface {
+	Now() time.Time
+	// After delivers the current time on the returned channel once d has
+	// elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock is the wall clock.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SimulatedClock is a Clock that only moves when told to. Timers created
+// with After fire as Set or Advance carry the clock past their deadline.
+// It is safe for concurrent use.
+type SimulatedClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewSimulatedClock creates a simulated clock reading start.
+func NewSimulatedClock(start time.Time) *SimulatedClock {
+	return &SimulatedClock{now: start}
+}
+
+// Now returns the simulated time.
+func (c *SimulatedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel that receives the simulated time once the clock
+// reaches now+d. A non-positive d fires immediately.
+func (c *SimulatedClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Timers returns the number of timers created with After that have not
+// fired yet, so that a test can wait for a component to start waiting
+// before moving the clock.
+func (c *SimulatedClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.waiters)
+}
+
+// Advance moves the clock forward by d.
+func (c *SimulatedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to t. Moving it backwards fires no timers.
+func (c *SimulatedClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(t)
+}
+
+// set fires due timers in deadline order. The caller holds the lock.
+func (c *SimulatedClock) set(t time.Time) {
+	c.now = t
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	fired := 0
+	for _, w := range c.waiters {
+		if w.at.After(t) {
+			break
+		}
+		w.ch <- w.at
+		fired++
+	}
+	c.waiters = c.waiters[fired:]
+}
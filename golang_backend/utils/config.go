@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config stores the configuration of the application.
+// Values are read from environment variables (see app.env).
+type Config struct {
+	DBSource          string
+	HTTPServerAddress string
+
+	MaxStrategyDrawdown float64
+	MaxAccountDrawdown  float64
+
+	MaxSymbolExposure float64
+	MaxTotalExposure  float64
+	MaxGroupExposure  float64
+
+	AllocationMethod  string
+	RebalanceInterval time.Duration
+
+	// CompactionInterval is how often the leader applies the candle
+	// retention policies; zero disables the scheduled compaction.
+	CompactionInterval time.Duration
+
+	PnLMethod       string
+	AccountCurrency string
+
+	OnChainAPIURL string
+	OnChainAPIKey string
+
+	SocialAPIURL string
+	SocialAPIKey string
+
+	// SentimentFeedHosts are the hosts RSS feeds named in sentiment
+	// requests may be fetched from; feeds are rejected when it is empty.
+	SentimentFeedHosts []string
+
+	// AdminToken authenticates the admin API, which is disabled when it is
+	// empty.
+	AdminToken string
+
+	// ConfigDir holds JSON configuration reloaded every ConfigReloadInterval
+	// without a restart; empty disables it.
+	ConfigDir            string
+	ConfigReloadInterval time.Duration
+
+	// RedisURL is the Redis server holding the leader lock and the state
+	// replicas share, as redis://[user:password@]host:port/db, or rediss://
+	// for TLS. When empty, the instance runs scheduled jobs on its own.
+	RedisURL   string
+	LeaderKey  string
+	LeaderTTL  time.Duration
+	InstanceID string
+
+	// SignalJournalLimit is the number of generated signals kept for
+	// export; the oldest are dropped past it.
+	SignalJournalLimit int
+
+	// SnapshotIndicators names the indicators computed for every snapshot,
+	// as "ema_20" or "rsi_14"; the snapshot defaults when empty.
+	// SnapshotSignals is the number of latest signals a snapshot carries.
+	SnapshotIndicators []string
+	SnapshotSignals    int
+
+	// StateFile is where the admin API saves and restores the in-memory
+	// state. It is saved on shutdown and restored on start when it exists
+	// and is at most StateMaxAge old; zero restores it at any age.
+	StateFile   string
+	StateMaxAge time.Duration
+
+	// On SIGTERM the instance reports itself not ready for ShutdownDelay,
+	// still serving, then waits up to ShutdownTimeout for requests in
+	// flight.
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	// OutputPrecision is the number of decimals floats are rounded to in
+	// responses; negative disables rounding.
+	OutputPrecision int
+}
+
+// LoadConfig reads configuration from environment variables.
+func LoadConfig() Config {
+	return Config{
+		DBSource:          os.Getenv("DB_SOURCE"),
+		HTTPServerAddress: getEnv("HTTP_SERVER_ADDRESS", "0.0.0.0:5001"),
+
+		MaxStrategyDrawdown: getEnvFloat("MAX_STRATEGY_DRAWDOWN", 0.10),
+		MaxAccountDrawdown:  getEnvFloat("MAX_ACCOUNT_DRAWDOWN", 0.20),
+
+		MaxSymbolExposure: getEnvFloat("MAX_SYMBOL_EXPOSURE", 0),
+		MaxTotalExposure:  getEnvFloat("MAX_TOTAL_EXPOSURE", 0),
+		MaxGroupExposure:  getEnvFloat("MAX_GROUP_EXPOSURE", 0),
+
+		AllocationMethod:  getEnv("ALLOCATION_METHOD", "fixed"),
+		RebalanceInterval: getEnvDuration("REBALANCE_INTERVAL", 0),
+
+		CompactionInterval: getEnvDuration("COMPACTION_INTERVAL", time.Hour),
+
+		PnLMethod:       getEnv("PNL_METHOD", "fifo"),
+		AccountCurrency: getEnv("ACCOUNT_CURRENCY", "USD"),
+
+		OnChainAPIURL: os.Getenv("ONCHAIN_API_URL"),
+		OnChainAPIKey: os.Getenv("ONCHAIN_API_KEY"),
+
+		SocialAPIURL: os.Getenv("SOCIAL_API_URL"),
+		SocialAPIKey: os.Getenv("SOCIAL_API_KEY"),
+
+		SentimentFeedHosts: getEnvList("SENTIMENT_FEED_HOSTS"),
+
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+
+		ConfigDir:            os.Getenv("CONFIG_DIR"),
+		ConfigReloadInterval: getEnvDuration("CONFIG_RELOAD_INTERVAL", 5*time.Second),
+
+		RedisURL:   os.Getenv("REDIS_URL"),
+		LeaderKey:  getEnv("LEADER_KEY", "quant:leader"),
+		LeaderTTL:  getEnvDuration("LEADER_TTL", 15*time.Second),
+		InstanceID: getEnv("INSTANCE_ID", instanceID()),
+
+		SignalJournalLimit: getEnvInt("SIGNAL_JOURNAL_LIMIT", 100000),
+
+		SnapshotIndicators: getEnvList("SNAPSHOT_INDICATORS"),
+		SnapshotSignals:    getEnvInt("SNAPSHOT_SIGNALS", 5),
+
+		StateFile:   getEnv("STATE_FILE", "state.json"),
+		StateMaxAge: getEnvDuration("STATE_MAX_AGE", 24*time.Hour),
+
+		ShutdownDelay:   getEnvDuration("SHUTDOWN_DELAY", 5*time.Second),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
+
+		OutputPrecision: getEnvInt("OUTPUT_PRECISION", -1),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, nil when it is unset.
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// instanceID identifies this process among the replicas by default.
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// candleTimeLayouts are the timestamp layouts accepted besides Unix
+// seconds and milliseconds.
+var candleTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+// ReadCandlesCSV reads candles from CSV with a header row. Columns are
+// matched by name, case-insensitively: time (or timestamp or date), open,
+// high, low, close and an optional volume. Times without a zone are UTC.
+func ReadCandlesCSV(r io.Reader) ([]models.OHLC, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read header: %w", err)
+	}
+
+	columns := map[string]int{"volume": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "timestamp", "date", "datetime":
+			name = "time"
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"time", "open", "high", "low", "close"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	candles := []models.OHLC{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return candles, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var candle models.OHLC
+		if candle.Time, err = parseCandleTime(record[columns["time"]]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		for _, field := range []struct {
+			name string
+			into *float64
+		}{
+			{"open", &candle.Open}, {"high", &candle.High}, {"low", &candle.Low},
+			{"close", &candle.Close}, {"volume", &candle.Volume},
+		} {
+			i := columns[field.name]
+			if i < 0 {
+				continue
+			}
+			if *field.into, err = strconv.ParseFloat(strings.TrimSpace(record[i]), 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, field.name, err)
+			}
+		}
+		candles = append(candles, candle)
+	}
+}
+
+// parseCandleTime parses a timestamp in one of candleTimeLayouts or as
+// Unix seconds, or milliseconds when it is too large to be seconds.
+func parseCandleTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n > 1e11 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+	for _, layout := range candleTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
@@ -0,0 +1,1341 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Indicator series have the same length as their input so that index i
+// always refers to candle i. Values inside the warm-up period, before an
+// indicator has enough data, are 0.
+
+// CalculateSMA returns the simple moving average of prices over period.
+func CalculateSMA(prices []float64, period int) []float64 {
+	sma := make([]float64, len(prices))
+	if period <= 0 || len(prices) < period {
+		return sma
+	}
+
+	var sum float64
+	for i, price := range prices {
+		sum += price
+		if i >= period {
+			sum -= prices[i-period]
+		}
+		if i >= period-1 {
+			sma[i] = sum / float64(period)
+		}
+	}
+	return sma
+}
+
+// CalculateEMA returns the exponential moving average of prices over
+// period, seeded with the SMA of the first period prices.
+func CalculateEMA(prices []float64, period int) []float64 {
+	ema := make([]float64, len(prices))
+	if period <= 0 || len(prices) < period {
+		return ema
+	}
+
+	var sum float64
+	for _, price := range prices[:period] {
+		sum += price
+	}
+	ema[period-1] = sum / float64(period)
+
+	k := 2 / float64(period+1)
+	for i := period; i < len(prices); i++ {
+		ema[i] = prices[i]*k + ema[i-1]*(1-k)
+	}
+	return ema
+}
+
+// CalculateWMA returns the linearly weighted moving average of prices over
+// period: the latest price weighs period, the oldest 1.
+func CalculateWMA(prices []float64, period int) []float64 {
+	wma := make([]float64, len(prices))
+	if period <= 0 || len(prices) < period {
+		return wma
+	}
+
+	// Moving the window one bar lowers every weight by one, which takes
+	// the previous window's plain sum off the weighted one.
+	var sum, weighted float64
+	weights := float64(period*(period+1)) / 2
+	for i, price := range prices {
+		if i < period {
+			weighted += float64(i+1) * price
+		} else {
+			weighted += float64(period)*price - sum
+		}
+		sum += price
+		if i >= period {
+			sum -= prices[i-period]
+		}
+		if i >= period-1 {
+			wma[i] = weighted / weights
+		}
+	}
+	return wma
+}
+
+// CalculateVWMA returns the volume weighted moving average of prices over
+// period. Windows without volume give 0.
+func CalculateVWMA(prices, volumes []float64, period int) []float64 {
+	vwma := make([]float64, len(prices))
+	if period <= 0 || len(prices) < period {
+		return vwma
+	}
+
+	var priceVolume, volume float64
+	for i, price := range prices {
+		priceVolume += price * volumes[i]
+		volume += volumes[i]
+		if i >= period {
+			priceVolume -= prices[i-period] * volumes[i-period]
+			volume -= volumes[i-period]
+		}
+		if i >= period-1 && volume > 0 {
+			vwma[i] = priceVolume / volume
+		}
+	}
+	return vwma
+}
+
+// CalculateHMA returns the Hull moving average of prices over period: a
+// WMA over the square root of the period of twice the half-period WMA less
+// the full one, which cancels most of the WMA's lag while staying smooth.
+func CalculateHMA(prices []float64, period int) []float64 {
+	if period <= 0 {
+		return make([]float64, len(prices))
+	}
+	half, full := CalculateWMA(prices, max(period/2, 1)), CalculateWMA(prices, period)
+	diff := make([]float64, len(prices))
+	for i := period - 1; i < len(prices); i++ {
+		diff[i] = 2*half[i] - full[i]
+	}
+	return wmaFrom(diff, period-1, max(int(math.Round(math.Sqrt(float64(period)))), 1))
+}
+
+// CalculateTEMA returns the triple exponential moving average of prices
+// over period, 3 EMA - 3 EMA(EMA) + EMA(EMA(EMA)), which removes the lag
+// of the single EMA.
+func CalculateTEMA(prices []float64, period int) []float64 {
+	tema := make([]float64, len(prices))
+	if period <= 0 {
+		return tema
+	}
+	single := CalculateEMA(prices, period)
+	double := emaFrom(single, period-1, period)
+	triple := emaFrom(double, 2*(period-1), period)
+	for i := 3 * (period - 1); i < len(prices); i++ {
+		tema[i] = 3*single[i] - 3*double[i] + triple[i]
+	}
+	return tema
+}
+
+// CalculateZLEMA returns the zero lag EMA of prices over period: the EMA
+// of each price pushed away from the price (period-1)/2 bars earlier by
+// their difference, which offsets the EMA's lag.
+func CalculateZLEMA(prices []float64, period int) []float64 {
+	if period <= 0 {
+		return make([]float64, len(prices))
+	}
+	lag := (period - 1) / 2
+	adjusted := make([]float64, len(prices))
+	for i := lag; i < len(prices); i++ {
+		adjusted[i] = 2*prices[i] - prices[i-lag]
+	}
+	return emaFrom(adjusted, lag, period)
+}
+
+// movingAverages are the moving averages MovingAverage computes by name.
+var movingAverages = map[string]func(prices []float64, period int) []float64{
+	"sma":   CalculateSMA,
+	"ema":   CalculateEMA,
+	"wma":   CalculateWMA,
+	"hma":   CalculateHMA,
+	"tema":  CalculateTEMA,
+	"zlema": CalculateZLEMA,
+}
+
+// MovingAverage returns the moving average of prices over period named
+// sma, ema, wma, hma, tema or zlema.
+func MovingAverage(name string, prices []float64, period int) ([]float64, error) {
+	average, ok := movingAverages[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown moving average %q", name)
+	}
+	return average(prices, period), nil
+}
+
+// CalculateRSI returns Wilder's relative strength index of prices over
+// period. The first value is at index period.
+func CalculateRSI(prices []float64, period int) []float64 {
+	return CalculateRSIInto(make([]float64, len(prices)), prices, period)
+}
+
+// CalculateRSIInto is CalculateRSI writing into dst, which must be zeroed
+// and at least as long as prices, as buffers from GetFloats are. It keeps
+// running averages of the gains and losses rather than series of them.
+func CalculateRSIInto(dst, prices []float64, period int) []float64 {
+	rsi := dst[:len(prices)]
+	if period <= 0 || len(prices) <= period {
+		return rsi
+	}
+
+	var avgGain, avgLoss float64
+	for i := 1; i <= period; i++ {
+		change := prices[i] - prices[i-1]
+		if change > 0 {
+			avgGain += change
+		} else {
+			avgLoss -= change
+		}
+	}
+	avgGain /= float64(period)
+	avgLoss /= float64(period)
+	rsi[period] = rsiValue(avgGain, avgLoss)
+
+	for i := period + 1; i < len(prices); i++ {
+		change := prices[i] - prices[i-1]
+		gain, loss := 0.0, 0.0
+		if change > 0 {
+			gain = change
+		} else {
+			loss = -change
+		}
+		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
+		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
+		rsi[i] = rsiValue(avgGain, avgLoss)
+	}
+	return rsi
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+avgGain/avgLoss)
+}
+
+// CalculateInverseFisherRSI returns Vervoort's inverse Fisher transform of
+// the RSI over rsiPeriod: the RSI rescaled to [-5, 5], smoothed by a WMA
+// over wmaPeriod and squashed into [-1, 1] by tanh. It spends most of its
+// time near -1 or 1, so crossings of ±0.5 mark turns sharply.
+func CalculateInverseFisherRSI(prices []float64, rsiPeriod, wmaPeriod int) []float64 {
+	if rsiPeriod <= 0 || wmaPeriod <= 0 {
+		return make([]float64, len(prices))
+	}
+	buf := GetFloats(len(prices))
+	defer PutFloats(buf)
+	return inverseFisherFromRSI(CalculateRSIInto(*buf, prices, rsiPeriod), rsiPeriod, wmaPeriod)
+}
+
+// inverseFisherFromRSI is CalculateInverseFisherRSI from the RSI over
+// rsiPeriod.
+func inverseFisherFromRSI(rsi []float64, rsiPeriod, wmaPeriod int) []float64 {
+	ift := make([]float64, len(rsi))
+	buf := GetFloats(len(rsi))
+	defer PutFloats(buf)
+	scaled := *buf
+	for i := rsiPeriod; i < len(rsi); i++ {
+		scaled[i] = 0.1 * (rsi[i] - 50)
+	}
+	smoothed := wmaFrom(scaled, rsiPeriod, wmaPeriod)
+	for i := rsiPeriod + wmaPeriod - 1; i < len(rsi); i++ {
+		ift[i] = math.Tanh(smoothed[i])
+	}
+	return ift
+}
+
+// CalculateQQE returns the quantitative qualitative estimation: the RSI
+// over rsiPeriod smoothed by an EMA over smoothing, a trailing line factor
+// times the smoothed RSI's average move away from it, as ATR bands trail
+// price, and the trend, 1 while the line is above its trailing line and -1
+// below, flipping when the line crosses it. The trailing line and trend
+// are 0 until the average move is warm, 2 × (2 × rsiPeriod - 1) bars after
+// the smoothed RSI.
+func CalculateQQE(prices []float64, rsiPeriod, smoothing int, factor float64) (line, trailing []float64, trend []int) {
+	if rsiPeriod <= 0 || smoothing <= 0 {
+		return make([]float64, len(prices)), make([]float64, len(prices)), make([]int, len(prices))
+	}
+	buf := GetFloats(len(prices))
+	defer PutFloats(buf)
+	return qqeFromRSI(CalculateRSIInto(*buf, prices, rsiPeriod), rsiPeriod, smoothing, factor)
+}
+
+// qqeFromRSI is CalculateQQE from the RSI over rsiPeriod.
+func qqeFromRSI(rsi []float64, rsiPeriod, smoothing int, factor float64) (line, trailing []float64, trend []int) {
+	trailing = make([]float64, len(rsi))
+	trend = make([]int, len(rsi))
+	line = emaFrom(rsi, rsiPeriod, smoothing)
+	start := rsiPeriod + smoothing - 1
+	buf := GetFloats(len(rsi))
+	defer PutFloats(buf)
+	moves := *buf
+	for i := start + 1; i < len(rsi); i++ {
+		moves[i] = math.Abs(line[i] - line[i-1])
+	}
+	wilders := 2*rsiPeriod - 1
+	average := emaFrom(moves, start+1, wilders)
+	start += 2 * wilders
+	distance := emaFrom(average, start-wilders, wilders)
+	if start >= len(rsi) {
+		return line, trailing, trend
+	}
+
+	long, short := line[start]-factor*distance[start], line[start]+factor*distance[start]
+	trend[start], trailing[start] = 1, long
+	for i := start + 1; i < len(rsi); i++ {
+		newLong, newShort := line[i]-factor*distance[i], line[i]+factor*distance[i]
+		prevLong, prevShort := long, short
+		if line[i-1] > prevLong && line[i] > prevLong {
+			long = max(prevLong, newLong)
+		} else {
+			long = newLong
+		}
+		if line[i-1] < prevShort && line[i] < prevShort {
+			short = min(prevShort, newShort)
+		} else {
+			short = newShort
+		}
+
+		trend[i] = trend[i-1]
+		switch {
+		case line[i] > prevShort && line[i-1] <= prevShort:
+			trend[i] = 1
+		case line[i] < prevLong && line[i-1] >= prevLong:
+			trend[i] = -1
+		}
+		if trend[i] == 1 {
+			trailing[i] = long
+		} else {
+			trailing[i] = short
+		}
+	}
+	return line, trailing, trend
+}
+
+// CalculateBollingerBands returns the bands stdDevMult population standard
+// deviations above and below the SMA of prices over period.
+func CalculateBollingerBands(prices []float64, period int, stdDevMult float64) (upper, middle, lower []float64) {
+	upper = make([]float64, len(prices))
+	lower = make([]float64, len(prices))
+	middle = CalculateSMA(prices, period)
+	if period <= 0 || len(prices) < period {
+		return upper, middle, lower
+	}
+
+	var sumSquares float64
+	for i, price := range prices {
+		sumSquares += price * price
+		if i >= period {
+			sumSquares -= prices[i-period] * prices[i-period]
+		}
+		if i >= period-1 {
+			mean := middle[i]
+			// Rounding can take the variance of a flat window just below 0.
+			deviation := math.Sqrt(max(sumSquares/float64(period)-mean*mean, 0))
+			upper[i] = mean + stdDevMult*deviation
+			lower[i] = mean - stdDevMult*deviation
+		}
+	}
+	return upper, middle, lower
+}
+
+// CalculateStochastic returns the stochastic oscillator of a series: %K
+// is where the close sits in the high-low range of the last kPeriod bars,
+// averaged over smooth bars (1 for the fast stochastic), and %D is the SMA
+// of %K over dPeriod bars. A flat range gives 50.
+func CalculateStochastic(highs, lows, closes []float64, kPeriod, smooth, dPeriod int) (k, d []float64) {
+	k = make([]float64, len(closes))
+	d = make([]float64, len(closes))
+	if kPeriod <= 0 || smooth <= 0 || dPeriod <= 0 || len(closes) < kPeriod {
+		return k, d
+	}
+
+	raw := rangePosition(highs, lows, closes, kPeriod)
+	k = smaFrom(raw, kPeriod-1, smooth)
+	d = smaFrom(k, kPeriod-1+smooth-1, dPeriod)
+	return k, d
+}
+
+// CalculateWilliamsR returns Williams %R over period: where the close sits
+// below the high of the last period bars, from 0 at the high to -100 at
+// the low. It is the fast stochastic %K shifted down by 100, so a flat
+// range gives -50.
+func CalculateWilliamsR(highs, lows, closes []float64, period int) []float64 {
+	r := make([]float64, len(closes))
+	if period <= 0 || len(closes) < period {
+		return r
+	}
+	raw := rangePosition(highs, lows, closes, period)
+	for i := period - 1; i < len(closes); i++ {
+		r[i] = raw[i] - 100
+	}
+	return r
+}
+
+// rangePosition returns where each close sits in the high-low range of the
+// last period bars, from 0 at the low to 100 at the high, or 50 for a flat
+// range. period must fit the series.
+func rangePosition(highs, lows, closes []float64, period int) []float64 {
+	raw := make([]float64, len(closes))
+	highs, lows = rollingMax(highs, period), rollingMin(lows, period)
+	for i := period - 1; i < len(closes); i++ {
+		highest, lowest := highs[i], lows[i]
+		if highest == lowest {
+			raw[i] = 50
+		} else {
+			raw[i] = 100 * (closes[i] - lowest) / (highest - lowest)
+		}
+	}
+	return raw
+}
+
+// CalculateDonchian returns Donchian Channels over period: the highest
+// high and lowest low of the last period bars, and their midpoint. The
+// window includes the current bar, so a close can never be outside its own
+// bands; breakouts compare the close with the previous bar's band.
+func CalculateDonchian(highs, lows []float64, period int) (upper, middle, lower []float64) {
+	upper, lower = rollingMax(highs, period), rollingMin(lows, period)
+	middle = make([]float64, len(highs))
+	if period <= 0 {
+		return upper, middle, lower
+	}
+	for i := period - 1; i < len(highs); i++ {
+		middle[i] = (upper[i] + lower[i]) / 2
+	}
+	return upper, middle, lower
+}
+
+// rollingMax returns the highest of the last period values, 0 before the
+// first full window.
+func rollingMax(values []float64, period int) []float64 {
+	return valuesAt(values, period, rollingExtreme(values, period, func(a, b float64) bool { return a > b }))
+}
+
+// rollingMin returns the lowest of the last period values, 0 before the
+// first full window.
+func rollingMin(values []float64, period int) []float64 {
+	return valuesAt(values, period, rollingExtreme(values, period, func(a, b float64) bool { return a < b }))
+}
+
+// valuesAt returns the values at the indices rollingExtreme found.
+func valuesAt(values []float64, period int, indices []int) []float64 {
+	at := make([]float64, len(values))
+	if period <= 0 {
+		return at
+	}
+	for i := period - 1; i < len(values); i++ {
+		at[i] = values[indices[i]]
+	}
+	return at
+}
+
+// rollingExtreme returns the index of the extreme of the last period
+// values, the latest on ties, and 0 before the first full window. It keeps
+// a monotonic deque of the indices of the window's candidates, each
+// beating all that follow it, so the front is the window's extreme and
+// every value is pushed and popped once: O(n) for any period. The front is
+// popped by moving head, so the deque is allocated once.
+func rollingExtreme(values []float64, period int, beats func(a, b float64) bool) []int {
+	extreme := make([]int, len(values))
+	if period <= 0 {
+		return extreme
+	}
+	deque := make([]int, 0, len(values))
+	var head int
+	for i, value := range values {
+		for len(deque) > head && !beats(values[deque[len(deque)-1]], value) {
+			deque = deque[:len(deque)-1]
+		}
+		deque = append(deque, i)
+		if deque[head] <= i-period {
+			head++
+		}
+		if i >= period-1 {
+			extreme[i] = deque[head]
+		}
+	}
+	return extreme
+}
+
+// CalculateAroon returns Aroon Up and Down over period, how recent the
+// highest high and lowest low of the last period+1 bars are, from 100 for
+// the current bar to 0 for the oldest, and the oscillator, up less down.
+// A new trend starts when one line crosses above 70 as the other falls.
+func CalculateAroon(highs, lows []float64, period int) (up, down, oscillator []float64) {
+	up = make([]float64, len(highs))
+	down = make([]float64, len(highs))
+	oscillator = make([]float64, len(highs))
+	if period <= 0 {
+		return up, down, oscillator
+	}
+
+	highest := rollingExtreme(highs, period+1, func(a, b float64) bool { return a > b })
+	lowest := rollingExtreme(lows, period+1, func(a, b float64) bool { return a < b })
+	for i := period; i < len(highs); i++ {
+		up[i] = 100 * float64(period-(i-highest[i])) / float64(period)
+		down[i] = 100 * float64(period-(i-lowest[i])) / float64(period)
+		oscillator[i] = up[i] - down[i]
+	}
+	return up, down, oscillator
+}
+
+// CalculateElderRay returns Elder's bull power, how far each high reaches
+// above the EMA of closes over period, and bear power, how far each low
+// reaches below it. Both are 0 until the EMA is warm.
+func CalculateElderRay(highs, lows, closes []float64, period int) (bull, bear []float64) {
+	if period <= 0 {
+		return make([]float64, len(closes)), make([]float64, len(closes))
+	}
+	return elderRayFromEMA(highs, lows, CalculateEMA(closes, period), period)
+}
+
+// elderRayFromEMA is CalculateElderRay from the EMA of closes over period.
+func elderRayFromEMA(highs, lows, ema []float64, period int) (bull, bear []float64) {
+	bull = make([]float64, len(ema))
+	bear = make([]float64, len(ema))
+	for i := period - 1; i < len(ema); i++ {
+		bull[i] = highs[i] - ema[i]
+		bear[i] = lows[i] - ema[i]
+	}
+	return bull, bear
+}
+
+// CalculateFisher returns Ehlers' Fisher transform of the bar midpoints
+// over period and its trigger, the transform one bar earlier. Where the
+// midpoint sits in the period's range is smoothed and mapped through the
+// Fisher transform, which turns its roughly uniform spread into a nearly
+// Gaussian one with sharp extremes at the turning points.
+func CalculateFisher(highs, lows []float64, period int) (fisher, trigger []float64) {
+	fisher = make([]float64, len(highs))
+	trigger = make([]float64, len(highs))
+	if period <= 0 {
+		return fisher, trigger
+	}
+
+	mids := make([]float64, len(highs))
+	for i := range highs {
+		mids[i] = (highs[i] + lows[i]) / 2
+	}
+	highest, lowest := rollingMax(mids, period), rollingMin(mids, period)
+	var value, prev float64
+	for i := period - 1; i < len(highs); i++ {
+		position := 0.5
+		if highest[i] > lowest[i] {
+			position = (mids[i] - lowest[i]) / (highest[i] - lowest[i])
+		}
+		// The transform is infinite at ±1, so the value is kept inside.
+		value = max(-0.999, min(0.999, 0.66*(position-0.5)+0.67*value))
+		fisher[i] = 0.5*math.Log((1+value)/(1-value)) + 0.5*prev
+		trigger[i] = prev
+		prev = fisher[i]
+	}
+	return fisher, trigger
+}
+
+// CalculateATR returns Wilder's average true range over period. The first
+// value, at index period, is the mean true range of the bars before it.
+func CalculateATR(highs, lows, closes []float64, period int) []float64 {
+	return CalculateATRInto(make([]float64, len(closes)), highs, lows, closes, period)
+}
+
+// CalculateATRInto is CalculateATR writing into dst, which must be zeroed
+// and at least as long as closes, as buffers from GetFloats are. True
+// ranges are computed bar by bar, never stored.
+func CalculateATRInto(dst, highs, lows, closes []float64, period int) []float64 {
+	atr := dst[:len(closes)]
+	if period <= 0 || len(closes) <= period {
+		return atr
+	}
+
+	var sum float64
+	for i := 1; i <= period; i++ {
+		sum += trueRange(highs, lows, closes, i)
+	}
+	atr[period] = sum / float64(period)
+	for i := period + 1; i < len(closes); i++ {
+		atr[i] = (atr[i-1]*float64(period-1) + trueRange(highs, lows, closes, i)) / float64(period)
+	}
+	return atr
+}
+
+// CalculateKeltner returns Keltner Channels: the EMA of closes over period
+// and bands multiplier ATRs over atrPeriod above and below it. Bands are 0
+// until both averages are warm.
+func CalculateKeltner(highs, lows, closes []float64, period, atrPeriod int, multiplier float64) (upper, middle, lower []float64) {
+	middle = CalculateEMA(closes, period)
+	if period <= 0 || atrPeriod <= 0 {
+		return make([]float64, len(closes)), middle, make([]float64, len(closes))
+	}
+	buf := GetFloats(len(closes))
+	defer PutFloats(buf)
+	upper, lower = keltnerBands(middle, CalculateATRInto(*buf, highs, lows, closes, atrPeriod), period, atrPeriod, multiplier)
+	return upper, middle, lower
+}
+
+// keltnerBands returns the Keltner bands around middle, the EMA over
+// period, from the ATR over atrPeriod.
+func keltnerBands(middle, atr []float64, period, atrPeriod int, multiplier float64) (upper, lower []float64) {
+	upper = make([]float64, len(middle))
+	lower = make([]float64, len(middle))
+	for i := max(period-1, atrPeriod); i < len(middle); i++ {
+		upper[i] = middle[i] + multiplier*atr[i]
+		lower[i] = middle[i] - multiplier*atr[i]
+	}
+	return upper, lower
+}
+
+// trueRange returns the range of bar i extended to the previous close.
+func trueRange(highs, lows, closes []float64, i int) float64 {
+	return max(highs[i], closes[i-1]) - min(lows[i], closes[i-1])
+}
+
+// CalculateSuperTrend returns the SuperTrend line, multiplier ATRs over
+// period below (uptrend) or above (downtrend) the bar midpoint, and its
+// direction: 1 in an uptrend, -1 in a downtrend and 0 before the first
+// ATR. Each band only moves towards price while price stays on its side,
+// and the trend flips when a close crosses the band it trails.
+func CalculateSuperTrend(highs, lows, closes []float64, period int, multiplier float64) ([]float64, []int) {
+	buf := GetFloats(len(closes))
+	defer PutFloats(buf)
+	return superTrendFromATR(highs, lows, closes, CalculateATRInto(*buf, highs, lows, closes, period), period, multiplier)
+}
+
+// superTrendFromATR is CalculateSuperTrend from the ATR over period.
+func superTrendFromATR(highs, lows, closes, atr []float64, period int, multiplier float64) ([]float64, []int) {
+	line := make([]float64, len(closes))
+	direction := make([]int, len(closes))
+	if period <= 0 || len(closes) <= period {
+		return line, direction
+	}
+
+	var upper, lower float64
+	for i := period; i < len(closes); i++ {
+		mid := (highs[i] + lows[i]) / 2
+		basicUpper, basicLower := mid+multiplier*atr[i], mid-multiplier*atr[i]
+		if i == period {
+			upper, lower = basicUpper, basicLower
+			direction[i] = 1
+			if closes[i] < mid {
+				direction[i] = -1
+			}
+		} else {
+			if basicUpper < upper || closes[i-1] > upper {
+				upper = basicUpper
+			}
+			if basicLower > lower || closes[i-1] < lower {
+				lower = basicLower
+			}
+			direction[i] = direction[i-1]
+			if direction[i] < 0 && closes[i] > upper {
+				direction[i] = 1
+			} else if direction[i] > 0 && closes[i] < lower {
+				direction[i] = -1
+			}
+		}
+
+		line[i] = upper
+		if direction[i] > 0 {
+			line[i] = lower
+		}
+	}
+	return line, direction
+}
+
+// CalculateADX returns Wilder's average directional index over period with
+// its +DI and -DI lines, all from 0 to 100. The DI lines start at index
+// period and the ADX, which smooths their spread, at 2*period - 1.
+func CalculateADX(highs, lows, closes []float64, period int) (adx, plusDI, minusDI []float64) {
+	adx = make([]float64, len(closes))
+	plusDI = make([]float64, len(closes))
+	minusDI = make([]float64, len(closes))
+	if period <= 0 || len(closes) <= period {
+		return adx, plusDI, minusDI
+	}
+
+	var smoothedTR, plusDM, minusDM, dxSum float64
+	for i := 1; i < len(closes); i++ {
+		up, down := highs[i]-highs[i-1], lows[i-1]-lows[i]
+		plus, minus := 0.0, 0.0
+		if up > down && up > 0 {
+			plus = up
+		}
+		if down > up && down > 0 {
+			minus = down
+		}
+		tr := trueRange(highs, lows, closes, i)
+
+		// Wilder's smoothing keeps running sums: seeded with the first
+		// period values, then decayed by 1/period per bar.
+		if i <= period {
+			smoothedTR += tr
+			plusDM += plus
+			minusDM += minus
+			if i < period {
+				continue
+			}
+		} else {
+			smoothedTR += tr - smoothedTR/float64(period)
+			plusDM += plus - plusDM/float64(period)
+			minusDM += minus - minusDM/float64(period)
+		}
+
+		if smoothedTR > 0 {
+			plusDI[i] = 100 * plusDM / smoothedTR
+			minusDI[i] = 100 * minusDM / smoothedTR
+		}
+		var dx float64
+		if sum := plusDI[i] + minusDI[i]; sum > 0 {
+			dx = 100 * math.Abs(plusDI[i]-minusDI[i]) / sum
+		}
+
+		switch {
+		case i < 2*period-1:
+			dxSum += dx
+		case i == 2*period-1:
+			adx[i] = (dxSum + dx) / float64(period)
+		default:
+			adx[i] = (adx[i-1]*float64(period-1) + dx) / float64(period)
+		}
+	}
+	return adx, plusDI, minusDI
+}
+
+// CalculateIchimoku returns the Ichimoku cloud of a series. Tenkan-sen and
+// Kijun-sen are the midpoints of the high-low range over the tenkan and
+// kijun periods. The Senkou spans are plotted displacement bars ahead, so
+// their series run displacement entries past the last candle: Senkou A is
+// the mean of Tenkan and Kijun, Senkou B the midpoint over senkouB bars.
+// Chikou is the close plotted displacement bars back, leaving the last
+// displacement entries 0.
+func CalculateIchimoku(highs, lows, closes []float64, tenkan, kijun, senkouB, displacement int) models.Ichimoku {
+	n := len(closes)
+	ichimoku := models.Ichimoku{
+		Tenkan:  midpoints(highs, lows, tenkan),
+		Kijun:   midpoints(highs, lows, kijun),
+		SenkouA: make([]float64, n+displacement),
+		SenkouB: make([]float64, n+displacement),
+		Chikou:  make([]float64, n),
+	}
+	if kijun > 0 && tenkan > 0 {
+		for i := max(tenkan, kijun) - 1; i < n; i++ {
+			ichimoku.SenkouA[i+displacement] = (ichimoku.Tenkan[i] + ichimoku.Kijun[i]) / 2
+		}
+	}
+	copy(ichimoku.SenkouB[displacement:], midpoints(highs, lows, senkouB))
+	for i := displacement; i < n; i++ {
+		ichimoku.Chikou[i-displacement] = closes[i]
+	}
+	return ichimoku
+}
+
+// midpoints returns the midpoint of the highest high and lowest low of the
+// last period bars.
+func midpoints(highs, lows []float64, period int) []float64 {
+	_, mid, _ := CalculateDonchian(highs, lows, period)
+	return mid
+}
+
+// MaxIchimokuDisplacement bounds the displacement of the Senkou spans,
+// whose series and projected times run that many entries past the last
+// candle.
+const MaxIchimokuDisplacement = 500
+
+// projectTimes extends the candle times count bars past the last candle at
+// the spacing of the last two candles.
+func projectTimes(candles []models.OHLC, count int) []time.Time {
+	times := make([]time.Time, 0, count)
+	if len(candles) < 2 {
+		return times
+	}
+	last := candles[len(candles)-1].Time
+	step := last.Sub(candles[len(candles)-2].Time)
+	for i := 1; i <= count; i++ {
+		times = append(times, last.Add(time.Duration(i)*step))
+	}
+	return times
+}
+
+// CalculateOBV returns the on-balance volume: the running total of volume,
+// added on up closes and subtracted on down closes, starting at 0.
+func CalculateOBV(closes, volumes []float64) []float64 {
+	obv := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		obv[i] = obv[i-1]
+		switch {
+		case closes[i] > closes[i-1]:
+			obv[i] += volumes[i]
+		case closes[i] < closes[i-1]:
+			obv[i] -= volumes[i]
+		}
+	}
+	return obv
+}
+
+// CalculateMFI returns the money flow index over period, an RSI of money
+// flow: typical price times volume, counted as positive when the typical
+// price rose and negative when it fell. The first value is at index
+// period.
+func CalculateMFI(candles []models.OHLC, period int) []float64 {
+	mfi := make([]float64, len(candles))
+	if period <= 0 || len(candles) <= period {
+		return mfi
+	}
+
+	flow := func(i int) (positive, negative float64) {
+		tp, prev := typicalPrice(candles[i]), typicalPrice(candles[i-1])
+		switch {
+		case tp > prev:
+			return tp * candles[i].Volume, 0
+		case tp < prev:
+			return 0, tp * candles[i].Volume
+		}
+		return 0, 0
+	}
+	var positive, negative float64
+	for i := 1; i < len(candles); i++ {
+		p, n := flow(i)
+		positive += p
+		negative += n
+		if i > period {
+			p, n := flow(i - period)
+			positive -= p
+			negative -= n
+		}
+		if i >= period {
+			// Rounding can leave a window that lost all its flow just off 0.
+			mfi[i] = rsiValue(max(positive, 0), max(negative, 0))
+		}
+	}
+	return mfi
+}
+
+// CalculateCCI returns the commodity channel index over period: how far
+// the typical price is from its SMA, in units of 0.015 mean absolute
+// deviations, so that most values fall between -100 and 100. A window
+// without deviation gives 0.
+func CalculateCCI(candles []models.OHLC, period int) []float64 {
+	cci := make([]float64, len(candles))
+	typical := make([]float64, len(candles))
+	for i, candle := range candles {
+		typical[i] = typicalPrice(candle)
+	}
+	sma := CalculateSMA(typical, period)
+	if period <= 0 || len(candles) < period {
+		return cci
+	}
+
+	for i := period - 1; i < len(candles); i++ {
+		var deviation float64
+		for _, tp := range typical[i-period+1 : i+1] {
+			deviation += math.Abs(tp - sma[i])
+		}
+		if deviation > 0 {
+			cci[i] = (typical[i] - sma[i]) / (0.015 * deviation / float64(period))
+		}
+	}
+	return cci
+}
+
+// CalculateTRIX returns TRIX over period: the percent change from the
+// previous bar of the triple smoothed EMA of prices. The smoothing filters
+// out cycles shorter than the period, leaving the trend's momentum.
+func CalculateTRIX(prices []float64, period int) []float64 {
+	trix := make([]float64, len(prices))
+	warm := 3 * (period - 1)
+	if period <= 0 || len(prices) <= warm+1 {
+		return trix
+	}
+
+	single := CalculateEMA(prices, period)
+	double := emaFrom(single, period-1, period)
+	triple := emaFrom(double, 2*(period-1), period)
+	for i := warm + 1; i < len(prices); i++ {
+		if triple[i-1] != 0 {
+			trix[i] = 100 * (triple[i] - triple[i-1]) / triple[i-1]
+		}
+	}
+	return trix
+}
+
+// CalculateCoppock returns the Coppock curve: the WMA over wmaPeriod of
+// the sum of the longROC and shortROC bar rates of change. Turning up
+// from below zero, it marks the end of a long decline.
+func CalculateCoppock(prices []float64, longROC, shortROC, wmaPeriod int) []float64 {
+	if longROC <= 0 || shortROC <= 0 || wmaPeriod <= 0 {
+		return make([]float64, len(prices))
+	}
+
+	long, short := rateOfChange(prices, longROC), rateOfChange(prices, shortROC)
+	start := max(longROC, shortROC)
+	sum := make([]float64, len(prices))
+	for i := start; i < len(prices); i++ {
+		sum[i] = long[i] + short[i]
+	}
+	return wmaFrom(sum, start, wmaPeriod)
+}
+
+// kstROC and kstSMA are Pring's periods of the four rates of change that
+// the Know Sure Thing sums, and of the SMAs that smooth them.
+var (
+	kstROC = [4]int{10, 15, 20, 30}
+	kstSMA = [4]int{10, 10, 10, 15}
+)
+
+// CalculateKST returns Pring's Know Sure Thing with its usual settings:
+// the sum of four smoothed rates of change, from 10 to 30 bars, weighted
+// 1 to 4 so the slower ones dominate, and its 9-bar SMA signal line.
+func CalculateKST(prices []float64) (kst, signal []float64) {
+	kst = make([]float64, len(prices))
+	var start int
+	for k := range kstROC {
+		start = max(start, kstROC[k]+kstSMA[k]-1)
+	}
+	if len(prices) <= start {
+		return kst, make([]float64, len(prices))
+	}
+
+	for k := range kstROC {
+		smoothed := smaFrom(rateOfChange(prices, kstROC[k]), kstROC[k], kstSMA[k])
+		for i := start; i < len(prices); i++ {
+			kst[i] += float64(k+1) * smoothed[i]
+		}
+	}
+	return kst, smaFrom(kst, start, 9)
+}
+
+// rateOfChange returns the percent change of prices over period bars, 0
+// where the earlier price is 0.
+func rateOfChange(prices []float64, period int) []float64 {
+	roc := make([]float64, len(prices))
+	for i := period; i < len(prices); i++ {
+		if prices[i-period] != 0 {
+			roc[i] = 100 * (prices[i] - prices[i-period]) / prices[i-period]
+		}
+	}
+	return roc
+}
+
+// CalculateSTC returns the Schaff trend cycle: the MACD of prices, the EMA
+// over fast less the EMA over slow, put through a stochastic over cycle
+// bars twice, each pass smoothed halfway towards the new value. It swings
+// between 0 and 100 faster than the MACD, turning at 25 and 75.
+func CalculateSTC(prices []float64, fast, slow, cycle int) []float64 {
+	if fast <= 0 || slow <= 0 || cycle <= 0 {
+		return make([]float64, len(prices))
+	}
+
+	start := max(fast, slow) - 1
+	fastEMA, slowEMA := CalculateEMA(prices, fast), CalculateEMA(prices, slow)
+	macd := make([]float64, len(prices))
+	for i := start; i < len(prices); i++ {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	first := schaffPass(macd, start, cycle)
+	return schaffPass(first, start+cycle-1, cycle)
+}
+
+// schaffPass is one stochastic pass of the Schaff trend cycle over values
+// from index start on. A flat window repeats the previous %K.
+func schaffPass(values []float64, start, cycle int) []float64 {
+	smoothed := make([]float64, len(values))
+	if start+cycle-1 >= len(values) {
+		return smoothed
+	}
+
+	highest, lowest := rollingMax(values[start:], cycle), rollingMin(values[start:], cycle)
+	k := 50.0
+	for i := start + cycle - 1; i < len(values); i++ {
+		j := i - start
+		if highest[j] > lowest[j] {
+			k = 100 * (values[i] - lowest[j]) / (highest[j] - lowest[j])
+		}
+		if i == start+cycle-1 {
+			smoothed[i] = k
+		} else {
+			smoothed[i] = smoothed[i-1] + 0.5*(k-smoothed[i-1])
+		}
+	}
+	return smoothed
+}
+
+// CalculateVWAP returns the volume weighted average price of candles,
+// anchored to sessions that start at midnight in location: the average
+// of each candle's typical price, (high + low + close) / 3, weighted by
+// volume since the session began. It is 0 until a session has traded
+// volume.
+func CalculateVWAP(candles []models.OHLC, location *time.Location) []float64 {
+	vwap := make([]float64, len(candles))
+	var session time.Time
+	var priceVolume, volume float64
+	for i, candle := range candles {
+		y, m, d := candle.Time.In(location).Date()
+		if day := time.Date(y, m, d, 0, 0, 0, 0, location); !day.Equal(session) {
+			session = day
+			priceVolume, volume = 0, 0
+		}
+		priceVolume += typicalPrice(candle) * candle.Volume
+		volume += candle.Volume
+		if volume > 0 {
+			vwap[i] = priceVolume / volume
+		}
+	}
+	return vwap
+}
+
+// CalculateRollingVWAP returns the volume weighted average price of the
+// last period candles. Windows without volume give 0.
+func CalculateRollingVWAP(candles []models.OHLC, period int) []float64 {
+	vwap := make([]float64, len(candles))
+	if period <= 0 || len(candles) < period {
+		return vwap
+	}
+
+	var priceVolume, volume float64
+	for i, candle := range candles {
+		priceVolume += typicalPrice(candle) * candle.Volume
+		volume += candle.Volume
+		if i >= period {
+			priceVolume -= typicalPrice(candles[i-period]) * candles[i-period].Volume
+			volume -= candles[i-period].Volume
+		}
+		if i >= period-1 && volume > 0 {
+			vwap[i] = priceVolume / volume
+		}
+	}
+	return vwap
+}
+
+func typicalPrice(candle models.OHLC) float64 {
+	return (candle.High + candle.Low + candle.Close) / 3
+}
+
+// smaFrom returns the SMA over period of values from index start on,
+// skipping the warm-up zeros of the series it smooths.
+func smaFrom(values []float64, start, period int) []float64 {
+	sma := make([]float64, len(values))
+	if start >= len(values) {
+		return sma
+	}
+	copy(sma[start:], CalculateSMA(values[start:], period))
+	return sma
+}
+
+// emaFrom is smaFrom for the EMA.
+func emaFrom(values []float64, start, period int) []float64 {
+	ema := make([]float64, len(values))
+	if start >= len(values) {
+		return ema
+	}
+	copy(ema[start:], CalculateEMA(values[start:], period))
+	return ema
+}
+
+// wmaFrom is smaFrom for the WMA.
+func wmaFrom(values []float64, start, period int) []float64 {
+	wma := make([]float64, len(values))
+	if start >= len(values) {
+		return wma
+	}
+	copy(wma[start:], CalculateWMA(values[start:], period))
+	return wma
+}
+
+// CalculateIndicators computes every indicator of an indicator request. It
+// fails only for an unknown VWAP timezone or moving average.
+func CalculateIndicators(req models.IndicatorRequest) (models.IndicatorResponse, error) {
+	return CachedIndicators(NewCache(req.Candles), req)
+}
+
+// CachedIndicators is CalculateIndicators over the cache of the request's
+// candles. Indicators built on the same EMA, RSI or ATR, such as the RSI
+// and QQE or the ATR, Keltner Channels and SuperTrend at their default
+// periods, compute it once, and so do other users of the cache.
+func CachedIndicators(cache *Cache, req models.IndicatorRequest) (models.IndicatorResponse, error) {
+	rsiPeriod := req.RSIPeriod
+	if rsiPeriod == 0 {
+		rsiPeriod = 14
+	}
+	bollinger := req.Bollinger
+	if bollinger.Period == 0 {
+		bollinger.Period = 20
+	}
+	if bollinger.StdDev == 0 {
+		bollinger.StdDev = 2
+	}
+	stochastic := req.Stochastic
+	if stochastic.KPeriod == 0 {
+		stochastic.KPeriod = 14
+	}
+	if stochastic.Smooth == 0 {
+		stochastic.Smooth = 3
+	}
+	if stochastic.DPeriod == 0 {
+		stochastic.DPeriod = 3
+	}
+	williamsR := req.WilliamsRPeriod
+	if williamsR == 0 {
+		williamsR = 14
+	}
+	keltner := req.Keltner
+	if keltner.Period == 0 {
+		keltner.Period = 20
+	}
+	if keltner.ATRPeriod == 0 {
+		keltner.ATRPeriod = 10
+	}
+	if keltner.Multiplier == 0 {
+		keltner.Multiplier = 2
+	}
+	donchianPeriod := req.DonchianPeriod
+	if donchianPeriod == 0 {
+		donchianPeriod = 20
+	}
+	elderRayPeriod := req.ElderRayPeriod
+	if elderRayPeriod == 0 {
+		elderRayPeriod = 13
+	}
+	aroonPeriod := req.AroonPeriod
+	if aroonPeriod == 0 {
+		aroonPeriod = 25
+	}
+	fisherPeriod := req.FisherPeriod
+	if fisherPeriod == 0 {
+		fisherPeriod = 10
+	}
+	inverseFisher := req.InverseFisherRSI
+	if inverseFisher.RSIPeriod == 0 {
+		inverseFisher.RSIPeriod = 5
+	}
+	if inverseFisher.WMAPeriod == 0 {
+		inverseFisher.WMAPeriod = 9
+	}
+	atrPeriod := req.ATRPeriod
+	if atrPeriod == 0 {
+		atrPeriod = 14
+	}
+	superTrend := req.SuperTrend
+	if superTrend.Period == 0 {
+		superTrend.Period = 10
+	}
+	if superTrend.Multiplier == 0 {
+		superTrend.Multiplier = 3
+	}
+	adxPeriod := req.ADXPeriod
+	if adxPeriod == 0 {
+		adxPeriod = 14
+	}
+	ichimoku := req.Ichimoku
+	if ichimoku.Tenkan == 0 {
+		ichimoku.Tenkan = 9
+	}
+	if ichimoku.Kijun == 0 {
+		ichimoku.Kijun = 26
+	}
+	if ichimoku.SenkouB == 0 {
+		ichimoku.SenkouB = 52
+	}
+	if ichimoku.Displacement == 0 {
+		ichimoku.Displacement = 26
+	}
+	if ichimoku.Displacement > MaxIchimokuDisplacement {
+		return models.IndicatorResponse{}, fmt.Errorf("ichimoku displacement must be at most %d", MaxIchimokuDisplacement)
+	}
+	mfiPeriod := req.MFIPeriod
+	if mfiPeriod == 0 {
+		mfiPeriod = 14
+	}
+	cciPeriod := req.CCIPeriod
+	if cciPeriod == 0 {
+		cciPeriod = 20
+	}
+	trixPeriod := req.TRIXPeriod
+	if trixPeriod == 0 {
+		trixPeriod = 15
+	}
+	coppock := req.Coppock
+	if coppock.LongROC == 0 {
+		coppock.LongROC = 14
+	}
+	if coppock.ShortROC == 0 {
+		coppock.ShortROC = 11
+	}
+	if coppock.WMAPeriod == 0 {
+		coppock.WMAPeriod = 10
+	}
+	stc := req.STC
+	if stc.Fast == 0 {
+		stc.Fast = 23
+	}
+	if stc.Slow == 0 {
+		stc.Slow = 50
+	}
+	if stc.Cycle == 0 {
+		stc.Cycle = 10
+	}
+	qqe := req.QQE
+	if qqe.RSIPeriod == 0 {
+		qqe.RSIPeriod = 14
+	}
+	if qqe.Smoothing == 0 {
+		qqe.Smoothing = 5
+	}
+	if qqe.Factor == 0 {
+		qqe.Factor = 4.236
+	}
+	volumePeriod := req.VolumePeriod
+	if volumePeriod == 0 {
+		volumePeriod = 20
+	}
+	vwap := req.VWAP
+	if vwap.Period == 0 {
+		vwap.Period = 20
+	}
+	location := time.UTC
+	if vwap.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(vwap.Timezone); err != nil {
+			return models.IndicatorResponse{}, fmt.Errorf("invalid vwap timezone: %w", err)
+		}
+	}
+
+	closes, highs, lows := cache.Closes(), cache.Highs(), cache.Lows()
+	response := models.IndicatorResponse{
+		EMA50:  cache.EMA(50),
+		EMA200: cache.EMA(200),
+		RSI:    cache.RSI(rsiPeriod),
+	}
+
+	bands := &response.Bollinger
+	bands.Upper, bands.Middle, bands.Lower = CalculateBollingerBands(closes, bollinger.Period, bollinger.StdDev)
+	bands.Bandwidth = make([]float64, len(closes))
+	for i, middle := range bands.Middle {
+		if middle != 0 {
+			bands.Bandwidth[i] = (bands.Upper[i] - bands.Lower[i]) / middle
+		}
+	}
+
+	channels := &response.Keltner
+	channels.Middle = cache.EMA(keltner.Period)
+	channels.Upper, channels.Lower = keltnerBands(channels.Middle, cache.ATR(keltner.ATRPeriod), keltner.Period, keltner.ATRPeriod, keltner.Multiplier)
+	channels.Squeeze = make([]bool, len(closes))
+	for i := range closes {
+		if channels.Upper[i] != 0 && bands.Upper[i] != 0 {
+			channels.Squeeze[i] = bands.Upper[i] < channels.Upper[i] && bands.Lower[i] > channels.Lower[i]
+		}
+	}
+
+	donchian := &response.Donchian
+	donchian.Upper, donchian.Middle, donchian.Lower = CalculateDonchian(highs, lows, donchianPeriod)
+
+	fast, slow := &response.Stochastic.Fast, &response.Stochastic.Slow
+	fast.K, fast.D = CalculateStochastic(highs, lows, closes, stochastic.KPeriod, 1, stochastic.DPeriod)
+	slow.K, slow.D = CalculateStochastic(highs, lows, closes, stochastic.KPeriod, stochastic.Smooth, stochastic.DPeriod)
+	response.WilliamsR = CalculateWilliamsR(highs, lows, closes, williamsR)
+
+	elder := &response.ElderRay
+	elder.BullPower, elder.BearPower = elderRayFromEMA(highs, lows, cache.EMA(elderRayPeriod), elderRayPeriod)
+
+	aroon := &response.Aroon
+	aroon.Up, aroon.Down, aroon.Oscillator = CalculateAroon(highs, lows, aroonPeriod)
+
+	fisher := &response.Fisher
+	fisher.Fisher, fisher.Trigger = CalculateFisher(highs, lows, fisherPeriod)
+	response.InverseFisherRSI = inverseFisherFromRSI(cache.RSI(inverseFisher.RSIPeriod), inverseFisher.RSIPeriod, inverseFisher.WMAPeriod)
+
+	response.ATR = cache.ATR(atrPeriod)
+	st := &response.SuperTrend
+	st.Line, st.Direction = superTrendFromATR(highs, lows, closes, cache.ATR(superTrend.Period), superTrend.Period, superTrend.Multiplier)
+
+	adx := &response.ADX
+	adx.ADX, adx.PlusDI, adx.MinusDI = CalculateADX(highs, lows, closes, adxPeriod)
+
+	response.Ichimoku = CalculateIchimoku(highs, lows, closes, ichimoku.Tenkan, ichimoku.Kijun, ichimoku.SenkouB, ichimoku.Displacement)
+	response.Ichimoku.Projection = projectTimes(req.Candles, ichimoku.Displacement)
+
+	volumes := Volumes(req.Candles)
+	response.Volume = models.Volume{
+		OBV: CalculateOBV(closes, volumes),
+		SMA: CalculateSMA(volumes, volumePeriod),
+		EMA: CalculateEMA(volumes, volumePeriod),
+	}
+
+	response.MovingAverages = make(map[string][]float64, len(req.MovingAverages))
+	for _, ma := range req.MovingAverages {
+		average, err := cache.MovingAverage(ma.Type, ma.Period)
+		if err != nil {
+			return models.IndicatorResponse{}, err
+		}
+		response.MovingAverages[fmt.Sprintf("%s_%d", ma.Type, ma.Period)] = average
+	}
+	response.WMA = make(map[int][]float64, len(req.WMAPeriods))
+	for _, period := range req.WMAPeriods {
+		response.WMA[period], _ = cache.MovingAverage("wma", period)
+	}
+	response.VWMA = make(map[int][]float64, len(req.VWMAPeriods))
+	for _, period := range req.VWMAPeriods {
+		response.VWMA[period] = CalculateVWMA(closes, volumes, period)
+	}
+
+	response.MFI = CalculateMFI(req.Candles, mfiPeriod)
+	response.CCI = CalculateCCI(req.Candles, cciPeriod)
+
+	response.TRIX = CalculateTRIX(closes, trixPeriod)
+	response.Coppock = CalculateCoppock(closes, coppock.LongROC, coppock.ShortROC, coppock.WMAPeriod)
+	response.KST.KST, response.KST.Signal = CalculateKST(closes)
+	response.STC = CalculateSTC(closes, stc.Fast, stc.Slow, stc.Cycle)
+	q := &response.QQE
+	q.Line, q.Trailing, q.Trend = qqeFromRSI(cache.RSI(qqe.RSIPeriod), qqe.RSIPeriod, qqe.Smoothing, qqe.Factor)
+
+	response.VWAP = models.VWAP{
+		Session: CalculateVWAP(req.Candles, location),
+		Rolling: CalculateRollingVWAP(req.Candles, vwap.Period),
+	}
+	return response, nil
+}
+
+// Closes returns the close prices of candles.
+func Closes(candles []models.OHLC) []float64 {
+	closes := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.Close
+	}
+	return closes
+}
+
+// Highs returns the high prices of candles.
+func Highs(candles []models.OHLC) []float64 {
+	highs := make([]float64, len(candles))
+	for i, candle := range candles {
+		highs[i] = candle.High
+	}
+	return highs
+}
+
+// Lows returns the low prices of candles.
+func Lows(candles []models.OHLC) []float64 {
+	lows := make([]float64, len(candles))
+	for i, candle := range candles {
+		lows[i] = candle.Low
+	}
+	return lows
+}
+
+// Volumes returns the volumes of candles.
+func Volumes(candles []models.OHLC) []float64 {
+	volumes := make([]float64, len(candles))
+	for i, candle := range candles {
+		volumes[i] = candle.Volume
+	}
+	return volumes
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abs/go_billing/models"
+)
+
+// Pivot periods.
+const (
+	PivotDaily  = "daily"
+	PivotWeekly = "weekly"
+)
+
+// pivotOrder is the order of the pivot methods computed when none are
+// selected.
+var pivotOrder = []string{"classic", "fibonacci", "camarilla", "woodie"}
+
+// pivotMethods compute the levels of a method from the prior period's OHLC
+// and the open of the period they apply to.
+var pivotMethods = map[string]func(prior models.OHLC, open float64) []models.PivotLevel{
+	"classic": func(prior models.OHLC, _ float64) []models.PivotLevel {
+		p := (prior.High + prior.Low + prior.Close) / 3
+		return floorLevels(p, prior)
+	},
+	"fibonacci": func(prior models.OHLC, _ float64) []models.PivotLevel {
+		p := (prior.High + prior.Low + prior.Close) / 3
+		r := prior.High - prior.Low
+		return []models.PivotLevel{
+			{Name: "pivot", Price: p},
+			{Name: "r1", Price: p + 0.382*r}, {Name: "r2", Price: p + 0.618*r}, {Name: "r3", Price: p + r},
+			{Name: "s1", Price: p - 0.382*r}, {Name: "s2", Price: p - 0.618*r}, {Name: "s3", Price: p - r},
+		}
+	},
+	// Camarilla levels sit around the prior close, at fractions of 1.1
+	// prior ranges.
+	"camarilla": func(prior models.OHLC, _ float64) []models.PivotLevel {
+		c, r := prior.Close, 1.1*(prior.High-prior.Low)
+		return []models.PivotLevel{
+			{Name: "pivot", Price: (prior.High + prior.Low + prior.Close) / 3},
+			{Name: "r1", Price: c + r/12}, {Name: "r2", Price: c + r/6}, {Name: "r3", Price: c + r/4}, {Name: "r4", Price: c + r/2},
+			{Name: "s1", Price: c - r/12}, {Name: "s2", Price: c - r/6}, {Name: "s3", Price: c - r/4}, {Name: "s4", Price: c - r/2},
+		}
+	},
+	// Woodie's pivot weighs the open of the new period twice.
+	"woodie": func(prior models.OHLC, open float64) []models.PivotLevel {
+		p := (prior.High + prior.Low + 2*open) / 4
+		return floorLevels(p, prior)
+	},
+}
+
+// floorLevels returns the floor trader levels around the pivot p.
+func floorLevels(p float64, prior models.OHLC) []models.PivotLevel {
+	r := prior.High - prior.Low
+	return []models.PivotLevel{
+		{Name: "pivot", Price: p},
+		{Name: "r1", Price: 2*p - prior.Low}, {Name: "r2", Price: p + r}, {Name: "r3", Price: prior.High + 2*(p-prior.Low)},
+		{Name: "s1", Price: 2*p - prior.High}, {Name: "s2", Price: p - r}, {Name: "s3", Price: prior.Low - 2*(prior.High-p)},
+	}
+}
+
+// CalculatePivots returns the pivot levels of the period, daily or weekly,
+// of the last candle, from the OHLC of the period before it in the
+// candles. Days start at midnight in location and weeks on Monday. As
+// zones, levels at or below the last close are bullish, supports, and
+// those above bearish, resistances; Touches counts the candles of the
+// period that traded at the level. Candles must be in time order.
+func CalculatePivots(candles []models.OHLC, period string, location *time.Location, methods []string) (models.Pivots, error) {
+	if period != PivotDaily && period != PivotWeekly {
+		return models.Pivots{}, fmt.Errorf("unknown pivot period %q", period)
+	}
+	if len(methods) == 0 {
+		methods = pivotOrder
+	}
+	if len(candles) == 0 {
+		return models.Pivots{}, errors.New("no candles")
+	}
+
+	last := len(candles) - 1
+	current := periodStart(candles[last].Time, period, location)
+	start := last
+	for start > 0 && periodStart(candles[start-1].Time, period, location).Equal(current) {
+		start--
+	}
+	if start == 0 {
+		return models.Pivots{}, fmt.Errorf("need candles from the %s period before the last one", period)
+	}
+
+	priorStart := periodStart(candles[start-1].Time, period, location)
+	prior := models.OHLC{Time: priorStart, High: candles[start-1].High, Low: candles[start-1].Low, Close: candles[start-1].Close}
+	first := start - 1
+	for ; first >= 0 && periodStart(candles[first].Time, period, location).Equal(priorStart); first-- {
+		prior.High = max(prior.High, candles[first].High)
+		prior.Low = min(prior.Low, candles[first].Low)
+		prior.Volume += candles[first].Volume
+	}
+	prior.Open = candles[first+1].Open
+
+	end := current.AddDate(0, 0, 1)
+	if period == PivotWeekly {
+		end = current.AddDate(0, 0, 7)
+	}
+	pivots := models.Pivots{Period: period, Start: current, End: end, Prior: prior, Sets: []models.PivotSet{}, Zones: []models.Zone{}}
+	for _, method := range methods {
+		levels, ok := pivotMethods[method]
+		if !ok {
+			return models.Pivots{}, fmt.Errorf("unknown pivot method %q", method)
+		}
+		set := models.PivotSet{Method: method, Levels: levels(prior, candles[start].Open)}
+		pivots.Sets = append(pivots.Sets, set)
+
+		for _, level := range set.Levels {
+			zone := models.Zone{
+				Type:       "pivot_" + method,
+				Direction:  models.Bearish,
+				Top:        level.Price,
+				Bottom:     level.Price,
+				StartIndex: start,
+				StartTime:  candles[start].Time,
+			}
+			if level.Price <= candles[last].Close {
+				zone.Direction = models.Bullish
+			}
+			for _, candle := range candles[start:] {
+				if candle.Low <= level.Price && candle.High >= level.Price {
+					zone.Touches++
+				}
+			}
+			pivots.Zones = append(pivots.Zones, zone)
+		}
+	}
+	return pivots, nil
+}
+
+// periodStart returns the midnight in location that starts the day, or
+// the Monday that starts the week, of t.
+func periodStart(t time.Time, period string, location *time.Location) time.Time {
+	y, m, d := t.In(location).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, location)
+	if period == PivotWeekly {
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	}
+	return day
+}
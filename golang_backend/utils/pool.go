@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"sync"
+
+	"github.com/abs/go_billing/models"
+)
+
+// floatPool holds scratch buffers for per-request temporaries. Pooled
+// slices are only for values that never leave the request: anything
+// returned to a caller or encoded in a response must be allocated
+// normally.
+var floatPool = sync.Pool{New: func() any { return new([]float64) }}
+
+// GetFloats returns a zeroed float64 buffer of length n from the pool.
+// Release it with PutFloats once it is no longer referenced.
+func GetFloats(n int) *[]float64 {
+	buf := floatPool.Get().(*[]float64)
+	if cap(*buf) < n {
+		*buf = make([]float64, n)
+	} else {
+		*buf = (*buf)[:n]
+		clear(*buf)
+	}
+	return buf
+}
+
+// PutFloats returns a buffer obtained from GetFloats to the pool.
+func PutFloats(buf *[]float64) {
+	floatPool.Put(buf)
+}
+
+// HighsInto is ClosesInto for the high prices.
+func HighsInto(dst []float64, candles []models.OHLC) []float64 {
+	dst = dst[:len(candles)]
+	for i, candle := range candles {
+		dst[i] = candle.High
+	}
+	return dst
+}
+
+// LowsInto is ClosesInto for the low prices.
+func LowsInto(dst []float64, candles []models.OHLC) []float64 {
+	dst = dst[:len(candles)]
+	for i, candle := range candles {
+		dst[i] = candle.Low
+	}
+	return dst
+}
+
+// ClosesInto writes the close prices of candles into dst, which must be at
+// least as long as candles, and returns it truncated to len(candles).
+func ClosesInto(dst []float64, candles []models.OHLC) []float64 {
+	dst = dst[:len(candles)]
+	for i, candle := range candles {
+		dst[i] = candle.Close
+	}
+	return dst
+}
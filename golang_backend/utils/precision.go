@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"math"
+	"strconv"
+)
+
+// RoundJSON rewrites every non-integer number in a JSON document rounded
+// to decimals places, leaving strings, keys and layout untouched. Integer
+// literals, and numbers too large to scale, are copied as they are.
+// Invalid JSON is copied through as far as it can be scanned.
+func RoundJSON(data []byte, decimals int) []byte {
+	out := make([]byte, 0, len(data))
+	scale := math.Pow(10, float64(decimals))
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '"':
+			// Copy the string through its closing quote, skipping escapes.
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(data))
+			out = append(out, data[i:j]...)
+			i = j
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			fractional := false
+			for j < len(data) && isNumberByte(data[j]) {
+				if data[j] == '.' || data[j] == 'e' || data[j] == 'E' {
+					fractional = true
+				}
+				j++
+			}
+			literal := data[i:j]
+			if v, err := strconv.ParseFloat(string(literal), 64); fractional && err == nil && !math.IsInf(v*scale, 0) {
+				out = strconv.AppendFloat(out, math.Round(v*scale)/scale, 'f', -1, 64)
+			} else {
+				out = append(out, literal...)
+			}
+			i = j
+		default:
+			out = append(out, c)
+			i++
+		}
+	}
+	return out
+}
+
+func isNumberByte(c byte) bool {
+	return c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-'
+}
+
+// Decimals returns the number of decimal places of a tick size such as
+// 0.01 or 0.25, capped at 12.
+func Decimals(tick float64) int {
+	for d := 0; d < 12; d++ {
+		if math.Abs(tick-math.Round(tick)) < 1e-9*math.Max(1, tick) {
+			return d
+		}
+		tick *= 10
+	}
+	return 12
+}
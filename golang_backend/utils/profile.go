@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type profileKey struct{}
+
+// Profile collects the time spent in the named stages of one request. A
+// nil *Profile records nothing, so code can time stages unconditionally.
+type Profile struct {
+	start  time.Time
+	mu     sync.Mutex
+	stages map[string]time.Duration
+}
+
+// WithProfile returns a context carrying a new profile started now.
+func WithProfile(ctx context.Context) (context.Context, *Profile) {
+	p := &Profile{start: time.Now(), stages: make(map[string]time.Duration)}
+	return context.WithValue(ctx, profileKey{}, p), p
+}
+
+// ProfileFrom returns the profile of ctx, or nil when it has none.
+func ProfileFrom(ctx context.Context) *Profile {
+	p, _ := ctx.Value(profileKey{}).(*Profile)
+	return p
+}
+
+// Time starts timing a stage and returns the function that stops it.
+// Stages timed more than once add up.
+func (p *Profile) Time(name string) func() {
+	if p == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() { p.Add(name, time.Since(start)) }
+}
+
+// Add records d against a stage.
+func (p *Profile) Add(name string, d time.Duration) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stages[name] += d
+}
+
+// Elapsed returns the time since the profile started.
+func (p *Profile) Elapsed() time.Duration {
+	return time.Since(p.start)
+}
+
+// Milliseconds returns the stage timings in milliseconds.
+func (p *Profile) Milliseconds() map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	timings := make(map[string]float64, len(p.stages))
+	for name, d := range p.stages {
+		timings[name] = float64(d) / float64(time.Millisecond)
+	}
+	return timings
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// timeframeUnits are the units of timeframes such as "15m", "4h" and "1d".
+var timeframeUnits = map[byte]time.Duration{
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+}
+
+// ParseTimeframe returns the candle duration of a timeframe: a positive
+// count followed by m, h, d or w. Months have no fixed duration and are
+// rejected.
+func ParseTimeframe(timeframe string) (time.Duration, error) {
+	if len(timeframe) < 2 {
+		return 0, fmt.Errorf("invalid timeframe %q", timeframe)
+	}
+	unit, ok := timeframeUnits[timeframe[len(timeframe)-1]]
+	count, err := strconv.Atoi(timeframe[:len(timeframe)-1])
+	if !ok || err != nil || count <= 0 {
+		return 0, fmt.Errorf("invalid timeframe %q: want a count and m, h, d or w", timeframe)
+	}
+	return time.Duration(count) * unit, nil
+}